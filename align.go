@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkAlignPhase checks the -align-phase used with -align-to-second is within a second
+func checkAlignPhase(phase time.Duration) error {
+	if phase < 0 || phase >= time.Second {
+		return fmt.Errorf("-align-phase %s must be at least 0 and less than 1s", phase)
+	}
+	return nil
+}
+
+// alignedStart returns the first instant at least lead after now that is phase past a whole second of
+// the wall clock.  Generators started with the same phase on synchronized clocks begin together.
+func alignedStart(now time.Time, phase, lead time.Duration) time.Time {
+	earliest := now.Add(lead)
+	start := earliest.Truncate(time.Second).Add(phase)
+	if start.Before(earliest) {
+		start = start.Add(time.Second)
+	}
+	return start
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlignedStart(t *testing.T) {
+	second := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Name     string
+		Now      time.Time
+		Phase    time.Duration
+		Lead     time.Duration
+		Expected time.Time
+	}{
+		{"NextSecond", second.Add(300 * time.Millisecond), 0, 80 * time.Millisecond, second.Add(time.Second)},
+		{"OnSecond", second.Add(-80 * time.Millisecond), 0, 80 * time.Millisecond, second},
+		{"LeadCrossesSecond", second.Add(950 * time.Millisecond), 0, 80 * time.Millisecond, second.Add(2 * time.Second)},
+		{"PhaseThisSecond", second.Add(100 * time.Millisecond), 500 * time.Millisecond, 80 * time.Millisecond, second.Add(500 * time.Millisecond)},
+		{"PhasePassed", second.Add(450 * time.Millisecond), 500 * time.Millisecond, 80 * time.Millisecond, second.Add(1500 * time.Millisecond)},
+		{"NoLead", second, 0, 0, second},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if start := alignedStart(c.Now, c.Phase, c.Lead); !start.Equal(c.Expected) {
+				st.Errorf("Expected start at %s, got %s", c.Expected, start)
+			}
+		})
+	}
+}
+
+func TestCheckAlignPhase(t *testing.T) {
+	testCases := []struct {
+		Phase       time.Duration
+		ExpectError bool
+	}{
+		{0, false},
+		{250 * time.Millisecond, false},
+		{time.Second - 1, false},
+		{time.Second, true},
+		{-time.Millisecond, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Phase.String(), func(st *testing.T) {
+			if err := checkAlignPhase(c.Phase); (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package biphase encodes LTC frames as biphase mark coded PCM, the line code used by SMPTE 12M
+package biphase
+
+import (
+	"fmt"
+	"math"
+)
+
+// Encoder generates biphase mark coded samples.  The level changes at the start of every bit and
+// again in the middle of each 1 bit.  The level is kept between calls to Encode so consecutive frames
+// follow on from each other.
+type Encoder struct {
+	sampleRate float64
+	bitRate    float64
+	amplitude  int32
+	sample     int64
+	bit        int64
+	level      bool
+}
+
+// NewEncoder returns an encoder producing bitRate bits a second at sampleRate, at +amplitude or
+// -amplitude.  Where the sample rate isn't a whole multiple of the bit rate, e.g. 44100Hz at 29.97fps,
+// bits are rounded to the nearest sample so they don't drift.  There must be at least 2 samples per
+// bit for the change in the middle of a 1.
+func NewEncoder(sampleRate float64, bitRate float64, amplitude int32) (*Encoder, error) {
+	if bitRate <= 0 || sampleRate < 2*bitRate {
+		return nil, fmt.Errorf("need at least 2 samples per bit, got %g", sampleRate/bitRate)
+	}
+	if amplitude <= 0 {
+		return nil, fmt.Errorf("amplitude must be positive, got %d", amplitude)
+	}
+	return &Encoder{sampleRate: sampleRate, bitRate: bitRate, amplitude: amplitude}, nil
+}
+
+// Encode appends the samples for frame to buf, bytes are sent most significant bit first as returned
+// by glitc.LTCFrame.EncodeFrame
+func (e *Encoder) Encode(buf []int32, frame []byte) []int32 {
+	for _, b := range frame {
+		for i := 7; i >= 0; i-- {
+			one := b>>uint(i)&0x1 == 1
+			e.level = !e.level
+
+			mid := float64(e.bit) + 0.5
+			e.bit++
+			end := int64(math.Round(float64(e.bit) * e.sampleRate / e.bitRate))
+			for ; e.sample < end; e.sample++ {
+				level := e.level
+				if one && float64(e.sample)*e.bitRate/e.sampleRate >= mid {
+					level = !level
+				}
+				buf = append(buf, e.levelSample(level))
+			}
+
+			if one {
+				e.level = !e.level
+			}
+		}
+	}
+	return buf
+}
+
+func (e *Encoder) levelSample(level bool) int32 {
+	if level {
+		return e.amplitude
+	}
+	return -e.amplitude
+}
+
+// Encode returns the samples for a single frame with a whole number of samples per bit, starting from
+// a low level
+func Encode(frame []byte, samplesPerBit int, amplitude int32) ([]int32, error) {
+	e, err := NewEncoder(float64(samplesPerBit), 1, amplitude)
+	if err != nil {
+		return nil, err
+	}
+	return e.Encode(make([]int32, 0, len(frame)*8*samplesPerBit), frame), nil
+}
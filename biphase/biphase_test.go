@@ -0,0 +1,132 @@
+package biphase_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/azenk/ltcgen/biphase"
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+)
+
+// transitions returns the sample indexes where the level changes, including the first sample, which
+// always changes from the initial low level
+func transitions(samples []int32) []int {
+	var t []int
+	prev := int32(-1)
+	for i, s := range samples {
+		if (s > 0) != (prev > 0) {
+			t = append(t, i)
+		}
+		prev = s
+	}
+	return t
+}
+
+func TestEncode(t *testing.T) {
+	samples, err := biphase.Encode([]byte{0x80}, 4, 100)
+	if err != nil {
+		t.Fatalf("Unable to encode: %v", err)
+	}
+	// a 1 changes level at the start and middle of the bit, each 0 only at the start
+	expected := []int32{
+		100, 100, -100, -100,
+		100, 100, 100, 100,
+		-100, -100, -100, -100,
+		100, 100, 100, 100,
+		-100, -100, -100, -100,
+		100, 100, 100, 100,
+		-100, -100, -100, -100,
+		100, 100, 100, 100,
+	}
+	if diff := deep.Equal(samples, expected); len(diff) > 0 {
+		t.Error("Samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestEncodeFrame(t *testing.T) {
+	// 00:00:00:00 at 25 fps, only the sync word and the clock flag are set
+	frame := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xFD}
+	samplesPerBit := 24
+	samples, err := biphase.Encode(frame, samplesPerBit, 1000)
+	if err != nil {
+		t.Fatalf("Unable to encode: %v", err)
+	}
+	if len(samples) != 80*samplesPerBit {
+		t.Fatalf("Expected %d samples, got %d", 80*samplesPerBit, len(samples))
+	}
+
+	var expected []int
+	for bit := 0; bit < 80; bit++ {
+		expected = append(expected, bit*samplesPerBit)
+		if frame[bit/8]>>uint(7-bit%8)&0x1 == 1 {
+			expected = append(expected, bit*samplesPerBit+samplesPerBit/2)
+		}
+	}
+	if diff := deep.Equal(transitions(samples), expected); len(diff) > 0 {
+		t.Error("Transitions don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestEncoderContinuous(t *testing.T) {
+	f := glitc.LTCFrame{FramesPerSecond: 30}
+	f.SetTimeCode(glitc.TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4}, f.Time)
+	// 48kHz at 30fps is 20 samples per bit
+	e, err := biphase.NewEncoder(48000, f.EffectiveFPS()*80, 1000)
+	if err != nil {
+		t.Fatalf("Unable to create encoder: %v", err)
+	}
+
+	// the polarity bit keeps every frame an even number of transitions long, so each one starts on
+	// the same level and the first bit of the next frame still changes level
+	var samples []int32
+	for i := 0; i < 3; i++ {
+		samples = e.Encode(samples, f.EncodeFrame())
+		if samples[len(samples)-1] >= 0 {
+			t.Errorf("Frame %d ends high, expected every frame to end low", i)
+		}
+		f.Time = f.Time.Add(f.FrameDuration())
+	}
+	for _, i := range []int{80 * 20, 2 * 80 * 20} {
+		if (samples[i-1] > 0) == (samples[i] > 0) {
+			t.Errorf("No transition at the frame boundary at sample %d", i)
+		}
+	}
+}
+
+func TestEncoderFractionalBits(t *testing.T) {
+	// 44100Hz at 29.97fps is 18.39 samples per bit, frames are 1471 or 1472 samples long
+	f := glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	e, err := biphase.NewEncoder(44100, f.EffectiveFPS()*80, 1000)
+	if err != nil {
+		t.Fatalf("Unable to create encoder: %v", err)
+	}
+
+	var samples []int32
+	for i := 1; i <= 100; i++ {
+		samples = e.Encode(samples, f.EncodeFrame())
+		expected := int(math.Round(float64(i) * 44100 / f.EffectiveFPS()))
+		if len(samples) != expected {
+			t.Fatalf("Expected %d samples after %d frames, got %d", expected, i, len(samples))
+		}
+		f.Time = f.Time.Add(f.FrameDuration())
+	}
+}
+
+func TestNewEncoderErrors(t *testing.T) {
+	if _, err := biphase.NewEncoder(48000, 48000, 100); err == nil {
+		t.Errorf("Expected an error with 1 sample per bit")
+	}
+	if _, err := biphase.NewEncoder(48000, 0, 100); err == nil {
+		t.Errorf("Expected an error with no bit rate")
+	}
+	if _, err := biphase.NewEncoder(48000, 2400, 0); err == nil {
+		t.Errorf("Expected an error with no amplitude")
+	}
+}
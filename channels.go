@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/azenk/audio/stream"
+)
+
+// ChannelMode selects what is written to an output channel
+type ChannelMode int
+
+const (
+	// ChannelSignal carries the LTC signal
+	ChannelSignal ChannelMode = iota
+	// ChannelInverted carries the phase inverted LTC signal, pair with ChannelSignal for a balanced feed
+	ChannelInverted
+	// ChannelSilent carries silence
+	ChannelSilent
+	// ChannelSignal2 carries the LTC signal of the second generator set with -rate2
+	ChannelSignal2
+	// ChannelInverted2 carries the phase inverted LTC signal of the second generator
+	ChannelInverted2
+)
+
+var channelModeNames = map[ChannelMode]string{
+	ChannelSignal:    "signal",
+	ChannelInverted:  "inverted",
+	ChannelSilent:    "silent",
+	ChannelSignal2:   "signal2",
+	ChannelInverted2: "inverted2",
+}
+
+func (m ChannelMode) String() string {
+	if name, ok := channelModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("ChannelMode(%d)", int(m))
+}
+
+// Apply returns the sample to write to a channel using this mode
+func (m ChannelMode) Apply(sample stream.Sample) stream.Sample {
+	switch m {
+	case ChannelInverted, ChannelInverted2:
+		if sample == math.MinInt32 {
+			return math.MaxInt32
+		}
+		return -sample
+	case ChannelSilent:
+		return 0
+	}
+	return sample
+}
+
+// generator returns the index of the generator whose signal the channel carries
+func (m ChannelMode) generator() int {
+	if m == ChannelSignal2 || m == ChannelInverted2 {
+		return 1
+	}
+	return 0
+}
+
+// FrameSample returns the sample to write to a channel from a sample frame holding one sample per
+// generator
+func (m ChannelMode) FrameSample(frame []stream.Sample) stream.Sample {
+	if g := m.generator(); g < len(frame) {
+		return m.Apply(frame[g])
+	}
+	return 0
+}
+
+// generatorCount returns the number of generators whose samples are interleaved in the stream sent
+// to a device with these channel modes, 2 if any channel carries the second generator
+func generatorCount(modes []ChannelMode) int {
+	count := 1
+	for _, mode := range modes {
+		if mode.generator()+1 > count {
+			count = mode.generator() + 1
+		}
+	}
+	return count
+}
+
+// polarity returns the mode applied to every sample before it is split into channels, -invert flips
+// the whole signal for readers that only lock to one polarity
+func polarity(invert bool) ChannelMode {
+	if invert {
+		return ChannelInverted
+	}
+	return ChannelSignal
+}
+
+// ltcChannelModes returns the modes for count output channels with the LTC signal on channel
+// ltcChannel, counted from 0, and silence on the rest, e.g. to send LTC on one channel of a
+// multichannel interface
+func ltcChannelModes(count, ltcChannel int) ([]ChannelMode, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("output channel count must be at least 1, got %d", count)
+	}
+	if ltcChannel < 0 || ltcChannel >= count {
+		return nil, fmt.Errorf("LTC channel %d is out of range for %d output channels, expected 0-%d", ltcChannel, count, count-1)
+	}
+	modes := make([]ChannelMode, count)
+	for i := range modes {
+		modes[i] = ChannelSilent
+	}
+	modes[ltcChannel] = ChannelSignal
+	return modes, nil
+}
+
+// ParseChannelModes parses a comma separated list of channel modes, one per output channel
+func ParseChannelModes(s string) ([]ChannelMode, error) {
+	var modes []ChannelMode
+	for _, name := range strings.Split(s, ",") {
+		found := false
+		for mode, modeName := range channelModeNames {
+			if strings.TrimSpace(name) == modeName {
+				modes = append(modes, mode)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown channel mode %q, expected one of signal, inverted, silent, signal2 or inverted2", name)
+		}
+	}
+	return modes, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/ltcgen/biphase"
+	"github.com/go-test/deep"
+)
+
+func TestParseChannelModes(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Input         string
+		ExpectedModes []ChannelMode
+		ExpectError   bool
+	}{
+		{"Mono", "signal", []ChannelMode{ChannelSignal}, false},
+		{"Balanced", "signal,inverted", []ChannelMode{ChannelSignal, ChannelInverted}, false},
+		{"Spaces", "signal, silent", []ChannelMode{ChannelSignal, ChannelSilent}, false},
+		{"SecondGenerator", "signal,signal2,inverted2", []ChannelMode{ChannelSignal, ChannelSignal2, ChannelInverted2}, false},
+		{"Unknown", "signal,left", nil, true},
+		{"Empty", "", nil, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			modes, err := ParseChannelModes(c.Input)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error parsing '%s', got %v", c.Input, modes)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unable to parse '%s': %v", c.Input, err)
+			}
+			if diff := deep.Equal(modes, c.ExpectedModes); len(diff) > 0 {
+				st.Error("Parsed modes don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestLTCChannelModes(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Count         int
+		LTCChannel    int
+		ExpectedModes []ChannelMode
+		ExpectError   bool
+	}{
+		{"Mono", 1, 0, []ChannelMode{ChannelSignal}, false},
+		{"Third", 4, 2, []ChannelMode{ChannelSilent, ChannelSilent, ChannelSignal, ChannelSilent}, false},
+		{"Last", 8, 7, []ChannelMode{ChannelSilent, ChannelSilent, ChannelSilent, ChannelSilent, ChannelSilent, ChannelSilent, ChannelSilent, ChannelSignal}, false},
+		{"OutOfRange", 4, 4, nil, true},
+		{"Negative", 4, -1, nil, true},
+		{"NoChannels", 0, 0, nil, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			modes, err := ltcChannelModes(c.Count, c.LTCChannel)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if diff := deep.Equal(modes, c.ExpectedModes); len(diff) > 0 {
+				st.Error("Channel modes don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestChannelModeApply(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Mode     ChannelMode
+		Sample   stream.Sample
+		Expected stream.Sample
+	}{
+		{"Signal", ChannelSignal, 1234, 1234},
+		{"Inverted", ChannelInverted, 1234, -1234},
+		{"InvertedMin", ChannelInverted, math.MinInt32, math.MaxInt32},
+		{"Silent", ChannelSilent, 1234, 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if sample := c.Mode.Apply(c.Sample); sample != c.Expected {
+				st.Errorf("Incorrect sample: got %d expected %d", sample, c.Expected)
+			}
+		})
+	}
+}
+
+func TestChannelModeFrameSample(t *testing.T) {
+	frame := []stream.Sample{1234, 5678}
+	testCases := []struct {
+		Name     string
+		Mode     ChannelMode
+		Frame    []stream.Sample
+		Expected stream.Sample
+	}{
+		{"Signal", ChannelSignal, frame, 1234},
+		{"Inverted", ChannelInverted, frame, -1234},
+		{"Silent", ChannelSilent, frame, 0},
+		{"Signal2", ChannelSignal2, frame, 5678},
+		{"Inverted2", ChannelInverted2, frame, -5678},
+		{"Signal2Mono", ChannelSignal2, frame[:1], 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if sample := c.Mode.FrameSample(c.Frame); sample != c.Expected {
+				st.Errorf("Incorrect sample: got %d expected %d", sample, c.Expected)
+			}
+		})
+	}
+
+	if count := generatorCount([]ChannelMode{ChannelSignal, ChannelInverted}); count != 1 {
+		t.Errorf("Expected 1 generator, got %d", count)
+	}
+	if count := generatorCount([]ChannelMode{ChannelSignal, ChannelInverted2}); count != 2 {
+		t.Errorf("Expected 2 generators, got %d", count)
+	}
+}
+
+func TestPolarity(t *testing.T) {
+	samples, err := biphase.Encode([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xFD}, 40, math.MaxInt32)
+	if err != nil {
+		t.Fatalf("Unable to encode frame: %v", err)
+	}
+
+	// the inverted signal is shaped the same way before it is negated
+	normal := newSlewLimiter(25*time.Microsecond, 96000, 1)
+	inverted := newSlewLimiter(25*time.Microsecond, 96000, 1)
+	for i, s := range samples {
+		n := polarity(false).Apply(normal.Apply(stream.Sample(s)))
+		inv := polarity(true).Apply(inverted.Apply(stream.Sample(s)))
+		if inv != -n {
+			t.Fatalf("Inverted sample %d is %d, expected %d", i, inv, -n)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// arecordPath is the ALSA capture client LTC is read through with -chase
+var arecordPath = "arecord"
+
+// captureChunk is the number of samples read from the capture client at a time, frames are timed by
+// when the chunk holding their end was read
+const captureChunk = 128
+
+// captureBufferTime and capturePeriodTime keep arecord passing samples on in small periods, the
+// arrival of each frame is only known to within a period
+const (
+	captureBufferTime = 20 * time.Millisecond
+	capturePeriodTime = 5 * time.Millisecond
+)
+
+// chaseFrame is a frame of timecode read from the chase input and when it ended
+type chaseFrame struct {
+	TimeCode glitc.TimeCode
+	End      time.Time
+}
+
+// ltcCapture reads LTC from an ALSA capture device through arecord, passing the frames decoded on
+// Frames().  Done() is closed when the capture stops, after sending the error that stopped it.
+type ltcCapture struct {
+	cmd      *exec.Cmd
+	framesCh chan chaseFrame
+	doneCh   chan error
+}
+
+// openLTCCapture starts capturing mono S32_LE samples at sampleRate from the named ALSA device and
+// decoding them as LTC at the rate of template.  Cancelling ctx stops the capture.
+func openLTCCapture(ctx context.Context, device string, sampleRate int, template glitc.LTCFrame, clock Clock) (*ltcCapture, error) {
+	if err := checkSamplesPerBit(float64(sampleRate), template.EffectiveFPS()); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, arecordPath,
+		"-q",
+		"-D", device,
+		"-t", "raw",
+		"-f", "S32_LE",
+		fmt.Sprintf("-r%d", sampleRate),
+		"-c1",
+		fmt.Sprintf("--buffer-time=%d", captureBufferTime/time.Microsecond),
+		fmt.Sprintf("--period-time=%d", capturePeriodTime/time.Microsecond),
+	)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to start %s: %v", arecordPath, err)
+	}
+
+	c := &ltcCapture{
+		cmd:      cmd,
+		framesCh: make(chan chaseFrame, 16),
+		doneCh:   make(chan error, 1),
+	}
+	go func() {
+		defer close(c.doneCh)
+		defer close(c.framesCh)
+		err := readLTC(stdout, float64(sampleRate), template, clock, c.framesCh)
+		if waitErr := cmd.Wait(); err == nil || err == io.EOF {
+			err = waitErr
+		}
+		if err == nil {
+			err = fmt.Errorf("%s stopped", arecordPath)
+		}
+		c.doneCh <- err
+	}()
+	return c, nil
+}
+
+// Frames returns the channel decoded frames are sent on
+func (c *ltcCapture) Frames() <-chan chaseFrame {
+	return c.framesCh
+}
+
+// Done returns the channel the error stopping the capture is sent on
+func (c *ltcCapture) Done() <-chan error {
+	return c.doneCh
+}
+
+// readLTC decodes S32_LE samples at sampleRate from r as LTC at the rate of template until r ends,
+// sending each frame on frames.  A frame ends at the clock's time when the samples holding its end
+// were read, less the samples read after it.  Frames that don't decode are skipped, as are frames
+// that arrive while the receiver is behind.
+func readLTC(r io.Reader, sampleRate float64, template glitc.LTCFrame, clock Clock, frames chan<- chaseFrame) error {
+	decoder := glitc.NewLTCDecoder(sampleRate, template.EffectiveFPS()*80)
+	buf := make([]byte, 4*captureChunk)
+	samples := make([]int32, captureChunk)
+	for {
+		n, err := io.ReadFull(r, buf)
+		read := clock.Now()
+		samples = samples[:n/4]
+		for i := range samples {
+			samples[i] = int32(binary.LittleEndian.Uint32(buf[4*i:]))
+		}
+		for _, decoded := range decoder.DecodeFrames(samples) {
+			frame, decodeErr := template.DecodeFrame(decoded.Bytes)
+			if decodeErr != nil {
+				continue
+			}
+			after := time.Duration(float64(len(samples)-decoded.End) / sampleRate * float64(time.Second))
+			select {
+			case frames <- chaseFrame{TimeCode: frame.Frame(), End: read.Add(-after)}:
+			default:
+			}
+		}
+		if err == io.ErrUnexpectedEOF {
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// chaseTimeout is the number of frame durations without a frame from the chase input after which the
+// generator is no longer locked to it
+const chaseTimeout = 10
+
+// chaseJamFrames is the phase error, in frames, beyond which the generator jumps to the chase input
+// rather than steering towards it, e.g. when the source relocates
+const chaseJamFrames = 2
+
+// chaser locks the generator's timecode to the frames read from a chase input.  Each frame read gives
+// the offset from the clock that would send the same timecode in phase with the input.  The PID
+// controller steers the offset towards it, so jitter in the input is smoothed out of the output, and
+// large errors jam the offset straight to the input.
+type chaser struct {
+	frame      glitc.LTCFrame
+	inputDelay time.Duration
+	pid        *pidController
+
+	prev       glitc.TimeCode
+	prevEnd    time.Time
+	phaseError time.Duration
+	locked     bool
+}
+
+// newChaser returns a chaser for timecode at the rate of frame, read inputDelay after it arrives at
+// the input
+func newChaser(frame glitc.LTCFrame, inputDelay time.Duration, pid *pidController) *chaser {
+	return &chaser{frame: frame, inputDelay: inputDelay, pid: pid}
+}
+
+// Update takes a frame read from the chase input and the offset of the generator's timecode from the
+// clock, returning the offset to use from the next frame.  jump is true if the offset was jammed to
+// the input rather than steered.
+func (c *chaser) Update(in chaseFrame, offset time.Duration) (next time.Duration, jump bool, err error) {
+	tc := in.TimeCode
+	if tc.DropFrame != c.frame.DropFrame || !tc.IsValid(c.frame.FramesPerSecond, c.frame.DropFrame) {
+		return offset, false, fmt.Errorf("chase input timecode %s isn't sent at %g fps, dropframe %v", tc, c.frame.EffectiveFPS(), c.frame.DropFrame)
+	}
+
+	frameDuration := c.frame.FrameDuration()
+	begin := in.End.Add(-c.inputDelay).Add(-frameDuration)
+	f := c.frame
+	f.SetTimeCode(tc, begin.Add(offset))
+	// above 30fps both frames of a pair carry the timecode of the first
+	if c.frame.FramesPerSecond > 30 && tc == c.prev && in.End.Sub(c.prevEnd) < 2*frameDuration {
+		f.Time = f.Time.Add(frameDuration)
+	}
+	c.prev, c.prevEnd = tc, in.End
+
+	target := f.FrameBeginTime().Sub(begin)
+	c.phaseError = wrapDay(target - offset)
+	if c.phaseError > chaseJamFrames*frameDuration || c.phaseError < -chaseJamFrames*frameDuration {
+		c.pid.Reset()
+		c.locked = false
+		return offset + c.phaseError, true, nil
+	}
+	c.locked = true
+	correction := c.pid.Update(c.phaseError.Seconds())
+	return offset + time.Duration(correction*float64(time.Second)), false, nil
+}
+
+// Locked returns true if the last frame from the chase input was steered towards rather than jammed,
+// and it arrived recently at now
+func (c *chaser) Locked(now time.Time) bool {
+	return c.locked && now.Sub(c.prevEnd) < chaseTimeout*c.frame.FrameDuration()
+}
+
+// PhaseError returns the difference between the generator's timecode and the chase input at the last
+// frame read, positive when the generator is behind
+func (c *chaser) PhaseError() time.Duration {
+	return c.phaseError
+}
+
+// wrapDay returns d less whole days, between -12h and 12h, so timecode either side of midnight is close
+func wrapDay(d time.Duration) time.Duration {
+	const day = 24 * time.Hour
+	d %= day
+	switch {
+	case d > day/2:
+		d -= day
+	case d < -day/2:
+		d += day
+	}
+	return d
+}
+
+// checkChase returns an error if -chase is used with options that take timecode away from the clock
+func checkChase() error {
+	switch {
+	case *freeRun || *reverse || *monotonic:
+		return fmt.Errorf("-chase can't be used with -free-run, -reverse or -monotonic")
+	case *sampleClock:
+		return fmt.Errorf("-chase can't be used with -sample-clock")
+	case *outputFile != "" || *selfTestFlag:
+		return fmt.Errorf("-chase can't be used with -output or -self-test")
+	}
+	return nil
+}
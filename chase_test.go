@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/biphase"
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// clockedReader advances a fake clock by the duration of the samples read, as if they were being
+// captured in real time
+type clockedReader struct {
+	r          io.Reader
+	clock      *fakeClock
+	sampleRate float64
+}
+
+func (r clockedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.clock.Advance(time.Duration(float64(n/4) / r.sampleRate * float64(time.Second)))
+	return n, err
+}
+
+func TestReadLTC(t *testing.T) {
+	start := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	frame := glitc.Rate25.Frame()
+	frame.SetTimeCode(glitc.TimeCode{Hour: 10}, start)
+
+	// 48kHz at 25fps is 24 samples per bit and 1920 per frame
+	encoder, err := biphase.NewEncoder(48000, frame.EffectiveFPS()*80, math.MaxInt32/2)
+	if err != nil {
+		t.Fatalf("Unable to create encoder: %v", err)
+	}
+	var samples []int32
+	for i := 0; i < 5; i++ {
+		samples = encoder.Encode(samples, frame.EncodeFrame())
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+	var pcm bytes.Buffer
+	binary.Write(&pcm, binary.LittleEndian, samples)
+
+	clock := newFakeClock(start)
+	frames := make(chan chaseFrame, 16)
+	if err := readLTC(clockedReader{&pcm, clock, 48000}, 48000, glitc.Rate25.Frame(), clock, frames); err != io.EOF {
+		t.Fatalf("Expected io.EOF at the end of the samples, got %v", err)
+	}
+	close(frames)
+
+	// the last frame isn't complete until the edge starting the next one
+	var n int
+	for in := range frames {
+		expected := glitc.TimeCode{Hour: 10, Frame: n}
+		if in.TimeCode != expected {
+			t.Errorf("Expected frame %s, got %s", expected, in.TimeCode)
+		}
+		end := start.Add(time.Duration(n+1) * 40 * time.Millisecond)
+		if d := in.End.Sub(end); d < -time.Microsecond || d > time.Microsecond {
+			t.Errorf("Expected frame %s to end at %s, got %s", in.TimeCode, end, in.End)
+		}
+		n++
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 frames, got %d", n)
+	}
+}
+
+func TestChaserUpdate(t *testing.T) {
+	second := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Name        string
+		InputDelay  time.Duration
+		TimeCode    glitc.TimeCode
+		End         time.Time
+		Offset      time.Duration
+		Expected    time.Duration
+		ExpectJump  bool
+		ExpectError bool
+	}{
+		{"InPhase", 0, glitc.TimeCode{Hour: 12, Frame: 10}, second.Add(440 * time.Millisecond), 0, 0, false, false},
+		{"InputDelay", 5 * time.Millisecond, glitc.TimeCode{Hour: 12, Frame: 10}, second.Add(445 * time.Millisecond), 0, 0, false, false},
+		// 10ms behind, p and i each correct part of it
+		{"Behind", 0, glitc.TimeCode{Hour: 12, Frame: 10}, second.Add(430 * time.Millisecond), 0, 1500 * time.Microsecond, false, false},
+		{"Ahead", 0, glitc.TimeCode{Hour: 13, Frame: 10}, second.Add(450 * time.Millisecond), time.Hour, time.Hour - 1500*time.Microsecond, false, false},
+		{"Relocated", 0, glitc.TimeCode{Hour: 13, Frame: 10}, second.Add(440 * time.Millisecond), 0, time.Hour, true, false},
+		{"Midnight", 0, glitc.TimeCode{}, second.Add(12*time.Hour - 10*time.Millisecond + 40*time.Millisecond), 0, 1500 * time.Microsecond, false, false},
+		{"DropFrame", 0, glitc.TimeCode{Hour: 12, Frame: 10, DropFrame: true}, second.Add(440 * time.Millisecond), 0, 0, false, true},
+		{"InvalidFrame", 0, glitc.TimeCode{Hour: 12, Frame: 25}, second.Add(440 * time.Millisecond), 0, 0, false, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			pid, err := newPIDController(0.1, 0.05, 0, 30)
+			if err != nil {
+				st.Fatalf("Unable to create controller: %v", err)
+			}
+			chase := newChaser(glitc.Rate25.Frame(), c.InputDelay, pid)
+			next, jump, err := chase.Update(chaseFrame{TimeCode: c.TimeCode, End: c.End}, c.Offset)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if jump != c.ExpectJump {
+				st.Errorf("Expected jump %v, got %v", c.ExpectJump, jump)
+			}
+			if d := next - c.Expected; d < -time.Microsecond || d > time.Microsecond {
+				st.Errorf("Expected offset %s, got %s", c.Expected, next)
+			}
+			if !c.ExpectError && chase.Locked(c.End) == c.ExpectJump {
+				st.Errorf("Expected locked %v", !c.ExpectJump)
+			}
+		})
+	}
+}
+
+func TestChaserFramePairs(t *testing.T) {
+	second := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+	pid, err := newPIDController(0.1, 0.05, 0, 30)
+	if err != nil {
+		t.Fatalf("Unable to create controller: %v", err)
+	}
+	chase := newChaser(glitc.Rate50.Frame(), 0, pid)
+
+	// frames 10 and 11 are both sent as 10, the first of the pair
+	tc := glitc.TimeCode{Hour: 12, Frame: 10}
+	for _, end := range []time.Duration{220 * time.Millisecond, 240 * time.Millisecond} {
+		next, jump, err := chase.Update(chaseFrame{TimeCode: tc, End: second.Add(end)}, 0)
+		if err != nil {
+			t.Fatalf("Unable to update: %v", err)
+		}
+		if jump || chase.PhaseError() != 0 || next != 0 {
+			t.Errorf("Expected frame ending at %s to be in phase, got error %s", end, chase.PhaseError())
+		}
+	}
+
+	if chase.Locked(second.Add(time.Second)) {
+		t.Errorf("Expected to lose lock without frames from the input")
+	}
+}
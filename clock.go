@@ -0,0 +1,63 @@
+package main
+
+import "time"
+
+// Clock is the source of time for the frame loop, replaced with a fake clock in tests
+type Clock interface {
+	Now() time.Time
+	// Monotonic returns the time elapsed since an arbitrary point, unaffected by changes to the
+	// system clock
+	Monotonic() time.Duration
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer fires once on C after its duration, see time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker fires on C once every period, see time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is a Clock using the system clock
+type realClock struct{}
+
+// monotonicOrigin is the reading realClock.Monotonic counts from
+var monotonicOrigin = time.Now()
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Monotonic() time.Duration {
+	return time.Since(monotonicOrigin)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
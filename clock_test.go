@@ -0,0 +1,152 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only advances when Advance is called, firing any timers and tickers that
+// become due along the way
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	start   time.Time
+	stepped time.Duration
+	timers  []*fakeTimer
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, start: now}
+}
+
+func (c *fakeClock) Monotonic() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now.Sub(c.start) - c.stepped
+}
+
+// Step changes the wall clock by d without any time passing, as NTP stepping the system clock would.
+// Timers and the monotonic clock are unaffected.
+func (c *fakeClock) Step(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.stepped += d
+	for _, t := range c.timers {
+		t.at = t.at.Add(d)
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	return fakeTicker{c.add(d, d)}
+}
+
+func (c *fakeClock) add(d time.Duration, period time.Duration) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), at: c.now.Add(d), period: period, active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing timers in order at the time they are due.  Like
+// time.Ticker, ticks are dropped if the previous tick hasn't been received.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if t.active && !t.at.After(end) && (next == nil || t.at.Before(next.at)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+		c.now = next.at
+		select {
+		case next.c <- next.at:
+		default:
+		}
+		if next.period == 0 {
+			next.active = false
+		} else {
+			next.at = next.at.Add(next.period)
+		}
+	}
+	c.now = end
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+	c := newFakeClock(start)
+	timer := c.NewTimer(time.Second)
+	ticker := c.NewTicker(300 * time.Millisecond)
+
+	c.Advance(500 * time.Millisecond)
+	if tick := <-ticker.C(); !tick.Equal(start.Add(300 * time.Millisecond)) {
+		t.Errorf("Expected tick at %s, got %s", start.Add(300*time.Millisecond), tick)
+	}
+	select {
+	case <-timer.C():
+		t.Errorf("Timer fired early")
+	default:
+	}
+
+	c.Advance(500 * time.Millisecond)
+	if fired := <-timer.C(); !fired.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected timer at %s, got %s", start.Add(time.Second), fired)
+	}
+	if tick := <-ticker.C(); !tick.Equal(start.Add(600 * time.Millisecond)) {
+		t.Errorf("Expected tick at %s, got %s", start.Add(600*time.Millisecond), tick)
+	}
+	if !c.Now().Equal(start.Add(time.Second)) {
+		t.Errorf("Expected clock at %s, got %s", start.Add(time.Second), c.Now())
+	}
+
+	ticker.Stop()
+	if timer.Stop() {
+		t.Errorf("Expected Stop to return false for a fired timer")
+	}
+}
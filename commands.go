@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// printFrame writes the bytes of binaryFrame in hex followed by each of its fields
+func printFrame(out io.Writer, binaryFrame []byte, fps float64, version glitc.SpecVersion) {
+	fmt.Fprintf(out, "%s\n", hex.EncodeToString(binaryFrame))
+	for _, field := range version.FrameFields(fps) {
+		var bits string
+		var value int
+		for i := 0; i < field.Bits; i++ {
+			n := field.First + i
+			bit := int(binaryFrame[n/8]>>uint(7-n%8)) & 0x1
+			bits += fmt.Sprint(bit)
+			// timecode digits are sent least significant bit first
+			value |= bit << uint(i)
+		}
+		position := fmt.Sprint(field.First)
+		if field.Bits > 1 {
+			position = fmt.Sprintf("%d-%d", field.First, field.First+field.Bits-1)
+		}
+		if field.BCD {
+			fmt.Fprintf(out, "%-5s %-20s %-16s %d\n", position, field.Name, bits, value)
+		} else {
+			fmt.Fprintf(out, "%-5s %-20s %s\n", position, field.Name, bits)
+		}
+	}
+}
+
+// encodeCommand prints the encoded frame for a timecode
+func encodeCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("encode", flag.ContinueOnError)
+	flags.SetOutput(out)
+	tcFlag := flags.String("tc", "", "Timecode to encode, hh:mm:ss:ff or hh:mm:ss;ff for drop frame")
+	fps := flags.Float64("fps", 30, "Frame rate: "+listFPS(glitc.SupportedRates, "or"))
+	version := flags.String("smpte-version", "1999", "SMPTE 12M revision whose flag bit assignments are used: 1986, 1999 or 2008")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	specVersion, err := glitc.ParseSpecVersion(*version)
+	if err != nil {
+		return err
+	}
+
+	tc, err := glitc.ParseTimeCode(*tcFlag)
+	if err != nil {
+		return err
+	}
+	frame, err := frameForRate(*fps, tc.DropFrame)
+	if err != nil {
+		return err
+	}
+	frame.Time = time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+	frame.SpecVersion = specVersion
+	binaryFrame, err := frame.EncodeTimeCode(tc)
+	if err != nil {
+		return err
+	}
+
+	printFrame(out, binaryFrame, frame.FramesPerSecond, specVersion)
+	return nil
+}
+
+// decodeCommand prints the timecode and flags of an encoded frame given in hex
+func decodeCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	flags.SetOutput(out)
+	fps := flags.Float64("fps", 30, "Frame rate: "+listFPS(glitc.SupportedRates, "or"))
+	version := flags.String("smpte-version", "1999", "SMPTE 12M revision whose flag bit assignments are used: 1986, 1999 or 2008")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	specVersion, err := glitc.ParseSpecVersion(*version)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("expected the frame as 20 hex digits")
+	}
+
+	binaryFrame, err := hex.DecodeString(strings.Join(strings.Fields(strings.Join(flags.Args(), "")), ""))
+	if err != nil {
+		return fmt.Errorf("invalid frame: %v", err)
+	}
+	template, err := frameForRate(*fps, false)
+	if err != nil {
+		return err
+	}
+	template.Time = time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+	template.SpecVersion = specVersion
+	frame, err := template.DecodeFrame(binaryFrame)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "%s\n", frame.Frame())
+	fmt.Fprintf(out, "color frame: %v\n", frame.ColorFrame)
+	fmt.Fprintf(out, "external clock sync: %v\n", frame.ExternalClockSync)
+	fmt.Fprintf(out, "binary group flag 0: %v\n", frame.BinaryGroupFlags&glitc.BGF0 != 0)
+	fmt.Fprintf(out, "binary group flag 2: %v\n", frame.BinaryGroupFlags&glitc.BGF2 != 0)
+	if frame.UserBytes != nil {
+		fmt.Fprintf(out, "user bytes: %s\n", hex.EncodeToString(frame.UserBytes[:]))
+	}
+	printFrame(out, binaryFrame, template.FramesPerSecond, specVersion)
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := encodeCommand([]string{"-tc", "01:02:03:04", "-fps", "25"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"2000c000400080003ffd\n",
+		"0-3   frame units          0010             4\n",
+		"16-19 second units         1100             3\n",
+		"27    binary group flag 0  0\n",
+		"59    phase correction     0\n",
+		"64-79 sync word            0011111111111101\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Output missing %q:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestEncodeCommandErrors(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Args []string
+	}{
+		{"NoTimeCode", []string{"-fps", "25"}},
+		{"Rate", []string{"-tc", "01:02:03:04", "-fps", "26"}},
+		{"Frame", []string{"-tc", "01:02:03:25", "-fps", "25"}},
+		{"DropFrameRate", []string{"-tc", "01:02:03;04", "-fps", "25"}},
+		{"DroppedFrame", []string{"-tc", "01:02:00;01", "-fps", "29.97"}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			var out bytes.Buffer
+			if err := encodeCommand(c.Args, &out); err == nil {
+				st.Errorf("Expected error for %v", c.Args)
+			}
+		})
+	}
+}
+
+func TestEncodeCommandSpecVersion(t *testing.T) {
+	var out bytes.Buffer
+	if err := encodeCommand([]string{"-tc", "01:02:03:06", "-fps", "50", "-smpte-version", "2008"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"c000c000400080103ffd\n",
+		"27    binary group flag 0  0\n",
+		"59    phase correction     1\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Output missing %q:\n%s", expected, out.String())
+		}
+	}
+
+	if err := encodeCommand([]string{"-tc", "01:02:03:06", "-fps", "50", "-smpte-version", "2014"}, &out); err == nil {
+		t.Errorf("Expected an error for an unknown version")
+	}
+}
+
+func TestDecodeCommand(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		FPS      string
+		TimeCode string
+	}{
+		{"25fps", "25", "01:02:03:04"},
+		{"29.97fps/df", "29.97", "23:59:59;29"},
+		{"23.976fps", "23.976", "10:00:00:23"},
+		{"60fps", "60", "10:00:00:28"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			var encoded bytes.Buffer
+			if err := encodeCommand([]string{"-tc", c.TimeCode, "-fps", c.FPS}, &encoded); err != nil {
+				st.Fatalf("Unable to encode: %v", err)
+			}
+			frameHex := strings.SplitN(encoded.String(), "\n", 2)[0]
+
+			var out bytes.Buffer
+			if err := decodeCommand([]string{"-fps", c.FPS, frameHex}, &out); err != nil {
+				st.Fatalf("Unable to decode %s: %v", frameHex, err)
+			}
+			if line := strings.SplitN(out.String(), "\n", 2)[0]; line != c.TimeCode {
+				st.Errorf("Expected %s, got %s", c.TimeCode, line)
+			}
+		})
+	}
+}
+
+func TestDecodeCommandErrors(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Args []string
+	}{
+		{"NoFrame", []string{"-fps", "25"}},
+		{"Hex", []string{"2000c000400080003ffx"}},
+		{"Short", []string{"2000c000400080003f"}},
+		{"Sync", []string{"2000c000400080003ffc"}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			var out bytes.Buffer
+			if err := decodeCommand(c.Args, &out); err == nil {
+				st.Errorf("Expected error for %v", c.Args)
+			}
+		})
+	}
+}
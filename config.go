@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// envPrefix starts the names of the environment variables ltcgen is configured from, e.g. LTCGEN_FPS
+// for fps in the config file and LTCGEN_OFFSET for -offset
+const envPrefix = "LTCGEN"
+
+// envName returns the environment variable setting a flag or config key, e.g. LTCGEN_LOG_FORMAT for
+// -log-format and LTCGEN_PID_P for pid.p
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// newConfig returns the configuration read from /etc/ltcgen/ltcgen.* with its defaults.  Any key can
+// be overridden by an environment variable named by envName, which takes precedence over the file.
+func newConfig() *viper.Viper {
+	cfgFile := viper.New()
+	cfgFile.AddConfigPath("/etc/ltcgen")
+	cfgFile.SetConfigName("ltcgen")
+	cfgFile.SetEnvPrefix(envPrefix)
+	cfgFile.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	cfgFile.AutomaticEnv()
+	cfgFile.SetDefault("fps", 29.97)
+	cfgFile.SetDefault("dropframe", true)
+	cfgFile.SetDefault("pulldown", false)
+	cfgFile.SetDefault("rateWindowMinutes", 2)
+	// pid steers the generator towards the -chase input, see pidController
+	cfgFile.SetDefault("pid.p", 0.1)
+	cfgFile.SetDefault("pid.i", 0.05)
+	cfgFile.SetDefault("pid.d", 0)
+	cfgFile.SetDefault("pid.depth", 30)
+	return cfgFile
+}
+
+// applyEnvFlags sets each flag that wasn't given on the command line from its environment variable,
+// so flags take precedence over the environment, which takes precedence over the flag defaults
+func applyEnvFlags(flags *flag.FlagSet, lookup func(string) (string, bool)) error {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var err error
+	flags.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+		name := envName(f.Name)
+		if value, ok := lookup(name); ok {
+			if setErr := flags.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %v", value, name, setErr)
+			}
+		}
+	})
+	return err
+}
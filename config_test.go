@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestApplyEnvFlags(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Args        []string
+		Env         map[string]string
+		Offset      time.Duration
+		Rate        string
+		Mute        bool
+		Level       float64
+		ExpectError bool
+	}{
+		{"Defaults", nil, nil, 0, "", false, 0, false},
+		{"Duration", nil, map[string]string{"LTCGEN_OFFSET": "1h"}, time.Hour, "", false, 0, false},
+		{"String", nil, map[string]string{"LTCGEN_RATE": "25"}, 0, "25", false, 0, false},
+		{"Bool", nil, map[string]string{"LTCGEN_MUTE": "true"}, 0, "", true, 0, false},
+		{"Dashed", nil, map[string]string{"LTCGEN_LEVEL_DBFS": "-6"}, 0, "", false, -6, false},
+		{"FlagWins", []string{"-offset", "10s"}, map[string]string{"LTCGEN_OFFSET": "1h"}, 10 * time.Second, "", false, 0, false},
+		{"Invalid", nil, map[string]string{"LTCGEN_OFFSET": "soon"}, 0, "", false, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			flags := flag.NewFlagSet("ltcgen", flag.ContinueOnError)
+			offset := flags.Duration("offset", 0, "")
+			rate := flags.String("rate", "", "")
+			mute := flags.Bool("mute", false, "")
+			level := flags.Float64("level-dbfs", 0, "")
+			if err := flags.Parse(c.Args); err != nil {
+				st.Fatalf("Unable to parse %v: %v", c.Args, err)
+			}
+
+			lookup := func(name string) (string, bool) {
+				value, ok := c.Env[name]
+				return value, ok
+			}
+			err := applyEnvFlags(flags, lookup)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if c.ExpectError {
+				return
+			}
+			if *offset != c.Offset || *rate != c.Rate || *mute != c.Mute || *level != c.Level {
+				st.Errorf("Expected offset %s, rate %q, mute %v and level %g, got %s, %q, %v and %g",
+					c.Offset, c.Rate, c.Mute, c.Level, *offset, *rate, *mute, *level)
+			}
+		})
+	}
+}
+
+func TestEnvNameCoversFlags(t *testing.T) {
+	// every flag can be set from the environment, names must not collide
+	names := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		if other, ok := names[name]; ok {
+			t.Errorf("-%s and -%s are both set by %s", f.Name, other, name)
+		}
+		names[name] = f.Name
+	})
+	if names["LTCGEN_PHASE_OFFSET"] != "phase-offset" {
+		t.Errorf("Expected -phase-offset to be set by LTCGEN_PHASE_OFFSET")
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	testCases := []struct {
+		Key      string
+		Env      string
+		Value    string
+		Expected interface{}
+	}{
+		{"fps", "LTCGEN_FPS", "25", 25.0},
+		{"dropframe", "LTCGEN_DROPFRAME", "false", false},
+		{"rate", "LTCGEN_RATE", "29.97df", "29.97df"},
+		{"samplerate", "LTCGEN_SAMPLERATE", "44100", 44100},
+		{"pid.p", "LTCGEN_PID_P", "2", 2.0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Key, func(st *testing.T) {
+			if name := envName(c.Key); name != c.Env {
+				st.Errorf("Expected %s to be set by %s, got %s", c.Key, c.Env, name)
+			}
+			os.Setenv(c.Env, c.Value)
+			defer os.Unsetenv(c.Env)
+
+			cfgFile := newConfig()
+			var value interface{}
+			switch c.Expected.(type) {
+			case float64:
+				value = cfgFile.GetFloat64(c.Key)
+			case bool:
+				value = cfgFile.GetBool(c.Key)
+			case int:
+				value = cfgFile.GetInt(c.Key)
+			default:
+				value = cfgFile.GetString(c.Key)
+			}
+			if value != c.Expected {
+				st.Errorf("Expected %s to be %v from %s, got %v", c.Key, c.Expected, c.Env, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/azenk/ltcgen/control"
+	"github.com/azenk/ltcgen/glitc"
+	"google.golang.org/grpc"
+)
+
+// errShuttingDown is returned by control commands sent once the frame loop has stopped
+var errShuttingDown = errors.New("ltcgen is shutting down")
+
+// controlRequest is a control command waiting to run on the frame loop
+type controlRequest struct {
+	apply func() error
+	reply chan error
+}
+
+// controlRuntime is the control.Runtime for the frame loop.  Commands are run on the frame loop's
+// goroutine, which owns the scheduler, by receiving from Requests().
+type controlRuntime struct {
+	ctx       context.Context
+	requests  chan controlRequest
+	status    *Status
+	mute      *muteSwitch
+	scheduler *frameScheduler
+}
+
+func newControlRuntime(ctx context.Context, status *Status, mute *muteSwitch) *controlRuntime {
+	return &controlRuntime{ctx: ctx, requests: make(chan controlRequest), status: status, mute: mute}
+}
+
+// Requests returns the channel commands are received on, apply each one and send its result on reply
+func (r *controlRuntime) Requests() <-chan controlRequest {
+	return r.requests
+}
+
+// do runs f on the frame loop and returns its result
+func (r *controlRuntime) do(f func() error) error {
+	request := controlRequest{apply: f, reply: make(chan error, 1)}
+	select {
+	case r.requests <- request:
+	case <-r.ctx.Done():
+		return errShuttingDown
+	}
+	return <-request.reply
+}
+
+func (r *controlRuntime) setMuted(muted bool) {
+	r.mute.Set(muted)
+	r.status.SetMuted(muted)
+	if muted {
+		logInfof(logFields{"timecode": r.scheduler.Frame().Frame().String()}, "Output stopped at %s by control request, timecode keeps counting", r.scheduler.Frame().Frame())
+	} else {
+		logInfof(logFields{"timecode": r.scheduler.Frame().Frame().String()}, "Output started at %s by control request", r.scheduler.Frame().Frame())
+	}
+}
+
+func (r *controlRuntime) Start() error {
+	return r.do(func() error {
+		r.scheduler.Resume()
+		r.setMuted(false)
+		return nil
+	})
+}
+
+func (r *controlRuntime) Stop() error {
+	return r.do(func() error {
+		r.setMuted(true)
+		return nil
+	})
+}
+
+func (r *controlRuntime) Pause(paused bool) error {
+	return r.do(func() error {
+		if paused {
+			r.scheduler.Pause()
+			logInfof(logFields{"timecode": r.scheduler.Frame().Frame().String()}, "Paused timecode at %s by control request", r.scheduler.Frame().Frame())
+		} else {
+			r.scheduler.Resume()
+			logInfof(nil, "Resumed timecode by control request")
+		}
+		return nil
+	})
+}
+
+func (r *controlRuntime) Jam(timecode string) error {
+	tc, err := glitc.ParseTimeCode(timecode)
+	if err != nil {
+		return control.RequestError{Err: err}
+	}
+	return r.do(func() error {
+		if err := r.scheduler.Jam(tc); err != nil {
+			return control.RequestError{Err: err}
+		}
+		logInfof(logFields{"timecode": tc.String()}, "Jammed timecode to %s by control request", tc)
+		return nil
+	})
+}
+
+func (r *controlRuntime) Status() *control.StatusSnapshot {
+	return r.status.Snapshot().Proto()
+}
+
+// serveGRPC serves the control service on addr in the background
+func serveGRPC(addr string, server *control.Server) {
+	s := grpc.NewServer()
+	control.RegisterControlServer(s, server)
+	logInfof(logFields{"addr": addr}, "Serving the control service on %s", addr)
+	go func() {
+		listener, err := net.Listen("tcp", addr)
+		if err == nil {
+			err = s.Serve(listener)
+		}
+		logWarningf(logFields{"error": err.Error()}, "gRPC server on %s stopped: %v", addr, err)
+	}()
+}
@@ -0,0 +1,111 @@
+// Package control serves remote management of a running generator over gRPC: starting and
+// stopping the output, pausing timecode, jam syncing it to a new timecode and subscribing to status.
+// The service is defined in control.proto.
+package control
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative ../control/control.proto
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Runtime is the generator being controlled
+type Runtime interface {
+	// Start sends LTC, unmuting the output and resuming paused timecode
+	Start() error
+	// Stop silences the output while timecode keeps counting
+	Stop() error
+	// Pause holds timecode on the current frame, or resumes it if paused is false
+	Pause(paused bool) error
+	// Jam makes timecode, e.g. 10:00:00:00, the timecode of the next frame
+	Jam(timecode string) error
+	// Status returns the current status
+	Status() *StatusSnapshot
+}
+
+// RequestError is returned by a Runtime for commands whose request was invalid, e.g. a timecode
+// that can't be parsed, it is sent to the client as INVALID_ARGUMENT
+type RequestError struct {
+	Err error
+}
+
+func (e RequestError) Error() string {
+	return e.Err.Error()
+}
+
+// Server implements the Control service for a runtime
+type Server struct {
+	UnimplementedControlServer
+	runtime  Runtime
+	interval time.Duration
+}
+
+// NewServer returns a server controlling runtime, status subscriptions are sent a status every
+// interval unless the request asks for another interval.  Register it with RegisterControlServer.
+func NewServer(runtime Runtime, interval time.Duration) *Server {
+	return &Server{runtime: runtime, interval: interval}
+}
+
+// reply returns the response to a command, converting an error from the runtime to a gRPC status
+func reply(err error) (*Empty, error) {
+	switch err.(type) {
+	case nil:
+		return &Empty{}, nil
+	case RequestError:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+func (s *Server) Start(context.Context, *Empty) (*Empty, error) {
+	return reply(s.runtime.Start())
+}
+
+func (s *Server) Stop(context.Context, *Empty) (*Empty, error) {
+	return reply(s.runtime.Stop())
+}
+
+func (s *Server) Pause(context.Context, *Empty) (*Empty, error) {
+	return reply(s.runtime.Pause(true))
+}
+
+func (s *Server) Resume(context.Context, *Empty) (*Empty, error) {
+	return reply(s.runtime.Pause(false))
+}
+
+func (s *Server) Jam(_ context.Context, request *JamRequest) (*Empty, error) {
+	if request.Timecode == "" {
+		return nil, status.Error(codes.InvalidArgument, "timecode is required, e.g. 10:00:00:00")
+	}
+	return reply(s.runtime.Jam(request.Timecode))
+}
+
+// Status sends the status every interval until the client goes away
+func (s *Server) Status(request *StatusRequest, stream Control_StatusServer) error {
+	interval := s.interval
+	if request.Interval != "" {
+		d, err := time.ParseDuration(request.Interval)
+		if err != nil || d <= 0 {
+			return status.Error(codes.InvalidArgument, "interval must be a positive duration, e.g. 1s")
+		}
+		interval = d
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := stream.Send(s.runtime.Status()); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
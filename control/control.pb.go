@@ -0,0 +1,548 @@
+// The control service served on -grpc-addr.  Commands that can't be carried out fail with an
+// INVALID_ARGUMENT status for a bad request, e.g. an unparsable timecode, or UNAVAILABLE once
+// ltcgen is shutting down.  control.pb.go and control_grpc.pb.go are generated from this file by
+// go generate, which needs protoc, protoc-gen-go and protoc-gen-go-grpc.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.1
+// 	protoc        (unknown)
+// source: control/control.proto
+
+package control
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_control_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_control_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_control_control_proto_rawDescGZIP(), []int{0}
+}
+
+type JamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// timecode, e.g. 10:00:00:00 or 10:00:00;00 for drop frame
+	Timecode      string `protobuf:"bytes,1,opt,name=timecode,proto3" json:"timecode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JamRequest) Reset() {
+	*x = JamRequest{}
+	mi := &file_control_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JamRequest) ProtoMessage() {}
+
+func (x *JamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JamRequest.ProtoReflect.Descriptor instead.
+func (*JamRequest) Descriptor() ([]byte, []int) {
+	return file_control_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JamRequest) GetTimecode() string {
+	if x != nil {
+		return x.Timecode
+	}
+	return ""
+}
+
+type StatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// interval between status messages, e.g. 1s, -status-interval if empty
+	Interval      string `protobuf:"bytes,1,opt,name=interval,proto3" json:"interval,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_control_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_control_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_control_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StatusRequest) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+type StatusSnapshot struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Sent              int64                  `protobuf:"varint,1,opt,name=sent,proto3" json:"sent,omitempty"`
+	Dropped           int64                  `protobuf:"varint,2,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	Duplicate         int64                  `protobuf:"varint,3,opt,name=duplicate,proto3" json:"duplicate,omitempty"`
+	LargeOffset       int64                  `protobuf:"varint,4,opt,name=large_offset,json=largeOffset,proto3" json:"large_offset,omitempty"`
+	OutsideWindow     int64                  `protobuf:"varint,5,opt,name=outside_window,json=outsideWindow,proto3" json:"outside_window,omitempty"`
+	Fps               float64                `protobuf:"fixed64,6,opt,name=fps,proto3" json:"fps,omitempty"`
+	OffsetMinNs       int64                  `protobuf:"varint,7,opt,name=offset_min_ns,json=offsetMinNs,proto3" json:"offset_min_ns,omitempty"`
+	OffsetMeanNs      int64                  `protobuf:"varint,8,opt,name=offset_mean_ns,json=offsetMeanNs,proto3" json:"offset_mean_ns,omitempty"`
+	OffsetStddevNs    int64                  `protobuf:"varint,9,opt,name=offset_stddev_ns,json=offsetStddevNs,proto3" json:"offset_stddev_ns,omitempty"`
+	OffsetMaxNs       int64                  `protobuf:"varint,10,opt,name=offset_max_ns,json=offsetMaxNs,proto3" json:"offset_max_ns,omitempty"`
+	SkewMinNs         int64                  `protobuf:"varint,18,opt,name=skew_min_ns,json=skewMinNs,proto3" json:"skew_min_ns,omitempty"`
+	SkewMeanNs        int64                  `protobuf:"varint,19,opt,name=skew_mean_ns,json=skewMeanNs,proto3" json:"skew_mean_ns,omitempty"`
+	SkewStddevNs      int64                  `protobuf:"varint,20,opt,name=skew_stddev_ns,json=skewStddevNs,proto3" json:"skew_stddev_ns,omitempty"`
+	SkewMaxNs         int64                  `protobuf:"varint,21,opt,name=skew_max_ns,json=skewMaxNs,proto3" json:"skew_max_ns,omitempty"`
+	OutputDelayNs     int64                  `protobuf:"varint,11,opt,name=output_delay_ns,json=outputDelayNs,proto3" json:"output_delay_ns,omitempty"`
+	DelayCorrectionNs int64                  `protobuf:"varint,22,opt,name=delay_correction_ns,json=delayCorrectionNs,proto3" json:"delay_correction_ns,omitempty"`
+	Xruns             int64                  `protobuf:"varint,12,opt,name=xruns,proto3" json:"xruns,omitempty"`
+	Reconnects        int64                  `protobuf:"varint,13,opt,name=reconnects,proto3" json:"reconnects,omitempty"`
+	DeviceOpen        bool                   `protobuf:"varint,14,opt,name=device_open,json=deviceOpen,proto3" json:"device_open,omitempty"`
+	Paused            bool                   `protobuf:"varint,15,opt,name=paused,proto3" json:"paused,omitempty"`
+	Muted             bool                   `protobuf:"varint,16,opt,name=muted,proto3" json:"muted,omitempty"`
+	BufferedFrames    int64                  `protobuf:"varint,17,opt,name=buffered_frames,json=bufferedFrames,proto3" json:"buffered_frames,omitempty"`
+	// user bits as hex, only sent when set
+	UserBytes string `protobuf:"bytes,23,opt,name=user_bytes,json=userBytes,proto3" json:"user_bytes,omitempty"`
+	// only sent with -chase
+	ChaseLocked   *bool `protobuf:"varint,24,opt,name=chase_locked,json=chaseLocked,proto3,oneof" json:"chase_locked,omitempty"`
+	ChaseErrorNs  int64 `protobuf:"varint,25,opt,name=chase_error_ns,json=chaseErrorNs,proto3" json:"chase_error_ns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusSnapshot) Reset() {
+	*x = StatusSnapshot{}
+	mi := &file_control_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusSnapshot) ProtoMessage() {}
+
+func (x *StatusSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_control_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusSnapshot.ProtoReflect.Descriptor instead.
+func (*StatusSnapshot) Descriptor() ([]byte, []int) {
+	return file_control_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StatusSnapshot) GetSent() int64 {
+	if x != nil {
+		return x.Sent
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetDuplicate() int64 {
+	if x != nil {
+		return x.Duplicate
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetLargeOffset() int64 {
+	if x != nil {
+		return x.LargeOffset
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetOutsideWindow() int64 {
+	if x != nil {
+		return x.OutsideWindow
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetFps() float64 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetOffsetMinNs() int64 {
+	if x != nil {
+		return x.OffsetMinNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetOffsetMeanNs() int64 {
+	if x != nil {
+		return x.OffsetMeanNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetOffsetStddevNs() int64 {
+	if x != nil {
+		return x.OffsetStddevNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetOffsetMaxNs() int64 {
+	if x != nil {
+		return x.OffsetMaxNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetSkewMinNs() int64 {
+	if x != nil {
+		return x.SkewMinNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetSkewMeanNs() int64 {
+	if x != nil {
+		return x.SkewMeanNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetSkewStddevNs() int64 {
+	if x != nil {
+		return x.SkewStddevNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetSkewMaxNs() int64 {
+	if x != nil {
+		return x.SkewMaxNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetOutputDelayNs() int64 {
+	if x != nil {
+		return x.OutputDelayNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetDelayCorrectionNs() int64 {
+	if x != nil {
+		return x.DelayCorrectionNs
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetXruns() int64 {
+	if x != nil {
+		return x.Xruns
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetReconnects() int64 {
+	if x != nil {
+		return x.Reconnects
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetDeviceOpen() bool {
+	if x != nil {
+		return x.DeviceOpen
+	}
+	return false
+}
+
+func (x *StatusSnapshot) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *StatusSnapshot) GetMuted() bool {
+	if x != nil {
+		return x.Muted
+	}
+	return false
+}
+
+func (x *StatusSnapshot) GetBufferedFrames() int64 {
+	if x != nil {
+		return x.BufferedFrames
+	}
+	return 0
+}
+
+func (x *StatusSnapshot) GetUserBytes() string {
+	if x != nil {
+		return x.UserBytes
+	}
+	return ""
+}
+
+func (x *StatusSnapshot) GetChaseLocked() bool {
+	if x != nil && x.ChaseLocked != nil {
+		return *x.ChaseLocked
+	}
+	return false
+}
+
+func (x *StatusSnapshot) GetChaseErrorNs() int64 {
+	if x != nil {
+		return x.ChaseErrorNs
+	}
+	return 0
+}
+
+var File_control_control_proto protoreflect.FileDescriptor
+
+var file_control_control_proto_rawDesc = []byte{
+	0x0a, 0x15, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
+	0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e,
+	0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x22, 0x28, 0x0a, 0x0a, 0x4a, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x74, 0x69, 0x6d, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x2b, 0x0a, 0x0d, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0xdc, 0x06, 0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x65,
+	0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x65, 0x6e, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x75, 0x70, 0x6c,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x64, 0x75, 0x70,
+	0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x5f,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6c, 0x61,
+	0x72, 0x67, 0x65, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x75, 0x74,
+	0x73, 0x69, 0x64, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0d, 0x6f, 0x75, 0x74, 0x73, 0x69, 0x64, 0x65, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77,
+	0x12, 0x10, 0x0a, 0x03, 0x66, 0x70, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x66,
+	0x70, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x5f, 0x6d, 0x69, 0x6e,
+	0x5f, 0x6e, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x4d, 0x69, 0x6e, 0x4e, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+	0x5f, 0x6d, 0x65, 0x61, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x4d, 0x65, 0x61, 0x6e, 0x4e, 0x73, 0x12, 0x28, 0x0a, 0x10,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x5f, 0x73, 0x74, 0x64, 0x64, 0x65, 0x76, 0x5f, 0x6e, 0x73,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x53, 0x74,
+	0x64, 0x64, 0x65, 0x76, 0x4e, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+	0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x6e, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6f,
+	0x66, 0x66, 0x73, 0x65, 0x74, 0x4d, 0x61, 0x78, 0x4e, 0x73, 0x12, 0x1e, 0x0a, 0x0b, 0x73, 0x6b,
+	0x65, 0x77, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x12, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x73, 0x6b, 0x65, 0x77, 0x4d, 0x69, 0x6e, 0x4e, 0x73, 0x12, 0x20, 0x0a, 0x0c, 0x73, 0x6b,
+	0x65, 0x77, 0x5f, 0x6d, 0x65, 0x61, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x73, 0x6b, 0x65, 0x77, 0x4d, 0x65, 0x61, 0x6e, 0x4e, 0x73, 0x12, 0x24, 0x0a, 0x0e,
+	0x73, 0x6b, 0x65, 0x77, 0x5f, 0x73, 0x74, 0x64, 0x64, 0x65, 0x76, 0x5f, 0x6e, 0x73, 0x18, 0x14,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x73, 0x6b, 0x65, 0x77, 0x53, 0x74, 0x64, 0x64, 0x65, 0x76,
+	0x4e, 0x73, 0x12, 0x1e, 0x0a, 0x0b, 0x73, 0x6b, 0x65, 0x77, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x6e,
+	0x73, 0x18, 0x15, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x6b, 0x65, 0x77, 0x4d, 0x61, 0x78,
+	0x4e, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x64, 0x65, 0x6c,
+	0x61, 0x79, 0x5f, 0x6e, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4e, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x64, 0x65,
+	0x6c, 0x61, 0x79, 0x5f, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e,
+	0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x43, 0x6f,
+	0x72, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x78, 0x72,
+	0x75, 0x6e, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x78, 0x72, 0x75, 0x6e, 0x73,
+	0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x73, 0x18, 0x0d,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x73,
+	0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x6f, 0x70, 0x65, 0x6e, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x4f, 0x70, 0x65,
+	0x6e, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x75, 0x74,
+	0x65, 0x64, 0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x6d, 0x75, 0x74, 0x65, 0x64, 0x12,
+	0x27, 0x0a, 0x0f, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x65, 0x64, 0x5f, 0x66, 0x72, 0x61, 0x6d,
+	0x65, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72,
+	0x65, 0x64, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75, 0x73,
+	0x65, 0x72, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x73, 0x65,
+	0x5f, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x18, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52,
+	0x0b, 0x63, 0x68, 0x61, 0x73, 0x65, 0x4c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x88, 0x01, 0x01, 0x12,
+	0x24, 0x0a, 0x0e, 0x63, 0x68, 0x61, 0x73, 0x65, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x6e,
+	0x73, 0x18, 0x19, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x63, 0x68, 0x61, 0x73, 0x65, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x4e, 0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x63, 0x68, 0x61, 0x73, 0x65, 0x5f,
+	0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x32, 0xea, 0x02, 0x0a, 0x07, 0x43, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x12, 0x35, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x15, 0x2e, 0x6c, 0x74,
+	0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x1a, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74,
+	0x72, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x53, 0x74, 0x6f,
+	0x70, 0x12, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65,
+	0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12,
+	0x35, 0x0a, 0x05, 0x50, 0x61, 0x75, 0x73, 0x65, 0x12, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65,
+	0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a,
+	0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x36, 0x0a, 0x06, 0x52, 0x65, 0x73, 0x75, 0x6d, 0x65,
+	0x12, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
+	0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e,
+	0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x38,
+	0x0a, 0x03, 0x4a, 0x61, 0x6d, 0x12, 0x1a, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x2e, 0x4a, 0x61, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x15, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x49, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x1d, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74,
+	0x72, 0x6f, 0x6c, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1e, 0x2e, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2e, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f,
+	0x74, 0x30, 0x01, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x61, 0x7a, 0x65, 0x6e, 0x6b, 0x2f, 0x6c, 0x74, 0x63, 0x67, 0x65, 0x6e, 0x2f, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_control_control_proto_rawDescOnce sync.Once
+	file_control_control_proto_rawDescData = file_control_control_proto_rawDesc
+)
+
+func file_control_control_proto_rawDescGZIP() []byte {
+	file_control_control_proto_rawDescOnce.Do(func() {
+		file_control_control_proto_rawDescData = protoimpl.X.CompressGZIP(file_control_control_proto_rawDescData)
+	})
+	return file_control_control_proto_rawDescData
+}
+
+var file_control_control_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_control_control_proto_goTypes = []any{
+	(*Empty)(nil),          // 0: ltcgen.control.Empty
+	(*JamRequest)(nil),     // 1: ltcgen.control.JamRequest
+	(*StatusRequest)(nil),  // 2: ltcgen.control.StatusRequest
+	(*StatusSnapshot)(nil), // 3: ltcgen.control.StatusSnapshot
+}
+var file_control_control_proto_depIdxs = []int32{
+	0, // 0: ltcgen.control.Control.Start:input_type -> ltcgen.control.Empty
+	0, // 1: ltcgen.control.Control.Stop:input_type -> ltcgen.control.Empty
+	0, // 2: ltcgen.control.Control.Pause:input_type -> ltcgen.control.Empty
+	0, // 3: ltcgen.control.Control.Resume:input_type -> ltcgen.control.Empty
+	1, // 4: ltcgen.control.Control.Jam:input_type -> ltcgen.control.JamRequest
+	2, // 5: ltcgen.control.Control.Status:input_type -> ltcgen.control.StatusRequest
+	0, // 6: ltcgen.control.Control.Start:output_type -> ltcgen.control.Empty
+	0, // 7: ltcgen.control.Control.Stop:output_type -> ltcgen.control.Empty
+	0, // 8: ltcgen.control.Control.Pause:output_type -> ltcgen.control.Empty
+	0, // 9: ltcgen.control.Control.Resume:output_type -> ltcgen.control.Empty
+	0, // 10: ltcgen.control.Control.Jam:output_type -> ltcgen.control.Empty
+	3, // 11: ltcgen.control.Control.Status:output_type -> ltcgen.control.StatusSnapshot
+	6, // [6:12] is the sub-list for method output_type
+	0, // [0:6] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_control_control_proto_init() }
+func file_control_control_proto_init() {
+	if File_control_control_proto != nil {
+		return
+	}
+	file_control_control_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_control_control_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_control_control_proto_goTypes,
+		DependencyIndexes: file_control_control_proto_depIdxs,
+		MessageInfos:      file_control_control_proto_msgTypes,
+	}.Build()
+	File_control_control_proto = out.File
+	file_control_control_proto_rawDesc = nil
+	file_control_control_proto_goTypes = nil
+	file_control_control_proto_depIdxs = nil
+}
@@ -0,0 +1,178 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// fakeRuntime records the commands it is sent
+type fakeRuntime struct {
+	mu       sync.Mutex
+	commands []string
+	sent     int64
+	stopped  bool
+}
+
+func (r *fakeRuntime) command(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return errors.New("shutting down")
+	}
+	r.commands = append(r.commands, name)
+	return nil
+}
+
+func (r *fakeRuntime) Start() error {
+	return r.command("start")
+}
+
+func (r *fakeRuntime) Stop() error {
+	return r.command("stop")
+}
+
+func (r *fakeRuntime) Pause(paused bool) error {
+	if paused {
+		return r.command("pause")
+	}
+	return r.command("resume")
+}
+
+func (r *fakeRuntime) Jam(timecode string) error {
+	if timecode == "25:00:00:00" {
+		return RequestError{errors.New("invalid timecode")}
+	}
+	return r.command("jam " + timecode)
+}
+
+func (r *fakeRuntime) Status() *StatusSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent++
+	return &StatusSnapshot{Sent: r.sent}
+}
+
+// newClient serves runtime on a local port and returns a client for it, call the returned function
+// to stop the server
+func newClient(t *testing.T, runtime Runtime, interval time.Duration) (ControlClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	RegisterControlServer(server, NewServer(runtime, interval))
+	go server.Serve(listener)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		server.Stop()
+		t.Fatalf("Unable to connect: %v", err)
+	}
+	return NewControlClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestServerCommands(t *testing.T) {
+	runtime := &fakeRuntime{}
+	client, stop := newClient(t, runtime, time.Second)
+	defer stop()
+
+	testCases := []struct {
+		Name     string
+		Call     func(ctx context.Context) error
+		Expected codes.Code
+	}{
+		{"Stop", func(ctx context.Context) error { _, err := client.Stop(ctx, &Empty{}); return err }, codes.OK},
+		{"Start", func(ctx context.Context) error { _, err := client.Start(ctx, &Empty{}); return err }, codes.OK},
+		{"Pause", func(ctx context.Context) error { _, err := client.Pause(ctx, &Empty{}); return err }, codes.OK},
+		{"Resume", func(ctx context.Context) error { _, err := client.Resume(ctx, &Empty{}); return err }, codes.OK},
+		{"Jam", func(ctx context.Context) error {
+			_, err := client.Jam(ctx, &JamRequest{Timecode: "10:00:00:00"})
+			return err
+		}, codes.OK},
+		{"JamMissingTimecode", func(ctx context.Context) error { _, err := client.Jam(ctx, &JamRequest{}); return err }, codes.InvalidArgument},
+		{"JamInvalidTimecode", func(ctx context.Context) error {
+			_, err := client.Jam(ctx, &JamRequest{Timecode: "25:00:00:00"})
+			return err
+		}, codes.InvalidArgument},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if code := status.Code(c.Call(ctx)); code != c.Expected {
+				st.Errorf("Expected status %v, got %v", c.Expected, code)
+			}
+		})
+	}
+
+	expected := []string{"stop", "start", "pause", "resume", "jam 10:00:00:00"}
+	runtime.mu.Lock()
+	defer runtime.mu.Unlock()
+	if diff := deep.Equal(runtime.commands, expected); len(diff) > 0 {
+		t.Error("Commands don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestServerShuttingDown(t *testing.T) {
+	client, stop := newClient(t, &fakeRuntime{stopped: true}, time.Second)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Start(ctx, &Empty{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected status %v, got %v", codes.Unavailable, err)
+	}
+}
+
+func TestServerStatus(t *testing.T) {
+	client, stop := newClient(t, &fakeRuntime{}, time.Hour)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Status(ctx, &StatusRequest{Interval: "1ms"})
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	for i := int64(1); i <= 3; i++ {
+		snapshot, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Status stream ended early: %v", err)
+		}
+		if snapshot.Sent != i {
+			t.Errorf("Expected status %d, got %v", i, snapshot)
+		}
+	}
+}
+
+func TestServerStatusInvalidInterval(t *testing.T) {
+	client, stop := newClient(t, &fakeRuntime{}, time.Second)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Status(ctx, &StatusRequest{Interval: "-1s"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected status %v, got %v", codes.InvalidArgument, err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// errDelayUnsupported is returned by devices that can't measure their output delay
+var errDelayUnsupported = errors.New("output delay measurement not supported by device")
+
+// delayMeter is implemented by output devices that can measure the time until a sample written now
+// is played, e.g. with snd_pcm_delay
+type delayMeter interface {
+	Delay() (time.Duration, error)
+}
+
+// delayCalibrator smooths output delay measurements, individual measurements jump by up to a period
+// depending on where the device is in its buffer.  A PID controller steers the delay compensated for
+// towards the measurements rather than following each one.
+type delayCalibrator struct {
+	pid      *pidController
+	estimate time.Duration
+	delay    time.Duration
+	measured bool
+}
+
+// newDelayCalibrator returns a calibrator starting from the estimated delay, each measurement moves
+// the smoothed delay by the correction pid gives for its difference from the measurement
+func newDelayCalibrator(estimate time.Duration, pid *pidController) *delayCalibrator {
+	return &delayCalibrator{pid: pid, estimate: estimate, delay: estimate}
+}
+
+// Update adds a measurement and returns the new smoothed delay.  The first measurement replaces
+// the estimate.
+func (c *delayCalibrator) Update(measured time.Duration) time.Duration {
+	if !c.measured {
+		c.measured = true
+		c.delay = measured
+		return c.delay
+	}
+	correction := c.pid.Update((measured - c.delay).Seconds())
+	c.delay += time.Duration(correction * float64(time.Second))
+	return c.delay
+}
+
+// Delay returns the smoothed delay
+func (c *delayCalibrator) Delay() time.Duration {
+	return c.delay
+}
+
+// Correction returns how far the smoothed delay has moved from the estimate it started from
+func (c *delayCalibrator) Correction() time.Duration {
+	return c.delay - c.estimate
+}
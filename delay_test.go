@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayCalibrator(t *testing.T) {
+	// a proportional controller moves a quarter of the way to each measurement
+	pid, err := newPIDController(0.25, 0, 0, 1)
+	if err != nil {
+		t.Fatalf("Unable to create controller: %v", err)
+	}
+	c := newDelayCalibrator(50*time.Millisecond, pid)
+	if c.Delay() != 50*time.Millisecond {
+		t.Errorf("Expected estimated delay before measuring, got %s", c.Delay())
+	}
+
+	testCases := []struct {
+		Measured time.Duration
+		Expected time.Duration
+	}{
+		{20 * time.Millisecond, 20 * time.Millisecond},
+		{28 * time.Millisecond, 22 * time.Millisecond},
+		{22 * time.Millisecond, 22 * time.Millisecond},
+		{10 * time.Millisecond, 19 * time.Millisecond},
+	}
+	for _, tc := range testCases {
+		if delay := c.Update(tc.Measured); delay != tc.Expected {
+			t.Errorf("Measured %s, expected smoothed delay %s got %s", tc.Measured, tc.Expected, delay)
+		}
+	}
+}
+
+func TestDelayCalibratorStep(t *testing.T) {
+	pid, err := newPIDController(0.1, 0.05, 0, 30)
+	if err != nil {
+		t.Fatalf("Unable to create controller: %v", err)
+	}
+	c := newDelayCalibrator(50*time.Millisecond, pid)
+	c.Update(20 * time.Millisecond)
+	if c.Correction() != -30*time.Millisecond {
+		t.Errorf("Expected the first measurement to correct the estimate by -30ms, got %s", c.Correction())
+	}
+
+	// the delay steps up by 10ms, it is followed gradually with the integral overshooting a little
+	var peak time.Duration
+	for i := 0; i < 200; i++ {
+		if delay := c.Update(30 * time.Millisecond); delay > peak {
+			peak = delay
+		}
+		if i == 0 && c.Delay() > 22*time.Millisecond {
+			t.Errorf("Expected the first step to be smoothed, got %s", c.Delay())
+		}
+	}
+	if peak > 31*time.Millisecond {
+		t.Errorf("Expected less than 1ms overshoot, peaked at %s", peak)
+	}
+	if d := 30*time.Millisecond - c.Delay(); d < -10*time.Microsecond || d > 10*time.Microsecond {
+		t.Errorf("Expected the delay to settle at 30ms, got %s", c.Delay())
+	}
+	if d := c.Correction() + 20*time.Millisecond; d < -10*time.Microsecond || d > 10*time.Microsecond {
+		t.Errorf("Expected a correction of -20ms, got %s", c.Correction())
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// asoundPCMPath lists the PCM devices of every ALSA card
+var asoundPCMPath = "/proc/asound/pcm"
+
+// PlaybackDevice is an ALSA PCM device that can play audio
+type PlaybackDevice struct {
+	Card   int
+	Device int
+	ID     string
+	Name   string
+}
+
+// ALSAName returns the name used to open the device, through the plug layer so the sample rate and
+// format are converted if the hardware doesn't support them
+func (d PlaybackDevice) ALSAName() string {
+	return fmt.Sprintf("plughw:%d,%d", d.Card, d.Device)
+}
+
+func (d PlaybackDevice) String() string {
+	return fmt.Sprintf("hw:%d,%d %s", d.Card, d.Device, d.Name)
+}
+
+// parsePCMList returns the playback devices listed in the format of /proc/asound/pcm, where each line
+// looks like "00-03: HDMI 0 : HDMI 0 : playback 1"
+func parsePCMList(r io.Reader) ([]PlaybackDevice, error) {
+	var devices []PlaybackDevice
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("unexpected PCM entry %q", line)
+		}
+		var device PlaybackDevice
+		if _, err := fmt.Sscanf(fields[0], "%d-%d", &device.Card, &device.Device); err != nil {
+			return nil, fmt.Errorf("unexpected PCM entry %q: %v", line, err)
+		}
+		device.ID = strings.TrimSpace(fields[1])
+		device.Name = strings.TrimSpace(fields[2])
+
+		playback := false
+		for _, field := range fields[3:] {
+			if strings.HasPrefix(strings.TrimSpace(field), "playback") {
+				playback = true
+			}
+		}
+		if playback {
+			devices = append(devices, device)
+		}
+	}
+	return devices, scanner.Err()
+}
+
+// listPlaybackDevices returns the ALSA playback devices on this machine
+func listPlaybackDevices() ([]PlaybackDevice, error) {
+	f, err := os.Open(asoundPCMPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list ALSA devices: %v", err)
+	}
+	defer f.Close()
+	return parsePCMList(f)
+}
+
+// selectPlaybackDevice returns the device called name, which may be its index in devices, its card
+// and device numbers as hw:0,3 or 0,3, or its ID or name
+func selectPlaybackDevice(devices []PlaybackDevice, name string) (PlaybackDevice, error) {
+	if index, err := strconv.Atoi(name); err == nil {
+		if index < 0 || index >= len(devices) {
+			return PlaybackDevice{}, fmt.Errorf("no playback device %d, there are %d", index, len(devices))
+		}
+		return devices[index], nil
+	}
+
+	var matches []PlaybackDevice
+	for _, device := range devices {
+		numbers := fmt.Sprintf("%d,%d", device.Card, device.Device)
+		switch {
+		case name == numbers, name == "hw:"+numbers, name == "plughw:"+numbers:
+			return device, nil
+		case strings.EqualFold(name, device.ID), strings.EqualFold(name, device.Name):
+			matches = append(matches, device)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return PlaybackDevice{}, fmt.Errorf("no playback device called %q, see -list-devices", name)
+	case 1:
+		return matches[0], nil
+	}
+	return PlaybackDevice{}, fmt.Errorf("%d playback devices are called %q, select one by index or hw:card,device", len(matches), name)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+const testPCMList = `00-00: ALC892 Analog : ALC892 Analog : playback 1 : capture 1
+00-01: ALC892 Digital : ALC892 Digital : playback 1
+00-02: ALC892 Alt Analog : ALC892 Alt Analog : capture 1
+01-00: USB Audio : USB Audio : playback 1 : capture 1
+02-00: USB Audio : USB Audio : playback 1
+`
+
+var testPlaybackDevices = []PlaybackDevice{
+	{0, 0, "ALC892 Analog", "ALC892 Analog"},
+	{0, 1, "ALC892 Digital", "ALC892 Digital"},
+	{1, 0, "USB Audio", "USB Audio"},
+	{2, 0, "USB Audio", "USB Audio"},
+}
+
+func TestParsePCMList(t *testing.T) {
+	devices, err := parsePCMList(strings.NewReader(testPCMList))
+	if err != nil {
+		t.Fatalf("Unable to parse PCM list: %v", err)
+	}
+	if diff := deep.Equal(devices, testPlaybackDevices); len(diff) > 0 {
+		t.Error("Playback devices don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	if _, err := parsePCMList(strings.NewReader("garbage\n")); err == nil {
+		t.Errorf("Expected an error parsing an invalid PCM list")
+	}
+}
+
+func TestSelectPlaybackDevice(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Device      string
+		Expected    string
+		ExpectError bool
+	}{
+		{"Index", "1", "plughw:0,1", false},
+		{"Numbers", "1,0", "plughw:1,0", false},
+		{"HW", "hw:2,0", "plughw:2,0", false},
+		{"Plug", "plughw:0,0", "plughw:0,0", false},
+		{"Name", "alc892 digital", "plughw:0,1", false},
+		{"Ambiguous", "USB Audio", "", true},
+		{"Missing", "HDMI", "", true},
+		{"IndexRange", "4", "", true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			device, err := selectPlaybackDevice(testPlaybackDevices, c.Device)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if err == nil && device.ALSAName() != c.Expected {
+				st.Errorf("Expected %s, got %s", c.Expected, device.ALSAName())
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// dryRunDevice is an output device that discards samples, used to run the frame loop without
+// opening a sound card
+type dryRunDevice struct {
+	sampleRate int
+	streamCh   chan []stream.Sample
+	doneCh     chan error
+}
+
+// newDryRunDevice returns a device discarding samples sent on Stream() until it is closed or ctx
+// is cancelled
+func newDryRunDevice(ctx context.Context, sampleRate int) *dryRunDevice {
+	d := &dryRunDevice{
+		sampleRate: sampleRate,
+		streamCh:   make(chan []stream.Sample, 1024),
+		doneCh:     make(chan error),
+	}
+	go d.discard(ctx)
+	return d
+}
+
+func (d *dryRunDevice) Stream() chan []stream.Sample {
+	return d.streamCh
+}
+
+func (d *dryRunDevice) Done() chan error {
+	return d.doneCh
+}
+
+func (d *dryRunDevice) SampleRate() int {
+	return d.sampleRate
+}
+
+// OutputDelay is 0, discarded samples are never played
+func (d *dryRunDevice) OutputDelay() time.Duration {
+	return 0
+}
+
+func (d *dryRunDevice) discard(ctx context.Context) {
+	defer close(d.doneCh)
+	for {
+		select {
+		case _, more := <-d.streamCh:
+			if !more {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dryRunLine returns the line printed for each frame sent in a dry run, its timecode followed by its
+// user bytes if they are set
+func dryRunLine(frame glitc.LTCFrame) string {
+	if frame.UserBytes == nil {
+		return frame.Frame().String()
+	}
+	return frame.Frame().String() + " user bytes " + hex.EncodeToString(frame.UserBytes[:])
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/ltcgen/glitc"
+)
+
+func TestDryRunDevice(t *testing.T) {
+	d := newDryRunDevice(context.Background(), 44100)
+	if d.SampleRate() != 44100 || d.OutputDelay() != 0 {
+		t.Errorf("Expected 44100 Hz with no output delay, got %d Hz and %s", d.SampleRate(), d.OutputDelay())
+	}
+
+	// samples are discarded as fast as they are sent
+	for i := 0; i < 4096; i++ {
+		d.Stream() <- []stream.Sample{1}
+	}
+	close(d.Stream())
+
+	select {
+	case err, more := <-d.Done():
+		if err != nil || more {
+			t.Errorf("Expected done to be closed without error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Device didn't finish after the stream was closed")
+	}
+}
+
+func TestDryRunLine(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 25}
+	frame.SetTimeCode(glitc.TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4}, time.Now())
+	if line := dryRunLine(frame); line != "01:02:03:04" {
+		t.Errorf("Expected only the timecode, got %q", line)
+	}
+	frame.SetUserBytes([4]byte{0xA5, 0xC3, 0x91, 0x72})
+	if line := dryRunLine(frame); line != "01:02:03:04 user bytes a5c39172" {
+		t.Errorf("Expected the timecode and user bytes, got %q", line)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// secondaryGenerator produces a second LTC stream at another frame rate, e.g. 25 fps alongside
+// 29.97 drop frame, whose samples are interleaved with the primary's.  It doesn't have a frame timer
+// of its own.  Instead it follows the frames sent by the primary generator, sending the frames that
+// begin while each primary frame is playing, so both streams come from the same sample clock and
+// stay phase coherent.  Timecode follows the primary's, so if the primary drops or repeats a frame
+// the secondary jumps with it.
+type secondaryGenerator struct {
+	frame glitc.LTCFrame
+	// primaryPos and pos are where the next primary and secondary frames begin in the stream,
+	// measured from the start of the first primary frame
+	primaryPos time.Duration
+	pos        time.Duration
+	started    bool
+	padding    time.Duration
+}
+
+func newSecondaryGenerator(frame glitc.LTCFrame) *secondaryGenerator {
+	return &secondaryGenerator{frame: frame}
+}
+
+// Padding returns the silence sent before the first secondary frame so that it begins on one of its
+// own frame boundaries, it is set by the first call to Follow
+func (g *secondaryGenerator) Padding() time.Duration {
+	return g.padding
+}
+
+// Follow returns the secondary frames that begin while primary is playing, primary being the next
+// frame sent by the primary generator
+func (g *secondaryGenerator) Follow(primary glitc.LTCFrame) []glitc.LTCFrame {
+	primaryBegin := primary.FrameBeginTime()
+	frameDuration := g.frame.FrameDuration()
+	if !g.started {
+		g.frame.Time = primaryBegin
+		begin := g.frame.FrameBeginTime()
+		// frame durations are truncated to whole nanoseconds, so begin times computed from midnight
+		// can be more than a frame early when a frame isn't a whole number of nanoseconds
+		for begin.Before(primaryBegin) {
+			begin = begin.Add(frameDuration)
+		}
+		g.padding = begin.Sub(primaryBegin)
+		g.pos = g.padding
+		g.started = true
+	}
+
+	primaryEnd := g.primaryPos + primary.FrameDuration()
+	var frames []glitc.LTCFrame
+	for ; g.pos < primaryEnd; g.pos += frameDuration {
+		frame := g.frame
+		// the middle of the frame keeps rounding from selecting its neighbour
+		frame.Time = primaryBegin.Add(g.pos - g.primaryPos).Add(frameDuration / 2)
+		frames = append(frames, frame)
+	}
+	g.primaryPos = primaryEnd
+	return frames
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+func TestSecondaryGenerator(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Primary   glitc.LTCFrame
+		Secondary glitc.LTCFrame
+	}{
+		{"29.97df/25", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, glitc.LTCFrame{FramesPerSecond: 25}},
+		{"25/29.97df", glitc.LTCFrame{FramesPerSecond: 25}, glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+		{"30/24", glitc.LTCFrame{FramesPerSecond: 30}, glitc.LTCFrame{FramesPerSecond: 24}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			primaryDuration := c.Primary.FrameDuration()
+			secondaryDuration := c.Secondary.FrameDuration()
+			start := time.Date(2018, 12, 1, 10, 14, 21, 12345678, time.Local)
+			g := newSecondaryGenerator(c.Secondary)
+
+			var sent []glitc.LTCFrame
+			primary := c.Primary
+			primary.Time = start
+			firstBegin := primary.FrameBeginTime()
+			for i := 0; i < 600; i++ {
+				primary.Time = firstBegin.Add(time.Duration(i) * primaryDuration).Add(primaryDuration / 2)
+				sent = append(sent, g.Follow(primary)...)
+			}
+
+			if g.Padding() < 0 || g.Padding() >= secondaryDuration {
+				st.Fatalf("Expected padding of less than a frame, got %s", g.Padding())
+			}
+			// the first secondary frame starts on one of its own frame boundaries
+			if begin := sent[0].FrameBeginTime(); !begin.Equal(firstBegin.Add(g.Padding())) {
+				st.Errorf("Expected first frame to begin at %s, got %s", firstBegin.Add(g.Padding()), begin)
+			}
+
+			expected := int((600*primaryDuration - g.Padding() + secondaryDuration - 1) / secondaryDuration)
+			if len(sent) != expected {
+				st.Errorf("Expected %d frames in 600 primary frames, got %d", expected, len(sent))
+			}
+			for i := 1; i < len(sent); i++ {
+				if next := sent[i-1].Frame().Add(1, c.Secondary.FramesPerSecond); sent[i].Frame() != next {
+					st.Fatalf("Frame %d is %s, expected %s", i, sent[i].Frame(), next)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package glitc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// FrameField describes a field of an encoded frame, bits are numbered in the order they are sent
+type FrameField struct {
+	Name  string
+	First int
+	Bits  int
+	// BCD fields hold a timecode digit sent least significant bit first
+	BCD bool
+}
+
+// FrameFields returns the layout of a frame at fps, the flag bits move at 25fps
+func FrameFields(fps float64) []FrameField {
+	return SMPTE12M1999.FrameFields(fps)
+}
+
+// FrameFields returns the layout of a frame at fps with this revision's flag bit assignments
+func (v SpecVersion) FrameFields(fps float64) []FrameField {
+	flags := LTCFrame{FramesPerSecond: fps, SpecVersion: v}.flagBits()
+	names := map[int]string{flags.bgf0: "binary group flag 0", flags.bgf2: "binary group flag 2", flags.parity: "phase correction"}
+	return []FrameField{
+		{"frame units", 0, 4, true},
+		{"user bits 1", 4, 4, false},
+		{"frame tens", 8, 2, true},
+		{"drop frame", 10, 1, false},
+		{"color frame", 11, 1, false},
+		{"user bits 2", 12, 4, false},
+		{"second units", 16, 4, true},
+		{"user bits 3", 20, 4, false},
+		{"second tens", 24, 3, true},
+		{names[27], 27, 1, false},
+		{"user bits 4", 28, 4, false},
+		{"minute units", 32, 4, true},
+		{"user bits 5", 36, 4, false},
+		{"minute tens", 40, 3, true},
+		{names[43], 43, 1, false},
+		{"user bits 6", 44, 4, false},
+		{"hour units", 48, 4, true},
+		{"user bits 7", 52, 4, false},
+		{"hour tens", 56, 2, true},
+		{"binary group flag 1", 58, 1, false},
+		{names[59], 59, 1, false},
+		{"user bits 8", 60, 4, false},
+		{"sync word", 64, 16, false},
+	}
+}
+
+// frameBit returns bit n of binaryFrame in the order it is sent
+func frameBit(binaryFrame []byte, n int) int {
+	return int(binaryFrame[n/8]>>uint(7-n%8)) & 0x1
+}
+
+// DebugFrame renders each of the 80 bits of binaryFrame at fps on its own line with its meaning.  Timecode
+// digits show the weight of each bit and the digit they add up to, and sync word bits that don't match
+// the sync pattern are marked.
+func DebugFrame(binaryFrame []byte, fps float64) string {
+	if len(binaryFrame) != 10 {
+		return fmt.Sprintf("invalid frame %x, expected 10 bytes", binaryFrame)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", hex.EncodeToString(binaryFrame))
+	for _, field := range FrameFields(fps) {
+		value := 0
+		for i := 0; i < field.Bits; i++ {
+			n := field.First + i
+			bit := frameBit(binaryFrame, n)
+			var detail string
+			switch {
+			case field.BCD:
+				value |= bit << uint(i)
+				detail = fmt.Sprintf("weight %d", 1<<uint(i))
+				if i == field.Bits-1 {
+					detail += fmt.Sprintf(", digit %d", value)
+				}
+			case field.Name == "sync word":
+				expected := SyncBits >> uint(15-i) & 0x1
+				detail = fmt.Sprintf("bit %d", i)
+				if bit != expected {
+					detail += fmt.Sprintf(", expected %d", expected)
+				}
+			case field.Bits > 1:
+				detail = fmt.Sprintf("bit %d", i)
+			}
+			line := fmt.Sprintf("%2d %d %-20s %s", n, bit, field.Name, detail)
+			fmt.Fprintf(&b, "%s\n", strings.TrimRight(line, " "))
+		}
+	}
+	return b.String()
+}
+
+// DebugString renders the encoded frame bit by bit, see DebugFrame
+func (f LTCFrame) DebugString() string {
+	return DebugFrame(f.EncodeFrame(), f.FramesPerSecond)
+}
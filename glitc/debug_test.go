@@ -0,0 +1,56 @@
+package glitc
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "Rewrite golden files with the current output")
+
+func TestDebugString(t *testing.T) {
+	userBytes := [4]byte{0x12, 0x34, 0x56, 0x78}
+	testCases := []struct {
+		Name     string
+		Frame    LTCFrame
+		TimeCode TimeCode
+	}{
+		{"30fps", LTCFrame{FramesPerSecond: 30, DropFrame: true, ExternalClockSync: true, UserBytes: &userBytes}, TimeCode{Hour: 23, Minute: 14, Second: 21, Frame: 29, DropFrame: true}},
+		{"25fps", LTCFrame{FramesPerSecond: 25, ColorFrame: true, BinaryGroupFlags: BGF0, UserBytes: &userBytes}, TimeCode{Hour: 10, Minute: 59, Second: 48, Frame: 24}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			c.Frame.SetTimeCode(c.TimeCode, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+			got := c.Frame.DebugString()
+
+			golden := filepath.Join("testdata", "debug_"+c.Name+".golden")
+			if *update {
+				if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+					st.Fatalf("Unable to update golden file: %v", err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				st.Fatalf("Unable to read golden file: %v", err)
+			}
+			if got != string(expected) {
+				st.Errorf("Debug output doesn't match %s, run with -update if the change is intended:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestDebugFrameSync(t *testing.T) {
+	binaryFrame := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xFC}
+	got := DebugFrame(binaryFrame, 30)
+	if !strings.Contains(got, "79 0 sync word            bit 15, expected 1\n") {
+		t.Errorf("Expected the corrupted sync bit to be marked:\n%s", got)
+	}
+	if strings.Count(got, "expected") != 1 {
+		t.Errorf("Expected only one sync bit to be marked:\n%s", got)
+	}
+}
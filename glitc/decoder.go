@@ -0,0 +1,108 @@
+package glitc
+
+// LTCDecoder recovers encoded frames from biphase mark coded samples, the inverse of LTCReader.
+// Bits are found from the time between zero crossings, a full bit period without a crossing is a 0
+// and two half bit periods are a 1.
+type LTCDecoder struct {
+	halfBitThreshold float64
+	sinceCrossing    float64
+	high             bool
+	started          bool
+	halfBit          bool
+	bits             []byte
+}
+
+// NewLTCDecoder returns a decoder for samples at sampleRate carrying bitRate bits per second, 80
+// times the frame rate
+func NewLTCDecoder(sampleRate float64, bitRate float64) *LTCDecoder {
+	return &LTCDecoder{
+		// anything shorter than three quarters of a bit is treated as half a bit
+		halfBitThreshold: 0.75 * sampleRate / bitRate,
+		bits:             make([]byte, 0, 80),
+	}
+}
+
+// DecodedFrame is a frame completed by LTCDecoder.DecodeFrames.  End is the index of the sample at
+// the edge that ended the frame, which is also where the following frame starts.
+type DecodedFrame struct {
+	Bytes []byte
+	End   int
+}
+
+// Decode consumes samples and returns any frames that were completed, each 10 bytes in the form
+// returned by LTCFrame.EncodeFrame
+func (d *LTCDecoder) Decode(samples []int32) [][]byte {
+	var frames [][]byte
+	for _, frame := range d.DecodeFrames(samples) {
+		frames = append(frames, frame.Bytes)
+	}
+	return frames
+}
+
+// DecodeFrames is Decode returning where in samples each frame ended, so a receiver can tell when
+// frames arrived
+func (d *LTCDecoder) DecodeFrames(samples []int32) []DecodedFrame {
+	var frames []DecodedFrame
+	for i, sample := range samples {
+		high := sample > 0
+		if !d.started {
+			d.started = true
+			d.high = high
+			continue
+		}
+
+		d.sinceCrossing++
+		if high == d.high {
+			continue
+		}
+		d.high = high
+		interval := d.sinceCrossing
+		d.sinceCrossing = 0
+
+		if interval < d.halfBitThreshold {
+			if d.halfBit {
+				d.halfBit = false
+				if frame := d.addBit(1); frame != nil {
+					frames = append(frames, DecodedFrame{frame, i})
+				}
+			} else {
+				d.halfBit = true
+			}
+			continue
+		}
+
+		// a lone half bit means we were out of step, start again from this crossing
+		d.halfBit = false
+		if frame := d.addBit(0); frame != nil {
+			frames = append(frames, DecodedFrame{frame, i})
+		}
+	}
+	return frames
+}
+
+// addBit appends a bit, returning the frame once the last 80 bits end in the sync word
+func (d *LTCDecoder) addBit(bit byte) []byte {
+	if len(d.bits) == 80 {
+		copy(d.bits, d.bits[1:])
+		d.bits = d.bits[:79]
+	}
+	d.bits = append(d.bits, bit)
+	if len(d.bits) < 80 {
+		return nil
+	}
+
+	var sync int
+	for _, b := range d.bits[64:] {
+		sync = sync<<1 | int(b)
+	}
+	if sync != SyncBits {
+		return nil
+	}
+
+	frame := make([]byte, 10)
+	for i, b := range d.bits {
+		frame[i/8] |= b << uint(7-i%8)
+	}
+	d.bits = d.bits[:0]
+	return frame
+}
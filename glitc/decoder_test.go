@@ -0,0 +1,79 @@
+package glitc
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/biphase"
+	"github.com/go-test/deep"
+)
+
+func TestLTCDecoder(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		SampleRate float64
+		Frame      LTCFrame
+	}{
+		{"48000@30", 48000, LTCFrame{FramesPerSecond: 30}},
+		{"44100@29.97df", 44100, LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+		{"48000@25", 48000, LTCFrame{FramesPerSecond: 25}},
+		{"44100@23.976", 44100, LTCFrame{FramesPerSecond: 24, PullDown: true}},
+		{"96000@60", 96000, LTCFrame{FramesPerSecond: 60}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			f.SetTimeCode(TimeCode{Hour: 10, Minute: 59, Second: 59, DropFrame: f.DropFrame}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+			encoder, err := biphase.NewEncoder(c.SampleRate, f.EffectiveFPS()*80, math.MaxInt32/2)
+			if err != nil {
+				st.Fatalf("Unable to create encoder: %v", err)
+			}
+			decoder := NewLTCDecoder(c.SampleRate, f.EffectiveFPS()*80)
+
+			var expected, decoded [][]byte
+			var samples []int32
+			for i := 0; i < 90; i++ {
+				binaryFrame := f.EncodeFrame()
+				expected = append(expected, binaryFrame)
+				samples = encoder.Encode(samples[:0], binaryFrame)
+				decoded = append(decoded, decoder.Decode(samples)...)
+				f.Time = f.Time.Add(f.FrameDuration())
+			}
+
+			// the last bit of a frame isn't complete until the edge starting the following frame
+			if diff := deep.Equal(decoded, expected[:len(expected)-1]); len(diff) > 0 {
+				st.Error("Decoded frames don't match encoded frames:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestLTCDecoderFrameEnds(t *testing.T) {
+	f := LTCFrame{FramesPerSecond: 30}
+	f.SetTimeCode(TimeCode{Hour: 1}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	// 48kHz at 30fps is exactly 20 samples per bit and 1600 per frame
+	encoder, err := biphase.NewEncoder(48000, f.EffectiveFPS()*80, math.MaxInt32/2)
+	if err != nil {
+		t.Fatalf("Unable to create encoder: %v", err)
+	}
+	decoder := NewLTCDecoder(48000, f.EffectiveFPS()*80)
+
+	var samples []int32
+	for i := 0; i < 4; i++ {
+		samples = encoder.Encode(samples, f.EncodeFrame())
+		f.Time = f.Time.Add(f.FrameDuration())
+	}
+
+	var ends []int
+	for _, frame := range decoder.DecodeFrames(samples) {
+		ends = append(ends, frame.End)
+	}
+	if diff := deep.Equal(ends, []int{1600, 3200, 4800}); len(diff) > 0 {
+		t.Errorf("Frame ends don't match expected: %v", diff)
+	}
+}
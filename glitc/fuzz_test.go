@@ -0,0 +1,184 @@
+//go:build go1.18
+// +build go1.18
+
+package glitc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+// Flag bits of the fuzzed flags byte
+const (
+	fuzzColorFrame = 1 << iota
+	fuzzExternalClockSync
+	fuzzBGF0
+	fuzzBGF2
+	fuzzSendFieldMark
+	fuzzFieldMark
+	fuzz2008
+)
+
+func FuzzFrameRoundTrip(f *testing.F) {
+	f.Add(uint8(0), uint32(0), uint8(0), uint32(0))
+	f.Add(uint8(3), uint32(17982), uint8(fuzzColorFrame|fuzzBGF0), uint32(0x4C544321))
+	f.Add(uint8(6), uint32(2591999), uint8(fuzzExternalClockSync|fuzzSendFieldMark|fuzzFieldMark), uint32(0xFFFFFFFF))
+	f.Add(uint8(7), uint32(5178815), uint8(fuzzBGF0|fuzzBGF2|fuzz2008), uint32(0xA5C39172))
+	f.Add(uint8(5), uint32(89999), uint8(fuzz2008|fuzzSendFieldMark), uint32(0x12345678))
+
+	day := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+	f.Fuzz(func(t *testing.T, rate uint8, index uint32, flags uint8, user uint32) {
+		r := Rates()[int(rate)%len(Rates())]
+		frame := r.Frame()
+		frame.ColorFrame = flags&fuzzColorFrame != 0
+		frame.ExternalClockSync = flags&fuzzExternalClockSync != 0
+		frame.SendFieldMark = flags&fuzzSendFieldMark != 0
+		frame.FieldMark = frame.SendFieldMark && flags&fuzzFieldMark != 0
+		if flags&fuzz2008 != 0 {
+			frame.SpecVersion = SMPTE12M2008
+		}
+		if flags&fuzzBGF0 != 0 {
+			frame.BinaryGroupFlags |= BGF0
+		}
+		if flags&fuzzBGF2 != 0 {
+			frame.BinaryGroupFlags |= BGF2
+		}
+		if frame.BinaryGroupFlags != 0 || user != 0 {
+			var userBytes [4]byte
+			binary.BigEndian.PutUint32(userBytes[:], user)
+			frame.UserBytes = &userBytes
+		}
+		if err := frame.Validate(); err != nil {
+			// e.g. BGF2 alone with user bits that aren't a date
+			t.Skip(err)
+		}
+
+		// pulled down timecode only reaches as far as the frames that fit in a day
+		fps := float64(frame.nominalFPS())
+		tc := TimeCodeFromFrames(int(index)%frame.FramesPerDay(), fps, frame.DropFrame)
+		if frame.FramesPerSecond > 30 {
+			// only the frame pair is sent, decoded frames are the first of the pair
+			tc.Frame &^= 1
+		}
+		frame.SetTimeCode(tc, day)
+		if frame.Frame() != tc {
+			t.Fatalf("%s frame set to %s holds %s", r, tc, frame.Frame())
+		}
+
+		template := LTCFrame{
+			Time:            day,
+			FramesPerSecond: frame.FramesPerSecond,
+			PullDown:        frame.PullDown,
+			SendFieldMark:   frame.SendFieldMark,
+			SpecVersion:     frame.SpecVersion,
+		}
+		decoded, err := template.DecodeFrame(frame.EncodeFrame())
+		if err != nil {
+			t.Fatalf("Unable to decode %s frame %s: %v", r, tc, err)
+		}
+		if decoded.Frame() != tc {
+			t.Errorf("Decoded %s frame %s as %s", r, tc, decoded.Frame())
+		}
+		decoded.Time = frame.Time
+		if diff := deep.Equal(decoded, frame); len(diff) > 0 {
+			t.Errorf("Decoded %s frame %s doesn't match original: %v", r, tc, diff)
+		}
+	})
+}
+
+func FuzzDecodeFrame(f *testing.F) {
+	valid := LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.UTC), FramesPerSecond: 30}
+	f.Add(valid.EncodeFrame(), uint8(30), false)
+	f.Add(valid.EncodeFrame(), uint8(60), true)
+	f.Add(make([]byte, FrameBytes), uint8(25), false)
+	f.Add([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x3F, 0xFD}, uint8(24), false)
+	f.Add([]byte{0xBF, 0xFC, 0, 0, 0, 0, 0, 0, 0, 0}, uint8(30), false)
+	f.Add([]byte{0x3F}, uint8(30), false)
+	dropFrame := LTCFrame{Time: time.Date(2018, 12, 1, 10, 1, 0, 100000000, time.UTC), FramesPerSecond: 30, DropFrame: true}
+	f.Add(dropFrame.EncodeFrame(), uint8(30), false)
+	f.Add(dropFrame.EncodeFrame(), uint8(25), false)
+	// frame units of 15, the four extra ones keep the parity even
+	bcd := valid.EncodeFrame()
+	bcd[0] |= 0xF0
+	f.Add(bcd, uint8(30), false)
+
+	f.Fuzz(func(t *testing.T, b []byte, fps uint8, sendFieldMark bool) {
+		template := LTCFrame{
+			Time:            time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+			FramesPerSecond: float64(fps),
+			SendFieldMark:   sendFieldMark,
+		}
+		frame, err := template.DecodeFrame(b)
+		switch {
+		case len(b) != FrameBytes:
+			if err == nil {
+				t.Errorf("Expected an error decoding %d bytes", len(b))
+			}
+			return
+		case !template.rateSet():
+			if err != ErrNoFrameRate {
+				t.Errorf("Expected %v decoding at %d fps, got %v", ErrNoFrameRate, fps, err)
+			}
+			return
+		case int(b[8])<<8|int(b[9]) != SyncBits:
+			if err != ErrSyncWord && err != ErrReversed {
+				t.Errorf("Expected a sync word error decoding % x, got %v", b, err)
+			}
+			return
+		case !sendFieldMark && !EvenParity(b):
+			if err != ErrParity {
+				t.Errorf("Expected a parity error decoding % x, got %v", b, err)
+			}
+			return
+		}
+
+		// digits are sent least significant bit first
+		digit := func(first, width int) int {
+			var d int
+			for i := 0; i < width; i++ {
+				d |= frameBit(b, first+i) << uint(i)
+			}
+			return d
+		}
+		units := []int{digit(0, 4), digit(16, 4), digit(32, 4), digit(48, 4)}
+		for _, u := range units {
+			if u > 9 {
+				if err != ErrBCD {
+					t.Errorf("Expected %v decoding % x, got %v", ErrBCD, b, err)
+				}
+				return
+			}
+		}
+		tc := TimeCode{
+			Hour:      10*digit(56, 2) + units[3],
+			Minute:    10*digit(40, 3) + units[2],
+			Second:    10*digit(24, 3) + units[1],
+			Frame:     10*digit(8, 2) + units[0],
+			DropFrame: frameBit(b, 10) != 0,
+		}
+		if template.FramesPerSecond > 30 {
+			tc.Frame *= 2
+		}
+		// IsValid also rejects drop frame at rates without it
+		if !tc.IsValid(template.FramesPerSecond, tc.DropFrame) {
+			if err != ErrInvalidTimeCode {
+				t.Errorf("Expected %v decoding %s from % x at %d fps, got %v", ErrInvalidTimeCode, tc, b, fps, err)
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("Unexpected error decoding % x: %v", b, err)
+		}
+		if frame.Frame() != tc {
+			t.Errorf("Decoded % x at %d fps as %s, expected %s", b, fps, frame.Frame(), tc)
+		}
+		if encoded := frame.EncodeFrame(); !bytes.Equal(encoded, b) {
+			t.Errorf("Frame decoded from % x at %d fps encodes as % x", b, fps, encoded)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package glitc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rate is a named frame rate.  Each rate maps to exactly one combination of FramesPerSecond,
+// DropFrame and PullDown, so 29.97 fps can't be mistaken for 30.
+type Rate int
+
+const (
+	Rate23976 Rate = iota + 1
+	Rate24
+	Rate25
+	Rate2997DF
+	Rate2997ND
+	Rate30ND
+	Rate50
+	Rate5994ND
+	Rate60
+	Rate5994DF
+)
+
+// RateSpec describes a supported frame rate.  FPS is the number of frames counted in each second of
+// timecode, and EffectiveFPS the number actually sent each second, which is lower for pulled down
+// and drop frame rates.
+type RateSpec struct {
+	Rate         Rate
+	Name         string
+	FPS          float64
+	DropFrame    bool
+	PullDown     bool
+	EffectiveFPS float64
+}
+
+// SupportedRates lists every supported frame rate, slowest first.  Rates, ParseRate and the command
+// line options all follow this table, so a new rate only needs adding here.
+var SupportedRates = []RateSpec{
+	{Rate23976, "23.976", 24, false, true, 24000.0 / 1001},
+	{Rate24, "24", 24, false, false, 24},
+	{Rate25, "25", 25, false, false, 25},
+	{Rate2997DF, "29.97df", 30, true, false, 29.97},
+	{Rate2997ND, "29.97nd", 30, false, true, 30000.0 / 1001},
+	{Rate30ND, "30nd", 30, false, false, 30},
+	{Rate50, "50", 50, false, false, 50},
+	{Rate5994DF, "59.94df", 60, true, false, 59.94},
+	{Rate5994ND, "59.94nd", 60, false, true, 60000.0 / 1001},
+	{Rate60, "60", 60, false, false, 60},
+}
+
+// Rates returns every named rate, slowest first
+func Rates() []Rate {
+	rates := make([]Rate, len(SupportedRates))
+	for i, spec := range SupportedRates {
+		rates[i] = spec.Rate
+	}
+	return rates
+}
+
+// Frame returns a frame with the flags for this rate set
+func (s RateSpec) Frame() LTCFrame {
+	return LTCFrame{FramesPerSecond: s.FPS, DropFrame: s.DropFrame, PullDown: s.PullDown}
+}
+
+// Spec returns the entry for this rate in SupportedRates, and false if it isn't one of them
+func (r Rate) Spec() (RateSpec, bool) {
+	for _, spec := range SupportedRates {
+		if spec.Rate == r {
+			return spec, true
+		}
+	}
+	return RateSpec{}, false
+}
+
+// ParseRate returns the rate with the given name, e.g. 25, 29.97df or 30nd.  29.97, 30 and 59.94 on
+// their own are ambiguous and are rejected.
+func ParseRate(name string) (Rate, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, spec := range SupportedRates {
+		if spec.Name == name {
+			return spec.Rate, nil
+		}
+	}
+
+	var names []string
+	for _, spec := range SupportedRates {
+		names = append(names, spec.Name)
+	}
+	switch name {
+	case "29.97", "30":
+		return 0, fmt.Errorf("frame rate %s is ambiguous, use one of 29.97df, 29.97nd or 30nd", name)
+	case "59.94":
+		return 0, fmt.Errorf("frame rate %s is ambiguous, use one of 59.94df or 59.94nd", name)
+	}
+	return 0, fmt.Errorf("unknown frame rate %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+func (r Rate) String() string {
+	if spec, ok := r.Spec(); ok {
+		return spec.Name
+	}
+	return fmt.Sprintf("Rate(%d)", int(r))
+}
+
+// Frame returns a frame with the flags for this rate set
+func (r Rate) Frame() LTCFrame {
+	spec, _ := r.Spec()
+	return spec.Frame()
+}
+
+// EffectiveFPS returns the number of frames per second actually sent at this rate
+func (r Rate) EffectiveFPS() float64 {
+	spec, _ := r.Spec()
+	return spec.EffectiveFPS
+}
+
+// FrameDuration returns the length of a frame at this rate
+func (r Rate) FrameDuration() time.Duration {
+	return r.Frame().FrameDuration()
+}
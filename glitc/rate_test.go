@@ -0,0 +1,77 @@
+package glitc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRate(t *testing.T) {
+	testCases := []struct {
+		Rate          Rate
+		EffectiveFPS  float64
+		FrameDuration time.Duration
+	}{
+		{Rate23976, 24000.0 / 1001, 41708333},
+		{Rate24, 24, 41666666},
+		{Rate25, 25, 40 * time.Millisecond},
+		{Rate2997DF, 29.97, 33366700},
+		{Rate2997ND, 30000.0 / 1001, 33366666},
+		{Rate30ND, 30, 33333333},
+		{Rate50, 50, 20 * time.Millisecond},
+		{Rate5994DF, 59.94, 16683350},
+		{Rate5994ND, 60000.0 / 1001, 16683333},
+		{Rate60, 60, 16666666},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Rate.String(), func(st *testing.T) {
+			if fps := c.Rate.EffectiveFPS(); fps != c.EffectiveFPS {
+				st.Errorf("Expected %f fps, got %f", c.EffectiveFPS, fps)
+			}
+			if d := c.Rate.FrameDuration(); d != c.FrameDuration {
+				st.Errorf("Expected frame duration %s, got %s", c.FrameDuration, d)
+			}
+
+			r, err := ParseRate(c.Rate.String())
+			if err != nil {
+				st.Fatalf("Unable to parse rate name: %v", err)
+			}
+			if r != c.Rate {
+				st.Errorf("Parsed %s as %s", c.Rate, r)
+			}
+		})
+	}
+}
+
+func TestParseRateErrors(t *testing.T) {
+	for _, name := range []string{"29.97", "30", "59.94", "30df", "25df", "", "fast"} {
+		if r, err := ParseRate(name); err == nil {
+			t.Errorf("Expected error parsing %q, got %s", name, r)
+		}
+	}
+}
+
+func TestSupportedRates(t *testing.T) {
+	names := make(map[string]bool)
+	for _, spec := range SupportedRates {
+		t.Run(spec.Name, func(st *testing.T) {
+			if names[spec.Name] {
+				st.Errorf("Rate name %s is used twice", spec.Name)
+			}
+			names[spec.Name] = true
+
+			if fps := spec.Frame().EffectiveFPS(); fps != spec.EffectiveFPS {
+				st.Errorf("Frame runs at %f fps, expected %f", fps, spec.EffectiveFPS)
+			}
+			if err := spec.Frame().Validate(); err != nil {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+			if s, ok := spec.Rate.Spec(); !ok || s != spec {
+				st.Errorf("Rate %d returned spec %+v", int(spec.Rate), s)
+			}
+		})
+	}
+	if _, ok := Rate(0).Spec(); ok {
+		t.Errorf("Expected no spec for an unknown rate")
+	}
+}
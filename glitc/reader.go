@@ -0,0 +1,83 @@
+package glitc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/azenk/ltcgen/biphase"
+)
+
+// LTCReader is an io.Reader producing signed little endian PCM samples of continuously advancing LTC
+type LTCReader struct {
+	ctx     context.Context
+	frames  chan []byte
+	pending []byte
+}
+
+// NewLTCReader returns a reader generating LTC starting at frame.Time using the frame's rate and flags.
+// Frames are generated as fast as they are read until ctx is cancelled, after which Read returns io.EOF.
+func NewLTCReader(ctx context.Context, frame LTCFrame, sampleRate int, bitsPerSample int) (*LTCReader, error) {
+	if bitsPerSample != 16 && bitsPerSample != 32 {
+		return nil, fmt.Errorf("unsupported bits per sample: %d", bitsPerSample)
+	}
+	if sampleRate < 1 {
+		return nil, fmt.Errorf("unsupported sample rate: %d", sampleRate)
+	}
+	if err := frame.Validate(); err != nil {
+		return nil, err
+	}
+	encoder, err := biphase.NewEncoder(float64(sampleRate), frame.EffectiveFPS()*80, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &LTCReader{
+		ctx:    ctx,
+		frames: make(chan []byte, 2),
+	}
+	go r.generate(frame, encoder, bitsPerSample)
+	return r, nil
+}
+
+func (r *LTCReader) generate(frame LTCFrame, encoder *biphase.Encoder, bitsPerSample int) {
+	sampleSize := bitsPerSample / 8
+	var samples []int32
+
+	for {
+		samples = encoder.Encode(samples[:0], frame.EncodeFrame())
+		pcm := make([]byte, len(samples)*sampleSize)
+		for i, sample := range samples {
+			switch bitsPerSample {
+			case 16:
+				binary.LittleEndian.PutUint16(pcm[i*sampleSize:], uint16(sample>>16))
+			case 32:
+				binary.LittleEndian.PutUint32(pcm[i*sampleSize:], uint32(sample))
+			}
+		}
+
+		select {
+		case r.frames <- pcm:
+		case <-r.ctx.Done():
+			return
+		}
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+}
+
+// Read fills p with PCM samples, blocking until at least one frame has been generated
+func (r *LTCReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		select {
+		case r.pending = <-r.frames:
+		case <-r.ctx.Done():
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
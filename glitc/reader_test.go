@@ -0,0 +1,84 @@
+package glitc
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+// decodeBiphase recovers bytes from biphase mark samples with a whole number of samples per bit
+func decodeBiphase(samples []int16, samplesPerBit int) []byte {
+	decoded := make([]byte, len(samples)/samplesPerBit/8)
+	for bit := 0; bit < len(decoded)*8; bit++ {
+		start := samples[bit*samplesPerBit]
+		mid := samples[bit*samplesPerBit+samplesPerBit/2]
+		if (start > 0) != (mid > 0) {
+			decoded[bit/8] |= 0x80 >> uint(bit%8)
+		}
+	}
+	return decoded
+}
+
+func TestLTCReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	frame := LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30}
+	r, err := NewLTCReader(ctx, frame, 48000, 16)
+	if err != nil {
+		t.Fatalf("Unable to create reader: %v", err)
+	}
+
+	// 48000 Hz at 30 fps is 1600 samples per frame and 20 samples per bit
+	pcm := make([]byte, 2*1600*2)
+	if _, err := io.ReadFull(r, pcm); err != nil {
+		t.Fatalf("Unable to read samples: %v", err)
+	}
+
+	samples := make([]int16, len(pcm)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:]))
+	}
+
+	for i := 1; i < len(samples); i++ {
+		if i%10 != 0 && samples[i] != samples[i-1] {
+			t.Fatalf("Unexpected transition at sample %d", i)
+		}
+		if i%20 == 0 && samples[i] == samples[i-1] {
+			t.Fatalf("Missing bit transition at sample %d", i)
+		}
+	}
+
+	expected := frame.EncodeFrame()
+	frame.Time = frame.Time.Add(frame.FrameDuration())
+	expected = append(expected, frame.EncodeFrame()...)
+	if diff := deep.Equal(decodeBiphase(samples, 20), expected); len(diff) > 0 {
+		t.Error("Decoded samples don't match encoded frames:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	cancel()
+	buf := make([]byte, 1024)
+	for err == nil {
+		_, err = r.Read(buf)
+	}
+	if err != io.EOF {
+		t.Errorf("Expected EOF after cancel, got %v", err)
+	}
+}
+
+func TestLTCReaderConfig(t *testing.T) {
+	frame := LTCFrame{FramesPerSecond: 30}
+	if _, err := NewLTCReader(context.Background(), frame, 48000, 24); err == nil {
+		t.Errorf("Expected error for unsupported bits per sample")
+	}
+	if _, err := NewLTCReader(context.Background(), frame, 0, 16); err == nil {
+		t.Errorf("Expected error for unsupported sample rate")
+	}
+}
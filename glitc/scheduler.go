@@ -0,0 +1,33 @@
+package glitc
+
+import "math"
+
+// SampleScheduler divides a stream of samples into periods, such as frames or bits, that don't
+// contain a whole number of samples.  Each period is given a whole number of samples and the
+// remainder is carried into the following periods, so the total number of samples after n periods
+// is always within half a sample of n periods worth of time.
+type SampleScheduler struct {
+	sampleRate       float64
+	periodsPerSecond float64
+	periods          int64
+	samples          int64
+}
+
+// NewSampleScheduler returns a scheduler for periodsPerSecond periods at sampleRate
+func NewSampleScheduler(sampleRate float64, periodsPerSecond float64) *SampleScheduler {
+	return &SampleScheduler{sampleRate: sampleRate, periodsPerSecond: periodsPerSecond}
+}
+
+// Next returns the number of samples in the next period
+func (s *SampleScheduler) Next() int {
+	s.periods++
+	end := int64(math.Round(float64(s.periods) * s.sampleRate / s.periodsPerSecond))
+	n := end - s.samples
+	s.samples = end
+	return int(n)
+}
+
+// Samples returns the total number of samples scheduled so far
+func (s *SampleScheduler) Samples() int64 {
+	return s.samples
+}
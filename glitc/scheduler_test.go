@@ -0,0 +1,63 @@
+package glitc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSampleScheduler(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		SampleRate float64
+		Frame      LTCFrame
+	}{
+		{"48000@24", 48000, LTCFrame{FramesPerSecond: 24}},
+		{"48000@25", 48000, LTCFrame{FramesPerSecond: 25}},
+		{"48000@29.97df", 48000, LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+		{"44100@29.97df", 44100, LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+		{"44100@23.976", 44100, LTCFrame{FramesPerSecond: 24, PullDown: true}},
+		{"44100@30", 44100, LTCFrame{FramesPerSecond: 30}},
+		{"48000@60", 48000, LTCFrame{FramesPerSecond: 60}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			samplesPerFrame := c.Frame.SamplesPerFrame(c.SampleRate)
+			s := NewSampleScheduler(c.SampleRate, c.Frame.EffectiveFPS())
+
+			// one hour of frames
+			frames := int(3600 * c.Frame.EffectiveFPS())
+			for i := 1; i <= frames; i++ {
+				n := s.Next()
+				if n != int(math.Floor(samplesPerFrame)) && n != int(math.Ceil(samplesPerFrame)) {
+					st.Fatalf("Frame %d has %d samples, expected %f", i, n, samplesPerFrame)
+				}
+				if drift := float64(s.Samples()) - float64(i)*samplesPerFrame; math.Abs(drift) > 0.5+1e-6 {
+					st.Fatalf("Scheduled %d samples after %d frames, drifted %f samples", s.Samples(), i, drift)
+				}
+			}
+		})
+	}
+}
+
+func TestSamplesPerFrame(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		SampleRate float64
+		Frame      LTCFrame
+		Expected   float64
+	}{
+		{"48000@25", 48000, LTCFrame{FramesPerSecond: 25}, 1920},
+		{"48000@30", 48000, LTCFrame{FramesPerSecond: 30}, 1600},
+		{"44100@29.97df", 44100, LTCFrame{FramesPerSecond: 30, DropFrame: true}, 44100 / 29.97},
+		{"48000@23.976", 48000, LTCFrame{FramesPerSecond: 24, PullDown: true}, 2002},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if actual := c.Frame.SamplesPerFrame(c.SampleRate); math.Abs(actual-c.Expected) > 1e-9 {
+				st.Errorf("Expected %f samples per frame, got %f", c.Expected, actual)
+			}
+		})
+	}
+}
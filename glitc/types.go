@@ -1,7 +1,10 @@
 package glitc
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/bits"
 	"time"
 )
@@ -13,6 +16,27 @@ import (
 // by 01. This is used to determine whether an audio tape is running forward or backward.
 const SyncBits = 0x3FFD
 
+// reverseSyncBits is the sync pattern as it appears at the start of a frame that is being played backwards
+const reverseSyncBits = 0xBFFC
+
+var (
+	// ErrSyncWord is returned when a frame doesn't end with the sync pattern
+	ErrSyncWord = errors.New("sync word not found")
+	// ErrReversed is returned when a frame starts with a reversed sync pattern
+	ErrReversed = errors.New("frame is reversed")
+	// ErrParity is returned when a frame contains an odd number of ones
+	ErrParity = errors.New("parity mismatch")
+	// ErrBCD is returned when a timecode digit in a frame is above 9
+	ErrBCD = errors.New("timecode digit isn't BCD")
+	// ErrInvalidTimeCode is returned when a frame's timecode isn't sent at the frame rate, e.g. frame 29
+	// at 25fps or drop frame at 25fps
+	ErrInvalidTimeCode = errors.New("timecode isn't sent at the frame rate")
+	// ErrNoFrameRate is returned when a frame's FramesPerSecond is unset
+	ErrNoFrameRate = errors.New("frame rate is unset, FramesPerSecond must be 24, 25, 30, 50 or 60")
+)
+
+// asBCD splits number into its tens and ones digits, digits above the tens are discarded so 131 is
+// returned as 3, 1.  Use asBCDChecked for values that haven't already been range checked.
 func asBCD(number int) (int, int) {
 	var ones, tens int
 	ones = number % 10
@@ -20,6 +44,16 @@ func asBCD(number int) (int, int) {
 	return tens, ones
 }
 
+// asBCDChecked splits number into its tens and ones digits, returning an error if number is negative
+// or above max, the largest legal value of the field, or doesn't fit in two digits
+func asBCDChecked(number int, max int) (int, int, error) {
+	if number < 0 || number > max || number > 99 {
+		return 0, 0, fmt.Errorf("%d is out of range 0-%d", number, max)
+	}
+	tens, ones := asBCD(number)
+	return tens, ones, nil
+}
+
 type TimeCode struct {
 	Hour      int
 	Minute    int
@@ -36,168 +70,913 @@ func (tc TimeCode) String() string {
 	return fmt.Sprintf(fmtString, tc.Hour, tc.Minute, tc.Second, tc.Frame)
 }
 
+// droppedPerMinute returns the number of frame numbers skipped at the start of every minute that isn't
+// a multiple of 10 in drop frame timecode at the nominal integer frame rate fps.  29.97 fps drops
+// frames 0 and 1, 59.94 fps drops twice as many, frames 0 to 3, which are frame pairs 0 and 1 in LTC.
+func droppedPerMinute(fps int) int {
+	return fps / 15
+}
+
+// dropFrame10MinFrames returns the number of frames in each 10 minute drop frame window at the nominal
+// integer frame rate fps, frames are dropped from 9 of its minutes
+func dropFrame10MinFrames(fps int) int {
+	return 10*60*fps - 9*droppedPerMinute(fps)
+}
+
+// frameCount returns the number of frames since 00:00:00:00 at the nominal integer frame rate fps
+func (tc TimeCode) frameCount(fps int) int {
+	frames := ((tc.Hour*60+tc.Minute)*60+tc.Second)*fps + tc.Frame
+	if tc.DropFrame {
+		minutes := tc.Hour*60 + tc.Minute
+		frames -= droppedPerMinute(fps) * (minutes - minutes/10)
+	}
+	return frames
+}
+
+// framesPerDay returns the number of frames in 24 hours of timecode at the nominal integer frame rate fps
+func framesPerDay(fps int, dropFrame bool) int {
+	if dropFrame {
+		return 24 * 6 * dropFrame10MinFrames(fps)
+	}
+	return 24 * 3600 * fps
+}
+
+// timeCodeFromCount returns the timecode frames after 00:00:00:00 at the nominal integer frame rate fps
+func timeCodeFromCount(frames int, fps int, dropFrame bool) TimeCode {
+	day := framesPerDay(fps, dropFrame)
+	frames = (frames%day + day) % day
+
+	if dropFrame {
+		// add back the frames skipped at the start of each minute that isn't a multiple of 10
+		dropped := droppedPerMinute(fps)
+		framesPerMin := 60*fps - dropped
+		tens, rem := frames/dropFrame10MinFrames(fps), frames%dropFrame10MinFrames(fps)
+		frames += 9*dropped*tens + dropped*((rem-dropped)/framesPerMin)
+	}
+
+	return TimeCode{
+		Hour:      frames / (fps * 3600),
+		Minute:    frames / (fps * 60) % 60,
+		Second:    frames / fps % 60,
+		Frame:     frames % fps,
+		DropFrame: dropFrame,
+	}
+}
+
+// ToFrames returns the number of frames from 00:00:00:00 to tc at fps, matching LTCFrame.FrameIndex
+func (tc TimeCode) ToFrames(fps float64, dropFrame bool) int {
+	tc.DropFrame = dropFrame
+	return tc.frameCount(int(math.Round(fps)))
+}
+
+// TimeCodeFromFrames returns the timecode n frames after 00:00:00:00 at fps, wrapping at 24 hours
+func TimeCodeFromFrames(n int, fps float64, dropFrame bool) TimeCode {
+	return timeCodeFromCount(n, int(math.Round(fps)), dropFrame)
+}
+
+// Add returns the timecode frames later at fps, wrapping at 24 hours and skipping dropped frames
+func (tc TimeCode) Add(frames int, fps float64) TimeCode {
+	return TimeCodeFromFrames(tc.ToFrames(fps, tc.DropFrame)+frames, fps, tc.DropFrame)
+}
+
+// Sub returns the timecode frames earlier at fps, wrapping at 24 hours and skipping dropped frames
+func (tc TimeCode) Sub(frames int, fps float64) TimeCode {
+	return tc.Add(-frames, fps)
+}
+
+// compare returns -1, 0 or 1 as tc is before, the same frame as or after other within the day.  A drop
+// frame and a non drop frame timecode are compared by frame count at 30 fps, so they compare by when
+// a 29.97 fps generator starting at midnight would send them.  Otherwise timecodes are compared field
+// by field, which gives the same order at any frame rate they are both valid at, since dropping
+// frame numbers doesn't change the order of those that are sent.
+func (tc TimeCode) compare(other TimeCode) int {
+	var a, b int
+	if tc.DropFrame != other.DropFrame {
+		a, b = tc.frameCount(30), other.frameCount(30)
+	} else {
+		// frames never reach 100, so this orders by hour, minute, second then frame
+		a, b = tc.frameCount(100), other.frameCount(100)
+	}
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Before returns true if tc comes before other in the day, see compare for how drop frame and non
+// drop frame timecodes are compared
+func (tc TimeCode) Before(other TimeCode) bool {
+	return tc.compare(other) < 0
+}
+
+// After returns true if tc comes after other in the day
+func (tc TimeCode) After(other TimeCode) bool {
+	return tc.compare(other) > 0
+}
+
+// Equal returns true if tc and other are the same frame of the day
+func (tc TimeCode) Equal(other TimeCode) bool {
+	return tc.compare(other) == 0
+}
+
+// IsValid returns true if tc is a timecode that is sent at fps.  Frames must be below the nominal integer
+// rate, and in drop frame frames 0 and 1 are skipped at the start of every minute that isn't a multiple
+// of 10, or frames 0 to 3 at 59.94 fps.  Drop frame is only defined at 30 and 60 fps.
+func (tc TimeCode) IsValid(fps float64, dropFrame bool) bool {
+	nominal := int(math.Round(fps))
+	if nominal <= 0 {
+		return false
+	}
+	if tc.Hour < 0 || tc.Hour > 23 || tc.Minute < 0 || tc.Minute > 59 || tc.Second < 0 || tc.Second > 59 {
+		return false
+	}
+	if tc.Frame < 0 || tc.Frame >= nominal {
+		return false
+	}
+	if dropFrame {
+		if nominal != 30 && nominal != 60 {
+			return false
+		}
+		if tc.Second == 0 && tc.Minute%10 != 0 && tc.Frame < droppedPerMinute(nominal) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTimeCode parses a timecode in the form returned by TimeCode.String, hh:mm:ss:ff for non drop frame
+// and hh:mm:ss;ff for drop frame.  Frames up to 59 are accepted, use IsValid to check the timecode is
+// sent at a particular rate.
+func ParseTimeCode(s string) (TimeCode, error) {
+	var tc TimeCode
+	if len(s) != 11 || s[2] != ':' || s[5] != ':' || (s[8] != ':' && s[8] != ';') {
+		return TimeCode{}, fmt.Errorf("invalid timecode %q", s)
+	}
+	tc.DropFrame = s[8] == ';'
+
+	fields := []struct {
+		name  string
+		value *int
+		max   int
+	}{
+		{"hour", &tc.Hour, 23},
+		{"minute", &tc.Minute, 59},
+		{"second", &tc.Second, 59},
+		{"frame", &tc.Frame, 59},
+	}
+	for i, field := range fields {
+		tens, ones := s[3*i], s[3*i+1]
+		if tens < '0' || tens > '9' || ones < '0' || ones > '9' {
+			return TimeCode{}, fmt.Errorf("invalid timecode %q: %s is not a number", s, field.name)
+		}
+		*field.value = int(tens-'0')*10 + int(ones-'0')
+		if *field.value > field.max {
+			return TimeCode{}, fmt.Errorf("invalid timecode %q: %s %d out of range", s, field.name, *field.value)
+		}
+	}
+
+	return tc, nil
+}
+
+// MarshalJSON encodes tc as its string form, e.g. "23:14:21:05" or "01:02:03;29" for drop frame
+func (tc TimeCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tc.String())
+}
+
+// UnmarshalJSON decodes a timecode string as parsed by ParseTimeCode, the separator before the
+// frames sets DropFrame
+func (tc *TimeCode) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("timecode must be a string: %v", err)
+	}
+	parsed, err := ParseTimeCode(s)
+	if err != nil {
+		return err
+	}
+	*tc = parsed
+	return nil
+}
+
+// BinaryGroupFlags describe the format of the user bits.  Binary group flag 1 is the clock flag, which
+// is set using LTCFrame.ExternalClockSync.
+type BinaryGroupFlags uint8
+
+const (
+	// BGF0 is binary group flag 0, set alone it indicates the user bits hold 8 bit characters
+	BGF0 BinaryGroupFlags = 1 << 0
+	// BGF2 is binary group flag 2, set alone it indicates the user bits hold a date and time zone
+	BGF2 BinaryGroupFlags = 1 << 2
+)
+
+// SpecVersion selects the revision of SMPTE 12M whose flag bit assignments are used.  Every revision
+// moves the flag bits at 25fps, where bit 27 is binary group flag 0, bit 43 binary group flag 2 and
+// bit 59 the phase correction, instead of bits 43, 59 and 27 at 24 and 30fps.  The revisions differ
+// at 50fps.
+type SpecVersion int
+
+const (
+	// SMPTE12M1999 uses the assignments of the 1986 and 1999 revisions, which only define 24, 25 and
+	// 30fps.  50 and 60fps frame pairs both use the 30fps assignments.
+	SMPTE12M1999 SpecVersion = iota
+	// SMPTE12M2008 uses the assignments of the 2008 revision, which groups rates into 25 and 30
+	// frame systems.  50fps frame pairs use the 25fps assignments, so a reader locked to them as
+	// 25fps finds the flags and phase correction where it expects them.
+	SMPTE12M2008
+)
+
+var specVersionNames = map[SpecVersion]string{
+	SMPTE12M1999: "1999",
+	SMPTE12M2008: "2008",
+}
+
+func (v SpecVersion) String() string {
+	if name, ok := specVersionNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("SpecVersion(%d)", int(v))
+}
+
+// ParseSpecVersion parses the year of a SMPTE 12M revision, 1986 and 1999 share their assignments
+func ParseSpecVersion(s string) (SpecVersion, error) {
+	switch s {
+	case "1986", "1999":
+		return SMPTE12M1999, nil
+	case "2008":
+		return SMPTE12M2008, nil
+	}
+	return 0, fmt.Errorf("unknown SMPTE 12M version %q, expected 1986, 1999 or 2008", s)
+}
+
+// twentyFiveFrameFlags reports whether a frame at fps uses the 25fps flag bit assignments in this
+// revision
+func (v SpecVersion) twentyFiveFrameFlags(fps float64) bool {
+	return fps == 25 || v == SMPTE12M2008 && fps == 50
+}
+
+// LTCFrame is a single frame of timecode along with its flag bits.  The bit carrying the biphase mark
+// phase correction (bit 27, or bit 59 at 25fps) carries the field mark in VITC.  Setting SendFieldMark
+// sends FieldMark in that bit instead, for equipment that cross checks LTC against VITC, at the cost
+// of the phase correction.  User bits come from UserBits when it is set, otherwise from UserBytes.
+// SpecVersion selects where the flag bits go, by default the 1999 assignments.  A frame whose Time is
+// unset is at midnight, timecode 00:00:00:00, whatever the location of the zero time.
 type LTCFrame struct {
 	Time              time.Time
 	FramesPerSecond   float64
 	DropFrame         bool
+	PullDown          bool
 	ColorFrame        bool
 	ExternalClockSync bool
+	BinaryGroupFlags  BinaryGroupFlags
 	UserBytes         *[4]byte
+	UserBits          UserBitsProvider
+	SendFieldMark     bool
+	FieldMark         bool
+	SpecVersion       SpecVersion
+
+	// dayStart is when 00:00:00:00 began for frames set by SetTimeCode.  A pulled down timecode day
+	// lasts 1001/1000 of 24 hours, so these count on from here through 23:59:59 rather than starting
+	// again at the next midnight of the clock.
+	dayStart time.Time
 }
 
-// dropFrame10MinIndex returns the number of frames since the beginning of this 10 minute drop frame window
+// dropFrame10MinIndex returns the number of frames since the beginning of this 10 minute drop frame window.
+// Windows are keyed to the time of day of Time, which SetTimeCode and clock offsets keep in step with the
+// timecode, so timecode starting part way through a window counts on correctly from there.
 func (f LTCFrame) dropFrame10MinIndex() int {
-	m := f.Time.Minute()
-	s := f.Time.Second()
-	n := f.Time.Nanosecond()
+	t := f.clock()
+	m := t.Minute()
+	s := t.Second()
+	n := t.Nanosecond()
 
 	nanoseconds := int64((m%10*60+s))*1e9 + int64(n)
 	framePeriod := f.FrameDuration().Nanoseconds()
 	frameIndex := int(nanoseconds / int64(framePeriod))
+	// the frame period is rounded down, leaving a few hundred nanoseconds at the end of the window
+	// that belong to its last frame rather than the first frame of the next window
+	if windowFrames := dropFrame10MinFrames(f.nominalFPS()); frameIndex >= windowFrames {
+		frameIndex = windowFrames - 1
+	}
 	return frameIndex
 }
 
-// Frame returns current frame number
+// pulledDown returns true for non drop frame rates slowed by 1000/1001, drop frame has its own handling
+func (f LTCFrame) pulledDown() bool {
+	return f.PullDown && !f.DropFrame
+}
+
+// pullDownFrames returns the start of the timecode day of a pulled down frame and the number of
+// frames from there to this one.  Without a day set by SetTimeCode the day starts at midnight, and
+// the last frame before the next midnight is cut short.
+func (f LTCFrame) pullDownFrames() (time.Time, int) {
+	start := f.dayStart
+	if start.IsZero() {
+		start = f.midnight()
+	}
+	elapsed, frameDuration := f.clock().Sub(start), f.FrameDuration()
+	frames := int(elapsed / frameDuration)
+	if elapsed%frameDuration < 0 {
+		frames--
+	}
+	return start, frames
+}
+
+// pullDownTimeCode returns the timecode of a pulled down frame, these run slower than wall clock
+// so the timecode is counted from the frame index rather than the time of day
+func (f LTCFrame) pullDownTimeCode() TimeCode {
+	fps := int(f.FramesPerSecond)
+	frameIndex := f.FrameIndex()
+	return TimeCode{
+		Hour:   frameIndex / (fps * 3600),
+		Minute: frameIndex / (fps * 60) % 60,
+		Second: frameIndex / fps % 60,
+		Frame:  frameIndex % fps,
+	}
+}
+
+// Frame returns current frame number, always 00:00:00:00 if the frame rate is unset
 func (f LTCFrame) Frame() TimeCode {
+	if !f.rateSet() {
+		return TimeCode{DropFrame: f.DropFrame}
+	}
+	if f.pulledDown() {
+		return f.pullDownTimeCode()
+	}
+
+	t := f.clock()
 	if !f.DropFrame {
 		return TimeCode{
-			Hour:      f.Time.Hour(),
-			Minute:    f.Time.Minute(),
-			Second:    f.Time.Second(),
-			Frame:     int(float64(f.Time.Nanosecond()) / 1e9 * f.EffectiveFPS()),
+			Hour:      t.Hour(),
+			Minute:    t.Minute(),
+			Second:    t.Second(),
+			Frame:     f.secondFrame(),
 			DropFrame: false,
 		}
 	}
 
+	// the first minute of the window is complete, the others are missing their first dropped frames
+	fps := f.nominalFPS()
+	dropped := droppedPerMinute(fps)
 	frameIndex := f.dropFrame10MinIndex()
-
-	var minute, second, frame int
-	if frameIndex < 30*60 {
-		minute = 0
-	} else {
-		minute = 1 + (frameIndex-30*60)/(30*59+28)
+	minute, inMinute := 0, frameIndex
+	if frameIndex >= 60*fps {
+		minute = 1 + (frameIndex-60*fps)/(60*fps-dropped)
+		inMinute = (frameIndex-60*fps)%(60*fps-dropped) + dropped
 	}
-	second = (frameIndex + 2*minute - 30*60*minute) / 30
-
-	if minute == 0 {
-		frame = frameIndex - second*30
-	} else if minute != 0 && second == 0 {
-		frame = 2 + (frameIndex - 1800 - (minute-1)*1798 - second*30)
-	} else {
-		frame = frameIndex + 2*minute - minute*30*60 - second*30
-	}
-	mTen := f.Time.Minute() / 10
+	mTen := t.Minute() / 10
 	return TimeCode{
-		Hour:      f.Time.Hour(),
+		Hour:      t.Hour(),
 		Minute:    mTen*10 + minute,
-		Second:    second,
-		Frame:     frame,
+		Second:    inMinute / fps,
+		Frame:     inMinute % fps,
 		DropFrame: true,
 	}
 }
 
-// FrameDuration total frame duration
+// secondFrame returns the number of the non drop frame within the current second.  Frame rates are
+// whole numbers here, so integer arithmetic keeps float rounding from producing frame fps at the very
+// end of a second.
+func (f LTCFrame) secondFrame() int {
+	t := f.clock()
+	fps := int64(f.FramesPerSecond)
+	frame := int(int64(t.Nanosecond()) * fps / int64(time.Second))
+	if frame >= int(fps) {
+		frame = int(fps) - 1
+	}
+	return frame
+}
+
+// FrameDuration total frame duration, 0 if the frame rate is unset
 func (f LTCFrame) FrameDuration() time.Duration {
+	if !f.rateSet() {
+		return 0
+	}
+	if f.pulledDown() {
+		// milliframe precision isn't enough to represent 1000/1001 rates without drifting
+		return time.Duration(float64(time.Second) / f.EffectiveFPS())
+	}
 	return time.Second * 1000 / time.Duration(f.EffectiveFPS()*1000)
 }
 
-// BitPeriod the clock period used for encoding
+// BitPeriod the clock period used for encoding.  Every frame carries 80 bits regardless of the
+// frame rate, so 50 and 60fps frames are sent at twice the bit rate of 25 and 30fps frames.
 func (f LTCFrame) BitPeriod() time.Duration {
 	return f.FrameDuration() / 80
 }
 
-// EffectiveFPS returns effective frames per second
+// EffectiveFPS returns effective frames per second.  Drop frame sends the frames of a 10 minute
+// window in exactly 10 minutes, 29.97 or 59.94 fps.
 func (f LTCFrame) EffectiveFPS() float64 {
 	if !f.DropFrame {
+		if f.PullDown {
+			return f.FramesPerSecond * 1000 / 1001
+		}
 		return float64(f.FramesPerSecond)
 	}
-	return float64(30) * float64(18000.0-18.0) / float64(18000.0)
+	return float64(dropFrame10MinFrames(f.nominalFPS())) / 600
 }
 
-// FrameIndex returns the number of whole frames from timecode 00:00:00:00
+// nominalFPS returns the integer frame rate frames are numbered at
+func (f LTCFrame) nominalFPS() int {
+	return int(math.Round(f.FramesPerSecond))
+}
+
+// rateSet returns false if FramesPerSecond is unset, or too small to number frames by.  Such frames
+// have no duration and are always at 00:00:00:00 rather than dividing by zero, Validate reports them.
+func (f LTCFrame) rateSet() bool {
+	return f.nominalFPS() > 0
+}
+
+// SamplesPerFrame returns the average number of samples in each frame at sampleRate.  This is rarely
+// a whole number, e.g. 44100Hz at 29.97fps is 1471.47 samples per frame, see SampleScheduler.
+func (f LTCFrame) SamplesPerFrame(sampleRate float64) float64 {
+	return sampleRate / f.EffectiveFPS()
+}
+
+// FrameIndex returns the number of whole frames from timecode 00:00:00:00, 0 if the frame rate is unset
 func (f LTCFrame) FrameIndex() int {
+	if !f.rateSet() {
+		return 0
+	}
+	t := f.clock()
+	if f.pulledDown() {
+		_, frames := f.pullDownFrames()
+		day := f.FramesPerDay()
+		return (frames%day + day) % day
+	}
+
 	if !f.DropFrame {
-		return int(float64(f.Time.Hour()*3600+f.Time.Minute()*60+f.Time.Second())*f.EffectiveFPS() + float64(f.Frame().Frame))
+		return int(float64(t.Hour()*3600+t.Minute()*60+t.Second())*f.EffectiveFPS() + float64(f.Frame().Frame))
 	}
 
-	return int(float64(f.Time.Hour())*3600*f.EffectiveFPS() +
-		float64(f.Time.Minute()/10)*60*10*f.EffectiveFPS() +
-		float64(f.dropFrame10MinIndex()))
+	return (t.Hour()*6+t.Minute()/10)*dropFrame10MinFrames(f.nominalFPS()) + f.dropFrame10MinIndex()
+}
+
+// FramesPerDay returns the number of frames from midnight to midnight, FrameIndex counts up to one
+// less than this before starting again from 0.  It is 0 if the frame rate is unset.
+func (f LTCFrame) FramesPerDay() int {
+	if !f.rateSet() {
+		return 0
+	}
+	if f.pulledDown() && !f.dayStart.IsZero() {
+		return framesPerDay(f.nominalFPS(), false)
+	}
+	if f.pulledDown() {
+		// pulled down frames don't fit exactly into a day, the last one is cut short at midnight
+		frameDuration := f.FrameDuration()
+		return int((24*time.Hour + frameDuration - 1) / frameDuration)
+	}
+	return framesPerDay(int(math.Round(f.FramesPerSecond)), f.DropFrame)
+}
+
+// clock returns Time, or the zero time in UTC when Time is unset.  The zero time may carry any
+// location, and in one west of UTC it falls late on the day before, so an unset frame would otherwise
+// send that time of day rather than 00:00:00:00.
+func (f LTCFrame) clock() time.Time {
+	if f.Time.IsZero() {
+		return time.Time{}
+	}
+	return f.Time
+}
+
+// midnight returns the start of the day containing this frame
+func (f LTCFrame) midnight() time.Time {
+	t := f.clock()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 }
 
 // FrameBeginTime returns the time this frame starts
 func (f LTCFrame) FrameBeginTime() time.Time {
-	midnightLocal := time.Date(f.Time.Year(), f.Time.Month(), f.Time.Day(), 0, 0, 0, 0, f.Time.Location())
-	return midnightLocal.Add(time.Duration(f.FrameIndex()) * f.FrameDuration())
+	if f.pulledDown() && f.rateSet() {
+		start, frames := f.pullDownFrames()
+		return start.Add(time.Duration(frames) * f.FrameDuration())
+	}
+	return f.midnight().Add(time.Duration(f.FrameIndex()) * f.FrameDuration())
 }
 
-// EncodeFrame returns a byte array representing this LTCFrame
+// timeCodeOffset returns the offset from midnight to the middle of the frame identified by tc
+func (f LTCFrame) timeCodeOffset(tc TimeCode) time.Duration {
+	frameDuration := f.FrameDuration()
+	if f.pulledDown() {
+		fps := int(f.FramesPerSecond)
+		frameIndex := ((tc.Hour*60+tc.Minute)*60+tc.Second)*fps + tc.Frame
+		return time.Duration(frameIndex)*frameDuration + frameDuration/2
+	}
+
+	if !f.DropFrame {
+		return time.Duration(tc.Hour)*time.Hour +
+			time.Duration(tc.Minute)*time.Minute +
+			time.Duration(tc.Second)*time.Second +
+			time.Duration(tc.Frame)*frameDuration + frameDuration/2
+	}
+
+	// frames are skipped at the start of every minute except the first of each 10 minute window
+	fps := f.nominalFPS()
+	dropped := droppedPerMinute(fps)
+	frameIndex := tc.Second*fps + tc.Frame
+	if m := tc.Minute % 10; m != 0 {
+		frameIndex += 60*fps + (m-1)*(60*fps-dropped) - dropped
+	}
+	return time.Duration(tc.Hour)*time.Hour +
+		time.Duration(tc.Minute/10)*10*time.Minute +
+		time.Duration(frameIndex)*frameDuration + frameDuration/2
+}
+
+// Validate returns an error if the frame rate or flags can't be encoded.  EncodeFrame doesn't check,
+// so frames built from configuration or other outside input should be validated first.
+func (f LTCFrame) Validate() error {
+	if f.FramesPerSecond == 0 {
+		return ErrNoFrameRate
+	}
+	switch f.FramesPerSecond {
+	case 24, 25, 30, 50, 60:
+	default:
+		return fmt.Errorf("unsupported frame rate %g, expected 24, 25, 30, 50 or 60 with PullDown for 1000/1001 rates", f.FramesPerSecond)
+	}
+	if f.DropFrame {
+		switch f.FramesPerSecond {
+		case 30, 60:
+		default:
+			return fmt.Errorf("drop frame is only defined for 29.97 and 59.94 fps, not %g", f.FramesPerSecond)
+		}
+		if f.PullDown {
+			return errors.New("drop frame is already pulled down, PullDown must not be set as well")
+		}
+	}
+
+	if f.BinaryGroupFlags&^(BGF0|BGF2) != 0 {
+		return fmt.Errorf("invalid binary group flags %#x, only BGF0 and BGF2 can be set", uint8(f.BinaryGroupFlags))
+	}
+	if f.BinaryGroupFlags != 0 && f.UserBytes == nil && f.UserBits == nil {
+		return fmt.Errorf("binary group flags %#x describe user bits, but none are set", uint8(f.BinaryGroupFlags))
+	}
+	if f.BinaryGroupFlags == BGF2 && f.UserBits == nil {
+		if _, err := f.GetDateUserBits(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FrameBytes is the length of an encoded frame, 80 bits
+const FrameBytes = 10
+
+// EncodeFrame returns a byte array representing this LTCFrame, see Validate
 func (f LTCFrame) EncodeFrame() []byte {
-	var externalClock, b10, b11, b27, b43, b59 int
+	binaryFrame := make([]byte, FrameBytes)
+	f.EncodeFrameInto(binaryFrame)
+	return binaryFrame
+}
+
+// EncodeFrameInto writes the encoded frame to the first FrameBytes of buf, as EncodeFrame does
+// without allocating.  It returns an error if buf is too short.  A frame whose rate is unset is still
+// written, always as 00:00:00:00 so EncodeFrame is deterministic, but ErrNoFrameRate is returned.
+func (f LTCFrame) EncodeFrameInto(buf []byte) error {
+	if len(buf) < FrameBytes {
+		return fmt.Errorf("buffer of %d bytes is too short for a %d byte frame", len(buf), FrameBytes)
+	}
+	var err error
+	if !f.rateSet() {
+		err = ErrNoFrameRate
+	}
+	binaryFrame := buf[:FrameBytes]
+	f.encodeFields(binaryFrame)
+	i, mask := bitPosition(f.flagBits().parity)
+	if f.SendFieldMark {
+		if f.FieldMark {
+			binaryFrame[i] |= mask
+		}
+		return err
+	}
+	// the parity bit is still clear, so it's needed if the other bits hold an odd number of ones
+	if !EvenParity(binaryFrame) {
+		binaryFrame[i] |= mask
+	}
+	return err
+}
+
+// EncodeTimeCode returns the encoded frame for tc on the day of f.Time, checking that each field of
+// tc is in range for the frame rate.  Unlike EncodeFrame this is safe to use with timecodes that come
+// from outside the program.
+func (f LTCFrame) EncodeTimeCode(tc TimeCode) ([]byte, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	fields := []struct {
+		name  string
+		value int
+		max   int
+	}{
+		{"hour", tc.Hour, 23},
+		{"minute", tc.Minute, 59},
+		{"second", tc.Second, 59},
+		{"frame", tc.Frame, int(math.Round(f.FramesPerSecond)) - 1},
+	}
+	for _, field := range fields {
+		if _, _, err := asBCDChecked(field.value, field.max); err != nil {
+			return nil, fmt.Errorf("invalid %s in timecode %s: %v", field.name, tc, err)
+		}
+	}
+	if tc.DropFrame != f.DropFrame {
+		return nil, fmt.Errorf("timecode %s doesn't match dropframe setting %v", tc, f.DropFrame)
+	}
+	if tc.DropFrame && tc.Minute%10 != 0 && tc.Second == 0 && tc.Frame < droppedPerMinute(f.nominalFPS()) {
+		return nil, fmt.Errorf("timecode %s is a dropped frame", tc)
+	}
+
+	f.SetTimeCode(tc, f.Time)
+	return f.EncodeFrame(), nil
+}
+
+// ParityBit returns the value of the biphase mark phase correction bit, chosen so that the encoded
+// frame, including the sync word, contains an even number of ones.  This is bit 59 at 25fps, and at
+// 50fps with SMPTE 12M-2008 flags, and bit 27 at all other rates.
+func (f LTCFrame) ParityBit() bool {
+	var binaryFrame [FrameBytes]byte
+	f.encodeFields(binaryFrame[:])
+	return !EvenParity(binaryFrame[:])
+}
+
+// flagBitNumbers are the bits, numbered in the order they are sent, that carry binary group flags 0
+// and 2 and the phase correction bit
+type flagBitNumbers struct {
+	bgf0, bgf2, parity int
+}
+
+// flagBits returns where binary group flags 0 and 2 and the phase correction bit go at this frame
+// rate.  With the 25fps assignments the phase correction bit moves from bit 27 to bit 59, and the
+// flags move down from bits 43 and 59 to bits 27 and 43 to make room, so user bytes marked by the
+// flags never share a bit with the parity.
+func (f LTCFrame) flagBits() flagBitNumbers {
+	if f.SpecVersion.twentyFiveFrameFlags(f.FramesPerSecond) {
+		return flagBitNumbers{bgf0: 27, bgf2: 43, parity: 59}
+	}
+	return flagBitNumbers{bgf0: 43, bgf2: 59, parity: 27}
+}
+
+// bitPosition returns the byte index and mask of bit n of an encoded frame, counting in the order
+// bits are sent
+func bitPosition(n int) (int, byte) {
+	return n / 8, 0x80 >> uint(n%8)
+}
+
+// EvenParity reports whether binaryFrame contains an even number of ones, which is true of every
+// correctly encoded LTC frame.
+func EvenParity(binaryFrame []byte) bool {
+	var ones int
+	for _, b := range binaryFrame {
+		ones += bits.OnesCount8(uint8(b))
+	}
+	return ones%2 == 0
+}
+
+// ReverseFrame returns binaryFrame with its bits in the opposite order, as seen by a reader when the
+// frame is played backwards.  The sync word then arrives first and reversed, which is how readers
+// detect the direction of play.  Reversing a reversed frame restores the original.
+func ReverseFrame(binaryFrame []byte) []byte {
+	reversed := make([]byte, len(binaryFrame))
+	for i, b := range binaryFrame {
+		reversed[len(binaryFrame)-1-i] = bits.Reverse8(b)
+	}
+	return reversed
+}
+
+// encodeFields writes the encoded frame to binaryFrame, which must be FrameBytes long, with the
+// parity bit cleared
+func (f LTCFrame) encodeFields(binaryFrame []byte) {
+	var externalClock, b10, b11 int
 
 	tc := f.Frame()
 
 	hTens, hOnes := asBCD(tc.Hour)
 	mTens, mOnes := asBCD(tc.Minute)
 	sTens, sOnes := asBCD(tc.Second)
-	fTens, fOnes := asBCD(tc.Frame)
+	// frame numbers above 39 don't fit in the frame tens field, so rates above 30fps send the frame pair number
+	frameNumber := tc.Frame
+	if f.FramesPerSecond > 30 {
+		frameNumber /= 2
+	}
+	fTens, fOnes := asBCD(frameNumber)
 
 	if f.DropFrame {
 		b10 = 1
 	}
 
-	// set color bit to 1
-	b11 = 1
+	if f.ColorFrame {
+		b11 = 1
+	}
 
 	if f.ExternalClockSync {
 		externalClock = 1
 	}
 
-	binaryFrame := make([]byte, 10)
+	for i := range binaryFrame {
+		binaryFrame[i] = 0
+	}
 
-	if f.UserBytes != nil {
-		if f.FramesPerSecond == 25 {
-			b27 = 1
-		} else {
-			b43 = 1
-		}
-		binaryFrame[7] |= f.UserBytes[3] >> 4 & 0xF
-		binaryFrame[6] |= f.UserBytes[3] & 0xF
-		binaryFrame[5] |= f.UserBytes[2] >> 4 & 0xF
-		binaryFrame[4] |= f.UserBytes[2] & 0xF
-		binaryFrame[3] |= f.UserBytes[1] >> 4 & 0xF
-		binaryFrame[2] |= f.UserBytes[1] & 0xF
-		binaryFrame[1] |= f.UserBytes[0] >> 4 & 0xF
-		binaryFrame[0] |= f.UserBytes[0] & 0xF
+	userBytes := f.UserBytes
+	if f.UserBits != nil {
+		b := f.UserBits.UserBits(tc)
+		userBytes = &b
+	}
+	if userBytes != nil {
+		binaryFrame[7] |= userBytes[3] >> 4 & 0xF
+		binaryFrame[6] |= userBytes[3] & 0xF
+		binaryFrame[5] |= userBytes[2] >> 4 & 0xF
+		binaryFrame[4] |= userBytes[2] & 0xF
+		binaryFrame[3] |= userBytes[1] >> 4 & 0xF
+		binaryFrame[2] |= userBytes[1] & 0xF
+		binaryFrame[1] |= userBytes[0] >> 4 & 0xF
+		binaryFrame[0] |= userBytes[0] & 0xF
 	}
 
 	binaryFrame[9] |= SyncBits & 0xFF
 	binaryFrame[8] |= SyncBits >> 8 & 0xFF
 
-	binaryFrame[7] |= byte(bits.Reverse8(uint8(hTens&0x3)) | uint8(externalClock&0x1)<<5 | uint8(b59&0x1)<<4)
+	binaryFrame[7] |= byte(bits.Reverse8(uint8(hTens&0x3)) | uint8(externalClock&0x1)<<5)
 	binaryFrame[6] |= byte(bits.Reverse8(uint8(hOnes & 0xF)))
 
-	binaryFrame[5] |= byte(bits.Reverse8(uint8(mTens&0x7)) | uint8(b43&0x1)<<4)
+	binaryFrame[5] |= byte(bits.Reverse8(uint8(mTens & 0x7)))
 	binaryFrame[4] |= byte(bits.Reverse8(uint8(mOnes & 0xF)))
 
-	binaryFrame[3] |= byte(bits.Reverse8(uint8(sTens&0x7)) | uint8(b27&0x1)<<4)
+	binaryFrame[3] |= byte(bits.Reverse8(uint8(sTens & 0x7)))
 	binaryFrame[2] |= byte(bits.Reverse8(uint8(sOnes & 0xF)))
 
 	binaryFrame[1] |= byte(bits.Reverse8(uint8(fTens&0x3)) | uint8(b10&0x1)<<5 | uint8(b11&0x1)<<4)
 	binaryFrame[0] |= byte(bits.Reverse8(uint8(fOnes & 0xF)))
 
-	var ones int
-	for _, b := range binaryFrame {
-		ones += bits.OnesCount8(uint8(b))
+	// binary group flags 0 and 2 move to make room for the parity bit at 25fps
+	flags := f.flagBits()
+	if f.BinaryGroupFlags&BGF0 != 0 {
+		i, mask := bitPosition(flags.bgf0)
+		binaryFrame[i] |= mask
+	}
+	if f.BinaryGroupFlags&BGF2 != 0 {
+		i, mask := bitPosition(flags.bgf2)
+		binaryFrame[i] |= mask
+	}
+}
+
+// SetTimeCode jam syncs the frame to tc by setting Time to the middle of that frame on the day of at.
+// Advancing Time by FrameDuration from here free runs the timecode from tc.  At pulled down rates the
+// timecode day outlasts the day of at, so late timecodes fall early the next day, and the frame keeps
+// counting from the midnight of at until SetClockTime is called.
+func (f *LTCFrame) SetTimeCode(tc TimeCode, at time.Time) {
+	f.Time = at
+	f.dayStart = f.midnight()
+	f.Time = f.dayStart.Add(f.timeCodeOffset(tc))
+}
+
+// SetClockTime sets Time to t and takes the timecode from the time of day of t again, dropping the
+// timecode day started by SetTimeCode
+func (f *LTCFrame) SetClockTime(t time.Time) {
+	f.Time = t
+	f.dayStart = time.Time{}
+}
+
+// SetUserBytes sends b in the user bits and clears the binary group flags, marking them as user
+// defined so readers don't take them for characters or a date
+func (f *LTCFrame) SetUserBytes(b [4]byte) {
+	f.UserBytes = &b
+	f.UserBits = nil
+	f.BinaryGroupFlags = 0
+}
+
+// SetDateUserBits stores the date and time zone of t in the user bits and sets BGF2 to mark them as a date.
+// User bit groups 1 and 2 hold the BCD day, groups 3 and 4 the BCD month and groups 5 and 6 the BCD year
+// within the century.  Groups 7 and 8 hold the offset from UTC in 15 minute increments as a two's
+// complement byte.  Each UserBytes entry holds two groups with the lower numbered group in the low nibble.
+func (f *LTCFrame) SetDateUserBits(t time.Time) {
+	b := dateUserBytes(t)
+	f.UserBytes = &b
+	f.BinaryGroupFlags = BGF2
+}
+
+// dateUserBytes returns the user bits holding the date and time zone of t, see SetDateUserBits
+func dateUserBytes(t time.Time) [4]byte {
+	_, offset := t.Zone()
+	dayTens, dayOnes := asBCD(t.Day())
+	monthTens, monthOnes := asBCD(int(t.Month()))
+	yearTens, yearOnes := asBCD(t.Year())
+	return [4]byte{
+		byte(dayTens<<4 | dayOnes),
+		byte(monthTens<<4 | monthOnes),
+		byte(yearTens<<4 | yearOnes),
+		byte(int8(offset / (15 * 60))),
+	}
+}
+
+// GetDateUserBits returns midnight of the date stored in the user bits by SetDateUserBits.  Years are
+// assumed to fall between 2000 and 2099.
+func (f LTCFrame) GetDateUserBits() (time.Time, error) {
+	if f.UserBytes == nil || f.BinaryGroupFlags != BGF2 {
+		return time.Time{}, errors.New("user bits don't contain a date")
 	}
 
-	if ones%2 != 0 {
-		if f.FramesPerSecond == 25 {
-			binaryFrame[7] |= 0x10
-		} else {
-			binaryFrame[3] |= 0x10
+	var values [3]int
+	for i := range values {
+		tens, ones := int(f.UserBytes[i]>>4), int(f.UserBytes[i]&0xF)
+		if tens > 9 || ones > 9 {
+			return time.Time{}, fmt.Errorf("invalid BCD value in user bits: %#x", f.UserBytes[i])
 		}
+		values[i] = tens*10 + ones
 	}
 
-	return binaryFrame
+	day, month, year := values[0], time.Month(values[1]), 2000+values[2]
+	offset := int(int8(f.UserBytes[3])) * 15 * 60
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.FixedZone("", offset))
+	if date.Day() != day || date.Month() != month {
+		return time.Time{}, fmt.Errorf("invalid date in user bits: %d-%02d-%02d", year, month, day)
+	}
+	return date, nil
+}
+
+// DecodeFrame parses a byte array produced by EncodeFrame.  LTC doesn't carry the frame rate, so the
+// receiver supplies FramesPerSecond, SendFieldMark and SpecVersion along with the date used for the
+// decoded frame's Time.  Above 30fps only the frame pair is sent, so decoded frames are always the
+// first of the pair.  Frames whose timecode isn't valid at FramesPerSecond, including drop frame at a
+// rate without it, return ErrBCD or ErrInvalidTimeCode.
+func (f LTCFrame) DecodeFrame(binaryFrame []byte) (LTCFrame, error) {
+	if len(binaryFrame) != 10 {
+		return LTCFrame{}, fmt.Errorf("frame must be 10 bytes, got %d", len(binaryFrame))
+	}
+	if !f.rateSet() {
+		return LTCFrame{}, ErrNoFrameRate
+	}
+
+	if int(binaryFrame[8])<<8|int(binaryFrame[9]) != SyncBits {
+		if int(binaryFrame[0])<<8|int(binaryFrame[1]) == reverseSyncBits {
+			return LTCFrame{}, ErrReversed
+		}
+		return LTCFrame{}, ErrSyncWord
+	}
+
+	// frames carrying the field mark have no phase correction
+	if !f.SendFieldMark && !EvenParity(binaryFrame) {
+		return LTCFrame{}, ErrParity
+	}
+
+	tc := TimeCode{
+		Hour:      10*int(bits.Reverse8(binaryFrame[7]&0xC0)) + int(bits.Reverse8(binaryFrame[6]&0xF0)),
+		Minute:    10*int(bits.Reverse8(binaryFrame[5]&0xE0)) + int(bits.Reverse8(binaryFrame[4]&0xF0)),
+		Second:    10*int(bits.Reverse8(binaryFrame[3]&0xE0)) + int(bits.Reverse8(binaryFrame[2]&0xF0)),
+		Frame:     10*int(bits.Reverse8(binaryFrame[1]&0xC0)) + int(bits.Reverse8(binaryFrame[0]&0xF0)),
+		DropFrame: binaryFrame[1]&0x20 != 0,
+	}
+	// the units digits are 4 bits wide, the tens are too narrow to go above 9
+	for _, i := range []int{0, 2, 4, 6} {
+		if bits.Reverse8(binaryFrame[i]&0xF0) > 9 {
+			return LTCFrame{}, ErrBCD
+		}
+	}
+	if f.FramesPerSecond > 30 {
+		tc.Frame *= 2
+	}
+	if !tc.IsValid(f.FramesPerSecond, tc.DropFrame) {
+		return LTCFrame{}, ErrInvalidTimeCode
+	}
+
+	frame := LTCFrame{
+		FramesPerSecond:   f.FramesPerSecond,
+		DropFrame:         tc.DropFrame,
+		PullDown:          f.PullDown,
+		ColorFrame:        binaryFrame[1]&0x10 != 0,
+		ExternalClockSync: binaryFrame[7]&0x20 != 0,
+		SendFieldMark:     f.SendFieldMark,
+		SpecVersion:       f.SpecVersion,
+	}
+	flags := f.flagBits()
+	if f.SendFieldMark {
+		frame.FieldMark = frameBit(binaryFrame, flags.parity) != 0
+	}
+	if frameBit(binaryFrame, flags.bgf0) != 0 {
+		frame.BinaryGroupFlags |= BGF0
+	}
+	if frameBit(binaryFrame, flags.bgf2) != 0 {
+		frame.BinaryGroupFlags |= BGF2
+	}
+
+	var userBytes [4]byte
+	var userBits bool
+	for i := range userBytes {
+		userBytes[i] = binaryFrame[2*i+1]&0xF<<4 | binaryFrame[2*i]&0xF
+		userBits = userBits || userBytes[i] != 0
+	}
+	if frame.BinaryGroupFlags != 0 || userBits {
+		frame.UserBytes = &userBytes
+	}
+
+	frame.SetTimeCode(tc, f.Time)
+	return frame, nil
+}
+
+// DecodeFrame parses a byte array produced by EncodeFrame when the frame rate isn't known.  Only drop
+// frame can be told from the frame itself, so frames are decoded as 30fps, drop frame if the flag is
+// set, which reads 24fps frames the same way.  The decoded frame's Time falls on the zero day.  Frames
+// sent at other rates need their rate, use LTCFrame.DecodeFrame for those.
+func DecodeFrame(binaryFrame []byte) (LTCFrame, error) {
+	return LTCFrame{FramesPerSecond: 30}.DecodeFrame(binaryFrame)
 }
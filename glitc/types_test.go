@@ -1,6 +1,10 @@
 package glitc
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
 	"testing"
 	"time"
 
@@ -33,6 +37,409 @@ func TestAsBCD(t *testing.T) {
 	}
 }
 
+func TestAsBCDChecked(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Number       int
+		Max          int
+		ExpectedTens int
+		ExpectedOnes int
+		ExpectError  bool
+	}{
+		{"Zero", 0, 99, 0, 0, false},
+		{"Max", 23, 23, 2, 3, false},
+		{"OverMax", 24, 23, 0, 0, true},
+		{"TwoDigits", 99, 99, 9, 9, false},
+		{"ThreeDigits", 100, 99, 0, 0, true},
+		{"ThreeDigitsMax", 100, 100, 0, 0, true},
+		{"Negative", -1, 99, 0, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			tens, ones, err := asBCDChecked(c.Number, c.Max)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error for %d with max %d", c.Number, c.Max)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unexpected error: %v", err)
+			}
+			if tens != c.ExpectedTens || ones != c.ExpectedOnes {
+				st.Errorf("Incorrect digits: got %d, %d expected %d, %d", tens, ones, c.ExpectedTens, c.ExpectedOnes)
+			}
+		})
+	}
+}
+
+func TestFrameValidate(t *testing.T) {
+	date := &[4]byte{0x01, 0x12, 0x18, 0x00}
+	testCases := []struct {
+		Name        string
+		Frame       LTCFrame
+		ExpectError bool
+	}{
+		{"24fps", LTCFrame{FramesPerSecond: 24}, false},
+		{"23.976fps", LTCFrame{FramesPerSecond: 24, PullDown: true}, false},
+		{"25fps", LTCFrame{FramesPerSecond: 25}, false},
+		{"29.97fps/df", LTCFrame{FramesPerSecond: 30, DropFrame: true}, false},
+		{"60fps", LTCFrame{FramesPerSecond: 60}, false},
+		{"29.97fps/fps", LTCFrame{FramesPerSecond: 29.97}, true},
+		{"0fps", LTCFrame{}, true},
+		{"48fps", LTCFrame{FramesPerSecond: 48}, true},
+		{"25fps/df", LTCFrame{FramesPerSecond: 25, DropFrame: true}, true},
+		{"59.94fps/df", LTCFrame{FramesPerSecond: 60, DropFrame: true}, false},
+		{"59.94fps/df/pulldown", LTCFrame{FramesPerSecond: 60, DropFrame: true, PullDown: true}, true},
+		{"29.97fps/df/pulldown", LTCFrame{FramesPerSecond: 30, DropFrame: true, PullDown: true}, true},
+		{"BGF0", LTCFrame{FramesPerSecond: 30, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{'L', 'T', 'C', '!'}}, false},
+		{"BGF0/no user bits", LTCFrame{FramesPerSecond: 30, BinaryGroupFlags: BGF0}, true},
+		{"BGF1", LTCFrame{FramesPerSecond: 30, BinaryGroupFlags: 1 << 1, UserBytes: date}, true},
+		{"BGF2", LTCFrame{FramesPerSecond: 30, BinaryGroupFlags: BGF2, UserBytes: date}, false},
+		{"BGF2/invalid date", LTCFrame{FramesPerSecond: 30, BinaryGroupFlags: BGF2, UserBytes: &[4]byte{0x31, 0x02, 0x18, 0x00}}, true},
+		{"User bits", LTCFrame{FramesPerSecond: 30, UserBytes: &[4]byte{0xFF, 0xFF, 0xFF, 0xFF}}, false},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if err := c.Frame.Validate(); (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected validation result: %v", err)
+			}
+		})
+	}
+}
+
+func TestEncodeTimeCode(t *testing.T) {
+	day := time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local)
+	testCases := []struct {
+		Name        string
+		Frame       LTCFrame
+		TimeCode    TimeCode
+		ExpectError bool
+	}{
+		{"30fps", LTCFrame{FramesPerSecond: 30}, TimeCode{23, 59, 59, 29, false}, false},
+		{"30fps/hour", LTCFrame{FramesPerSecond: 30}, TimeCode{24, 0, 0, 0, false}, true},
+		{"30fps/minute", LTCFrame{FramesPerSecond: 30}, TimeCode{1, 60, 0, 0, false}, true},
+		{"30fps/second", LTCFrame{FramesPerSecond: 30}, TimeCode{1, 0, 131, 0, false}, true},
+		{"30fps/frame", LTCFrame{FramesPerSecond: 30}, TimeCode{1, 0, 0, 30, false}, true},
+		{"25fps/frame", LTCFrame{FramesPerSecond: 25}, TimeCode{1, 0, 0, 25, false}, true},
+		{"60fps", LTCFrame{FramesPerSecond: 60}, TimeCode{1, 0, 0, 58, false}, false},
+		{"30fps/df", LTCFrame{FramesPerSecond: 30, DropFrame: true}, TimeCode{1, 10, 0, 0, true}, false},
+		{"30fps/dropped", LTCFrame{FramesPerSecond: 30, DropFrame: true}, TimeCode{1, 11, 0, 1, true}, true},
+		{"30fps/mismatch", LTCFrame{FramesPerSecond: 30, DropFrame: true}, TimeCode{1, 10, 0, 0, false}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			f.Time = day
+			encoded, err := f.EncodeTimeCode(c.TimeCode)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error for %s", c.TimeCode)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unexpected error: %v", err)
+			}
+
+			decoded, err := f.DecodeFrame(encoded)
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if diff := deep.Equal(decoded.Frame(), c.TimeCode); len(diff) > 0 {
+				st.Error("Decoded timecode doesn't match:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTimeCode(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Input            string
+		ExpectedTimeCode TimeCode
+		ExpectError      bool
+	}{
+		{"NonDrop", "23:14:21:05", TimeCode{23, 14, 21, 5, false}, false},
+		{"DropFrame", "01:02:03;29", TimeCode{1, 2, 3, 29, true}, false},
+		{"Zero", "00:00:00:00", TimeCode{0, 0, 0, 0, false}, false},
+		{"HourRange", "25:00:00:00", TimeCode{}, true},
+		{"MinuteRange", "00:60:00:00", TimeCode{}, true},
+		{"SecondRange", "00:00:60:00", TimeCode{}, true},
+		{"FramePair", "23:59:59:58", TimeCode{23, 59, 59, 58, false}, false},
+		{"DropFramePair", "00:10:00;59", TimeCode{0, 10, 0, 59, true}, false},
+		{"FrameRange", "00:00:00:60", TimeCode{}, true},
+		{"DropFrameSeparator", "00:00;00:00", TimeCode{}, true},
+		{"Separator", "00.00.00.00", TimeCode{}, true},
+		{"Short", "0:00:00:00", TimeCode{}, true},
+		{"Long", "00:00:00:000", TimeCode{}, true},
+		{"Sign", "+1:00:00:00", TimeCode{}, true},
+		{"Empty", "", TimeCode{}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			tc, err := ParseTimeCode(c.Input)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error parsing '%s', got %s", c.Input, tc)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unable to parse '%s': %v", c.Input, err)
+			}
+			if diff := deep.Equal(tc, c.ExpectedTimeCode); len(diff) > 0 {
+				st.Error("Parsed timecode doesn't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+			if s := tc.String(); s != c.Input {
+				st.Errorf("Timecode doesn't round trip: got '%s' expected '%s'", s, c.Input)
+			}
+		})
+	}
+}
+
+func TestTimeCodeJSON(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		TimeCode TimeCode
+		JSON     string
+	}{
+		{"NonDrop", TimeCode{23, 14, 21, 5, false}, `"23:14:21:05"`},
+		{"DropFrame", TimeCode{1, 2, 3, 29, true}, `"01:02:03;29"`},
+		{"Midnight", TimeCode{0, 0, 0, 0, false}, `"00:00:00:00"`},
+		{"FramePair", TimeCode{12, 30, 15, 48, false}, `"12:30:15:48"`},
+		{"DropFramePair", TimeCode{23, 59, 59, 59, true}, `"23:59:59;59"`},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			b, err := json.Marshal(c.TimeCode)
+			if err != nil {
+				st.Fatalf("Unable to marshal %s: %v", c.TimeCode, err)
+			}
+			if string(b) != c.JSON {
+				st.Errorf("Incorrect JSON: got %s expected %s", b, c.JSON)
+			}
+
+			var tc TimeCode
+			if err := json.Unmarshal(b, &tc); err != nil {
+				st.Fatalf("Unable to unmarshal %s: %v", b, err)
+			}
+			if diff := deep.Equal(tc, c.TimeCode); len(diff) > 0 {
+				st.Error("Round tripped timecode doesn't match:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+
+	// timecodes embedded in other values use the same form
+	b, err := json.Marshal(struct {
+		Start *TimeCode `json:"start"`
+	}{&TimeCode{10, 0, 0, 0, true}})
+	if err != nil || string(b) != `{"start":"10:00:00;00"}` {
+		t.Errorf("Incorrect embedded JSON %s: %v", b, err)
+	}
+
+	for _, invalid := range []string{`"10:00:00"`, `"25:00:00:00"`, `"00:00:00:60"`, `36000`, `{"Hour":10}`} {
+		var tc TimeCode
+		if err := json.Unmarshal([]byte(invalid), &tc); err == nil {
+			t.Errorf("Expected an error unmarshalling %s, got %s", invalid, tc)
+		}
+	}
+}
+
+func TestTimeCodeIsValid(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		TimeCode  TimeCode
+		FPS       float64
+		DropFrame bool
+		Expected  bool
+	}{
+		{"30fps", TimeCode{1, 2, 3, 29, false}, 30, false, true},
+		{"25fps", TimeCode{1, 2, 3, 24, false}, 25, false, true},
+		{"25fps-frame29", TimeCode{1, 2, 3, 29, false}, 25, false, false},
+		{"23.976fps-frame23", TimeCode{1, 2, 3, 23, false}, 23.976, false, true},
+		{"23.976fps-frame24", TimeCode{1, 2, 3, 24, false}, 23.976, false, false},
+		{"60fps-frame59", TimeCode{1, 2, 3, 59, false}, 60, false, true},
+		{"Hour", TimeCode{24, 0, 0, 0, false}, 30, false, false},
+		{"Minute", TimeCode{0, 60, 0, 0, false}, 30, false, false},
+		{"Second", TimeCode{0, 0, 60, 0, false}, 30, false, false},
+		{"Negative", TimeCode{0, 0, 0, -1, false}, 30, false, false},
+		{"ZeroFPS", TimeCode{0, 0, 0, 0, false}, 0, false, false},
+		{"df-frame0", TimeCode{0, 1, 0, 0, true}, 29.97, true, false},
+		{"df-frame1", TimeCode{0, 1, 0, 1, true}, 29.97, true, false},
+		{"df-frame2", TimeCode{0, 1, 0, 2, true}, 29.97, true, true},
+		{"df-tens", TimeCode{0, 10, 0, 0, true}, 29.97, true, true},
+		{"df-second", TimeCode{0, 1, 1, 0, true}, 29.97, true, true},
+		{"df-midnight", TimeCode{0, 0, 0, 0, true}, 29.97, true, true},
+		// frames are only dropped from drop frame timecode
+		{"ndf-frame0", TimeCode{0, 1, 0, 0, false}, 29.97, false, true},
+		{"df-25fps", TimeCode{0, 0, 1, 0, true}, 25, true, false},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if valid := c.TimeCode.IsValid(c.FPS, c.DropFrame); valid != c.Expected {
+				st.Errorf("Expected IsValid(%g, %v) to be %v for %s", c.FPS, c.DropFrame, c.Expected, c.TimeCode)
+			}
+		})
+	}
+}
+
+func TestTimeCodeCompare(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		A        TimeCode
+		B        TimeCode
+		Expected int
+	}{
+		{"Frame", TimeCode{1, 2, 3, 4, false}, TimeCode{1, 2, 3, 5, false}, -1},
+		{"Hour", TimeCode{2, 0, 0, 0, false}, TimeCode{1, 59, 59, 29, false}, 1},
+		{"Same", TimeCode{1, 2, 3, 4, false}, TimeCode{1, 2, 3, 4, false}, 0},
+		{"60fps", TimeCode{0, 0, 0, 59, false}, TimeCode{0, 0, 1, 0, false}, -1},
+		{"DropFrameSkip", TimeCode{0, 0, 59, 29, true}, TimeCode{0, 1, 0, 2, true}, -1},
+		{"DropFrameTens", TimeCode{0, 10, 0, 0, true}, TimeCode{0, 9, 59, 29, true}, 1},
+		// 17982 frames after midnight, 18 frames have been dropped by 00:10:00;00
+		{"MixedEqual", TimeCode{0, 10, 0, 0, true}, TimeCode{0, 9, 59, 12, false}, 0},
+		// field by field the drop frame timecode is later
+		{"MixedBefore", TimeCode{0, 10, 0, 0, true}, TimeCode{0, 9, 59, 20, false}, -1},
+		{"MixedAfter", TimeCode{0, 1, 0, 2, true}, TimeCode{0, 0, 59, 29, false}, 1},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if before := c.A.Before(c.B); before != (c.Expected < 0) {
+				st.Errorf("Expected %s.Before(%s) to be %v", c.A, c.B, c.Expected < 0)
+			}
+			if after := c.A.After(c.B); after != (c.Expected > 0) {
+				st.Errorf("Expected %s.After(%s) to be %v", c.A, c.B, c.Expected > 0)
+			}
+			if equal := c.A.Equal(c.B); equal != (c.Expected == 0) {
+				st.Errorf("Expected %s.Equal(%s) to be %v", c.A, c.B, c.Expected == 0)
+			}
+			// and the other way around
+			if after := c.B.After(c.A); after != (c.Expected < 0) {
+				st.Errorf("Expected %s.After(%s) to be %v", c.B, c.A, c.Expected < 0)
+			}
+		})
+	}
+}
+
+func TestTimeCodeAdd(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		TimeCode         TimeCode
+		FPS              float64
+		Frames           int
+		ExpectedTimeCode TimeCode
+	}{
+		{"25fps", TimeCode{1, 2, 3, 4, false}, 25, 3, TimeCode{1, 2, 3, 7, false}},
+		{"25fps-second", TimeCode{1, 2, 3, 24, false}, 25, 1, TimeCode{1, 2, 4, 0, false}},
+		{"25fps-back", TimeCode{1, 2, 4, 0, false}, 25, -1, TimeCode{1, 2, 3, 24, false}},
+		{"30fps-minutes", TimeCode{1, 2, 3, 4, false}, 30, 30 * 60 * 10, TimeCode{1, 12, 3, 4, false}},
+		{"30fps-wrap", TimeCode{23, 59, 59, 29, false}, 30, 1, TimeCode{0, 0, 0, 0, false}},
+		{"30fps-wrap-back", TimeCode{0, 0, 0, 0, false}, 30, -1, TimeCode{23, 59, 59, 29, false}},
+		{"23.976fps", TimeCode{0, 0, 59, 23, false}, 23.976, 1, TimeCode{0, 1, 0, 0, false}},
+		{"29.97fps/df", TimeCode{0, 0, 0, 0, true}, 29.97, 5, TimeCode{0, 0, 0, 5, true}},
+		{"29.97fps/df-drop", TimeCode{0, 0, 59, 29, true}, 29.97, 1, TimeCode{0, 1, 0, 2, true}},
+		{"29.97fps/df-drop-back", TimeCode{0, 1, 0, 2, true}, 29.97, -1, TimeCode{0, 0, 59, 29, true}},
+		{"29.97fps/df-tens", TimeCode{0, 9, 59, 29, true}, 29.97, 1, TimeCode{0, 10, 0, 0, true}},
+		{"29.97fps/df-tens-back", TimeCode{0, 10, 0, 0, true}, 29.97, -1, TimeCode{0, 9, 59, 29, true}},
+		{"29.97fps/df-minute", TimeCode{0, 0, 58, 0, true}, 29.97, 90, TimeCode{0, 1, 1, 2, true}},
+		{"29.97fps/df-wrap", TimeCode{23, 59, 59, 29, true}, 29.97, 1, TimeCode{0, 0, 0, 0, true}},
+		{"29.97fps/df-wrap-back", TimeCode{0, 0, 0, 0, true}, 29.97, -1, TimeCode{23, 59, 59, 29, true}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			tc := c.TimeCode.Add(c.Frames, c.FPS)
+			if diff := deep.Equal(tc, c.ExpectedTimeCode); len(diff) > 0 {
+				st.Errorf("Incorrect timecode adding %d frames to %s: got %s", c.Frames, c.TimeCode, tc)
+			}
+			if back := tc.Sub(c.Frames, c.FPS); back != c.TimeCode {
+				st.Errorf("Incorrect timecode subtracting %d frames from %s: got %s expected %s", c.Frames, tc, back, c.TimeCode)
+			}
+		})
+	}
+}
+
+func TestTimeCodeFrames(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		TimeCode       TimeCode
+		FPS            float64
+		DropFrame      bool
+		ExpectedFrames int
+	}{
+		{"Zero", TimeCode{0, 0, 0, 0, false}, 30, false, 0},
+		{"25fps", TimeCode{1, 2, 3, 4, false}, 25, false, 93079},
+		{"30fps-day", TimeCode{23, 59, 59, 29, false}, 30, false, 2591999},
+		{"29.97fps/df-minute", TimeCode{0, 1, 0, 2, true}, 29.97, true, 1800},
+		{"29.97fps/df-tens", TimeCode{0, 10, 0, 0, true}, 29.97, true, 17982},
+		{"29.97fps/df-hour", TimeCode{1, 0, 0, 0, true}, 29.97, true, 107892},
+		{"29.97fps/df-day", TimeCode{23, 59, 59, 29, true}, 29.97, true, 2589407},
+		{"59.94fps/df-minute", TimeCode{0, 1, 0, 4, true}, 59.94, true, 3600},
+		{"59.94fps/df-second", TimeCode{0, 1, 1, 0, true}, 59.94, true, 3656},
+		{"59.94fps/df-tens", TimeCode{0, 10, 0, 0, true}, 59.94, true, 35964},
+		{"59.94fps/df-hour", TimeCode{1, 0, 0, 0, true}, 59.94, true, 215784},
+		{"59.94fps/df-day", TimeCode{23, 59, 59, 59, true}, 59.94, true, 5178815},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if frames := c.TimeCode.ToFrames(c.FPS, c.DropFrame); frames != c.ExpectedFrames {
+				st.Errorf("Incorrect frame count for %s: got %d expected %d", c.TimeCode, frames, c.ExpectedFrames)
+			}
+			if tc := TimeCodeFromFrames(c.ExpectedFrames, c.FPS, c.DropFrame); tc != c.TimeCode {
+				st.Errorf("Incorrect timecode for %d frames: got %s expected %s", c.ExpectedFrames, tc, c.TimeCode)
+			}
+		})
+	}
+
+	if tc := TimeCodeFromFrames(2589408, 29.97, true); tc != (TimeCode{0, 0, 0, 0, true}) {
+		t.Errorf("29.97fps/df should wrap after 2589408 frames, got %s", tc)
+	}
+}
+
+func TestTimeCodeFramesMatchFrameIndex(t *testing.T) {
+	frames := []LTCFrame{
+		{FramesPerSecond: 24},
+		{FramesPerSecond: 25},
+		{FramesPerSecond: 30},
+		{FramesPerSecond: 24, PullDown: true},
+		{FramesPerSecond: 30, DropFrame: true},
+	}
+	midnight := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, f := range frames {
+		// pulled down timecode runs slower than wall clock and can't reach 24:00:00:00 in a day
+		day := int(math.Min(float64(framesPerDay(int(f.FramesPerSecond), f.DropFrame)), 24*3600*f.EffectiveFPS()))
+		for n := 0; n < day; n += 997 {
+			tc := TimeCodeFromFrames(n, f.EffectiveFPS(), f.DropFrame)
+			f.SetTimeCode(tc, midnight)
+			if index := f.FrameIndex(); index != n {
+				t.Fatalf("%0.3f fps frame index for %s doesn't match frame count: got %d expected %d", f.EffectiveFPS(), tc, index, n)
+			}
+			if diff := deep.Equal(f.Frame(), tc); len(diff) > 0 {
+				t.Fatalf("%0.3f fps timecode for frame %d doesn't match: got %s expected %s", f.EffectiveFPS(), n, f.Frame(), tc)
+			}
+		}
+	}
+}
+
 func TestFrame(t *testing.T) {
 	testCases := []struct {
 		Name             string
@@ -105,6 +512,10 @@ func TestFrameDuration(t *testing.T) {
 		{"29.97fps(df)", LTCFrame{FramesPerSecond: 30, DropFrame: true}, 33366700 * time.Nanosecond},
 		{"25fps", LTCFrame{FramesPerSecond: 25}, 40000000 * time.Nanosecond},
 		{"24fps", LTCFrame{FramesPerSecond: 24}, 41666666 * time.Nanosecond},
+		{"50fps", LTCFrame{FramesPerSecond: 50}, 20000000 * time.Nanosecond},
+		{"60fps", LTCFrame{FramesPerSecond: 60}, 16666666 * time.Nanosecond},
+		{"59.94fps", LTCFrame{FramesPerSecond: 60, PullDown: true}, 16683333 * time.Nanosecond},
+		{"23.976fps", LTCFrame{FramesPerSecond: 24, PullDown: true}, 41708333 * time.Nanosecond},
 	}
 
 	for _, c := range testCases {
@@ -117,75 +528,757 @@ func TestFrameDuration(t *testing.T) {
 	}
 }
 
-func TestFrameEncode(t *testing.T) {
+func TestFrameCountOneSecond(t *testing.T) {
+	start := time.Date(2018, 12, 1, 10, 0, 0, 0, time.UTC)
+
 	testCases := []struct {
-		Name          string
-		Frame         LTCFrame
-		ExpectedFrame []byte
+		Name               string
+		Frame              LTCFrame
+		ExpectedFrameCount int
 	}{
-		{
-			"25fps-0",
-			LTCFrame{Time: time.Date(2018, 12, 1, 23, 0, 0, 0, time.Local), FramesPerSecond: 25},
-			[]byte{0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x50, 0x3F, 0xFD},
-		},
-		{
-			"30fps-0",
-			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30},
-			[]byte{0x00, 0x10, 0x80, 0x50, 0x20, 0x80, 0xC0, 0x40, 0x3F, 0xFD},
-		},
-		{
-			"30fps/df-2",
-			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 0, 0, time.Local), FramesPerSecond: 30, DropFrame: true},
-			[]byte{0x10, 0x70, 0x90, 0xB0, 0xC0, 0x80, 0xC0, 0x40, 0x3F, 0xFD},
-		},
-		{
-			"30fps/df-0",
-			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 30, DropFrame: true, ExternalClockSync: true},
-			[]byte{0x90, 0x70, 0x00, 0x40, 0x00, 0x20, 0xC0, 0x60, 0x3F, 0xFD},
-		},
-		{
-			"30fps/df-0-userdata",
-			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 30, DropFrame: true, ExternalClockSync: true, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
-			[]byte{0x95, 0x7A, 0x03, 0x4C, 0x01, 0x39, 0xC2, 0x67, 0x3F, 0xFD},
-		},
-		{
-			"25fps-0-userdata",
-			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 25, ExternalClockSync: true, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
-			[]byte{0x05, 0x1A, 0x83, 0x5C, 0x01, 0x29, 0xC2, 0x77, 0x3F, 0xFD},
-		},
+		{"24fps", LTCFrame{FramesPerSecond: 24}, 24},
+		{"25fps", LTCFrame{FramesPerSecond: 25}, 25},
+		{"30fps", LTCFrame{FramesPerSecond: 30}, 30},
+		{"50fps", LTCFrame{FramesPerSecond: 50}, 50},
+		{"60fps", LTCFrame{FramesPerSecond: 60}, 60},
+		{"59.94fps", LTCFrame{FramesPerSecond: 60, PullDown: true}, 60},
 	}
 
 	for _, c := range testCases {
 		t.Run(c.Name, func(st *testing.T) {
-			frameBytes := c.Frame.EncodeFrame()
-			if diff := deep.Equal(frameBytes, c.ExpectedFrame); len(diff) > 0 {
-				st.Error("Encoded frame doesn't match expected value:")
-				st.Logf("Got time: %s", c.Frame.Frame())
+			f := c.Frame
+			f.SetTimeCode(TimeCode{Hour: 10}, start)
+			begin := f.Time.Add(-f.FrameDuration() / 2)
+			frames := map[int]bool{}
+			for d := 500 * time.Microsecond; d < time.Second; d += time.Millisecond {
+				f.Time = begin.Add(d)
+				frames[f.FrameIndex()] = true
+				if tc := f.Frame(); tc.Frame >= c.ExpectedFrameCount {
+					st.Fatalf("Frame number out of range at %s: %s", d, tc)
+				}
+			}
+			if len(frames) != c.ExpectedFrameCount {
+				st.Errorf("Incorrect frame count in one second: got %d expected %d", len(frames), c.ExpectedFrameCount)
+			}
+			if bitPeriod := f.BitPeriod(); bitPeriod != f.FrameDuration()/80 {
+				st.Errorf("Incorrect bit period: got %s expected %s", bitPeriod, f.FrameDuration()/80)
+			}
+		})
+	}
+}
+
+func TestFrameNumberEndOfSecond(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+	}{
+		{"24fps", LTCFrame{FramesPerSecond: 24}},
+		{"25fps", LTCFrame{FramesPerSecond: 25}},
+		{"30fps", LTCFrame{FramesPerSecond: 30}},
+		{"50fps", LTCFrame{FramesPerSecond: 50}},
+		{"60fps", LTCFrame{FramesPerSecond: 60}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			fps := int(f.FramesPerSecond)
+			second := time.Date(2018, 12, 1, 10, 0, 59, 0, time.UTC)
+
+			// every nanosecond either side of each frame boundary, and the last microsecond of the second
+			var offsets []time.Duration
+			for frame := 1; frame <= fps; frame++ {
+				boundary := time.Duration(frame) * time.Second / time.Duration(fps)
+				for d := -2 * time.Nanosecond; d <= 2*time.Nanosecond; d++ {
+					offsets = append(offsets, boundary+d)
+				}
+			}
+			for d := time.Second - time.Microsecond; d < time.Second; d++ {
+				offsets = append(offsets, d)
+			}
+
+			maxFrame := 0
+			for _, d := range offsets {
+				if d >= time.Second {
+					continue
+				}
+				f.Time = second.Add(d)
+				tc := f.Frame()
+				if tc.Frame >= fps || tc.Frame < 0 {
+					st.Fatalf("Illegal frame number at %s: %s", d, tc)
+				}
+				if expected := int(time.Duration(fps) * d / time.Second); tc.Frame != expected {
+					st.Fatalf("Incorrect frame number at %s: got %d expected %d", d, tc.Frame, expected)
+				}
+				if tc.Frame > maxFrame {
+					maxFrame = tc.Frame
+				}
+			}
+			if maxFrame != fps-1 {
+				st.Errorf("Expected the last frame of the second to be %d, got %d", fps-1, maxFrame)
+			}
+			f.Time = second.Add(time.Second - time.Nanosecond)
+			if tc := f.Frame(); tc.Second != 59 || tc.Frame != fps-1 {
+				st.Errorf("Expected the last nanosecond of the second to be frame 59:%d, got %s", fps-1, tc)
+			}
+		})
+	}
+}
+
+func TestFrameCountOneHour(t *testing.T) {
+	midnight := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name               string
+		Frame              LTCFrame
+		ExpectedFrameCount int
+		ExpectedTimeCode   TimeCode
+	}{
+		{"24fps", LTCFrame{FramesPerSecond: 24}, 86400, TimeCode{1, 0, 0, 0, false}},
+		{"23.976fps", LTCFrame{FramesPerSecond: 24, PullDown: true}, 86313, TimeCode{0, 59, 56, 9, false}},
+		{"29.97fps/df", LTCFrame{FramesPerSecond: 30, DropFrame: true}, 107892, TimeCode{1, 0, 0, 0, true}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			f.Time = midnight.Add(time.Hour)
+			if frameCount := f.FrameIndex(); frameCount != c.ExpectedFrameCount {
+				st.Errorf("Incorrect frame count after one hour: got %d expected %d", frameCount, c.ExpectedFrameCount)
+			}
+			if diff := deep.Equal(f.Frame(), c.ExpectedTimeCode); len(diff) > 0 {
+				st.Error("Timecode after one hour doesn't match expected value:")
 				for _, l := range diff {
 					st.Log(l)
 				}
 			}
+
+			// Step through the hour a frame at a time, the index must never skip or repeat
+			f.Time = midnight.Add(f.FrameDuration() / 2)
+			for i := 0; i < c.ExpectedFrameCount; i++ {
+				if index := f.FrameIndex(); index != i {
+					st.Fatalf("Frame index discontinuity at %s: got %d expected %d", f.Frame(), index, i)
+				}
+				f.Time = f.Time.Add(f.FrameDuration())
+			}
 		})
 	}
+}
+
+func TestDropFrameTenMinutes(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		FPS     int
+		Dropped int
+		Frames  int
+	}{
+		{"29.97df", 30, 2, 17982},
+		{"59.94df", 60, 4, 35964},
+	}
+	starts := []time.Time{
+		time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2018, 12, 1, 13, 50, 0, 0, time.UTC),
+		time.Date(2018, 12, 1, 23, 50, 0, 0, time.UTC),
+	}
 
+	for _, c := range testCases {
+		for _, start := range starts {
+			t.Run(c.Name+"/"+start.Format("15:04"), func(st *testing.T) {
+				testDropFrameWindow(st, c.FPS, c.Dropped, c.Frames, start)
+			})
+		}
+	}
 }
 
-func TestFrameBeginTime(t *testing.T) {
-	zoneUSCentral, err := time.LoadLocation("US/Central")
-	if err != nil {
-		t.Fatalf("Unable to load US/Central Timezone: %v", err)
+// testDropFrameWindow steps through the 10 minute drop frame window at fps from start, checking that
+// exactly the first dropped frame numbers of 9 of its minutes are skipped
+func testDropFrameWindow(st *testing.T, fps int, dropped int, frames int, start time.Time) {
+	f := LTCFrame{FramesPerSecond: float64(fps), DropFrame: true}
+	end := start.Add(10 * time.Minute)
+
+	// sample every millisecond, and every nanosecond across the end of each second
+	var prev TimeCode
+	seen := 0
+	for at := start; at.Before(end); {
+		f.Time = at
+		tc := f.Frame()
+
+		if tc.Frame < 0 || tc.Frame >= fps || tc.Second < 0 || tc.Second > 59 ||
+			tc.Hour != start.Hour() || tc.Minute/10 != start.Minute()/10 {
+			st.Fatalf("Invalid timecode %s at %s", tc, at.Format(time.RFC3339Nano))
+		}
+		if tc.Minute%10 != 0 && tc.Second == 0 && tc.Frame < dropped {
+			st.Fatalf("Dropped frame %s at %s", tc, at.Format(time.RFC3339Nano))
+		}
+		if at == start || tc != prev {
+			seen++
+		}
+		if at != start && tc != prev && tc != prev.Add(1, float64(fps)) {
+			st.Fatalf("Timecode jumped from %s to %s at %s", prev, tc, at.Format(time.RFC3339Nano))
+		}
+		if index := f.FrameIndex(); index != tc.ToFrames(float64(fps), true) {
+			st.Fatalf("Frame index %d doesn't match timecode %s at %s", index, tc, at.Format(time.RFC3339Nano))
+		}
+		prev = tc
+
+		switch ns := at.Nanosecond(); {
+		case ns >= 999999000:
+			at = at.Add(time.Nanosecond)
+		case ns >= 999000000:
+			at = at.Add(time.Microsecond)
+		default:
+			at = at.Add(time.Millisecond)
+		}
+	}
+	if seen != frames {
+		st.Errorf("Expected %d frames in the window, got %d", frames, seen)
 	}
 
-	cases := []struct {
-		Name              string
-		Frame             LTCFrame
-		ExpectedBeginTime time.Time
-	}{
-		{
-			Name:              "midnight",
-			Frame:             LTCFrame{Time: time.Date(2019, 1, 1, 0, 0, 0, 0, zoneUSCentral), FramesPerSecond: 30},
-			ExpectedBeginTime: time.Date(2019, 1, 1, 0, 0, 0, 0, zoneUSCentral),
-		},
+	// the final frame of the window runs right up to the next window
+	f.Time = end.Add(-time.Nanosecond)
+	expected := TimeCode{Hour: start.Hour(), Minute: start.Minute() + 9, Second: 59, Frame: fps - 1, DropFrame: true}
+	if diff := deep.Equal(f.Frame(), expected); len(diff) > 0 {
+		st.Error("Final frame of window doesn't match expected value:")
+		for _, l := range diff {
+			st.Log(l)
+		}
+	}
+}
+
+func TestDropFramePartialWindow(t *testing.T) {
+	day := time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Name  string
+		Start string
+	}{
+		{"MidWindow", "01:07:33;00"},
+		{"AcrossWindow", "01:09:50;12"},
+		{"AcrossDroppedMinute", "01:00:59;15"},
+		{"AcrossHour", "01:59:55;00"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			tc, err := ParseTimeCode(c.Start)
+			if err != nil {
+				st.Fatalf("Unable to parse %s: %v", c.Start, err)
+			}
+			f := LTCFrame{FramesPerSecond: 30, DropFrame: true}
+			f.SetTimeCode(tc, day)
+			if f.Frame() != tc {
+				st.Fatalf("Expected to start at %s, got %s", tc, f.Frame())
+			}
+
+			expected := tc
+			index := f.FrameIndex()
+			for i := 1; i <= 600; i++ {
+				f.Time = f.Time.Add(f.FrameDuration())
+				expected = expected.Add(1, 30)
+				if f.Frame() != expected {
+					st.Fatalf("Frame %d after %s: got %s expected %s", i, tc, f.Frame(), expected)
+				}
+				if next := f.FrameIndex(); next != index+1 {
+					st.Fatalf("Frame index discontinuity at %s: got %d expected %d", f.Frame(), next, index+1)
+				}
+				index++
+			}
+		})
+	}
+}
+
+func TestSetTimeCode(t *testing.T) {
+	at := time.Date(2018, 12, 1, 12, 34, 56, 0, time.Local)
+
+	testCases := []struct {
+		Name             string
+		Frame            LTCFrame
+		TimeCode         TimeCode
+		Advance          int
+		ExpectedTimeCode TimeCode
+	}{
+		{"25fps", LTCFrame{FramesPerSecond: 25}, TimeCode{1, 2, 3, 4, false}, 3, TimeCode{1, 2, 3, 7, false}},
+		{"25fps-second", LTCFrame{FramesPerSecond: 25}, TimeCode{1, 2, 3, 23, false}, 3, TimeCode{1, 2, 4, 1, false}},
+		{"30fps-hour", LTCFrame{FramesPerSecond: 30}, TimeCode{9, 59, 59, 29, false}, 1, TimeCode{10, 0, 0, 0, false}},
+		{"23.976fps", LTCFrame{FramesPerSecond: 24, PullDown: true}, TimeCode{1, 0, 59, 22, false}, 4, TimeCode{1, 1, 0, 2, false}},
+		{"29.97fps/df", LTCFrame{FramesPerSecond: 30, DropFrame: true}, TimeCode{10, 0, 0, 0, true}, 5, TimeCode{10, 0, 0, 5, true}},
+		{"29.97fps/df-drop", LTCFrame{FramesPerSecond: 30, DropFrame: true}, TimeCode{10, 0, 59, 28, true}, 3, TimeCode{10, 1, 0, 3, true}},
+		{"29.97fps/df-nodrop", LTCFrame{FramesPerSecond: 30, DropFrame: true}, TimeCode{10, 9, 59, 28, true}, 3, TimeCode{10, 10, 0, 1, true}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			f.SetTimeCode(c.TimeCode, at)
+			if diff := deep.Equal(f.Frame(), c.TimeCode); len(diff) > 0 {
+				st.Error("Timecode doesn't match jam sync value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+
+			index := f.FrameIndex()
+			f.Time = f.Time.Add(time.Duration(c.Advance) * f.FrameDuration())
+			if f.FrameIndex() != index+c.Advance {
+				st.Errorf("Incorrect frame index: got %d expected %d", f.FrameIndex(), index+c.Advance)
+			}
+			if diff := deep.Equal(f.Frame(), c.ExpectedTimeCode); len(diff) > 0 {
+				st.Error("Timecode after advancing doesn't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestSetTimeCodePullDownMidnight(t *testing.T) {
+	at := time.Date(2018, 12, 1, 12, 34, 56, 0, time.Local)
+	for _, r := range []Rate{Rate23976, Rate2997ND, Rate5994ND} {
+		t.Run(r.String(), func(st *testing.T) {
+			fps := int(r.Frame().FramesPerSecond)
+			step := 1
+			if fps > 30 {
+				// only frame pairs are sent
+				step = 2
+			}
+			for frame := 0; frame < fps; frame += step {
+				tc := TimeCode{Hour: 23, Minute: 59, Second: 59, Frame: frame}
+				f := r.Frame()
+				f.SetTimeCode(tc, at)
+				if f.Frame() != tc {
+					st.Fatalf("Frame set to %s holds %s", tc, f.Frame())
+				}
+				if begin := f.FrameBeginTime(); f.Time.Before(begin) || !f.Time.Before(begin.Add(f.FrameDuration())) {
+					st.Errorf("Frame %s at %s begins at %s", tc, f.Time, begin)
+				}
+
+				f.Time = at
+				encoded, err := f.EncodeTimeCode(tc)
+				if err != nil {
+					st.Fatalf("Unexpected error result: %v", err)
+				}
+				decoded, err := LTCFrame{Time: at, FramesPerSecond: f.FramesPerSecond, PullDown: true}.DecodeFrame(encoded)
+				if err != nil {
+					st.Fatalf("Unable to decode frame %s: %v", tc, err)
+				}
+				if decoded.Frame() != tc {
+					st.Errorf("Encoded %s decodes as %s", tc, decoded.Frame())
+				}
+			}
+
+			// the last frame of the timecode day is followed by 00:00:00:00
+			f := r.Frame()
+			f.SetTimeCode(TimeCode{Hour: 23, Minute: 59, Second: 59, Frame: fps - 1}, at)
+			index := f.FrameIndex()
+			f.Time = f.Time.Add(f.FrameDuration())
+			if f.Frame() != (TimeCode{}) || f.FrameIndex() != 0 || index != f.FramesPerDay()-1 {
+				st.Errorf("Frame %d of %d was followed by %s, index %d", index, f.FramesPerDay(), f.Frame(), f.FrameIndex())
+			}
+
+			// following the clock again the day ends at midnight
+			f.SetClockTime(at)
+			if f.FramesPerDay() >= 24*3600*fps {
+				st.Errorf("Expected the clock's day to hold fewer than %d frames, got %d", 24*3600*fps, f.FramesPerDay())
+			}
+		})
+	}
+}
+
+func TestFrameEncode(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Frame         LTCFrame
+		ExpectedFrame []byte
+	}{
+		{
+			"25fps-0",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 0, 0, 0, time.Local), FramesPerSecond: 25, ColorFrame: true},
+			[]byte{0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x50, 0x3F, 0xFD},
+		},
+		{
+			"30fps-0",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true},
+			[]byte{0x00, 0x10, 0x80, 0x50, 0x20, 0x80, 0xC0, 0x40, 0x3F, 0xFD},
+		},
+		{
+			"30fps/df-2",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 0, 0, time.Local), FramesPerSecond: 30, ColorFrame: true, DropFrame: true},
+			[]byte{0x10, 0x70, 0x90, 0xB0, 0xC0, 0x80, 0xC0, 0x40, 0x3F, 0xFD},
+		},
+		{
+			"30fps/df-0",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true, DropFrame: true, ExternalClockSync: true},
+			[]byte{0x90, 0x70, 0x00, 0x40, 0x00, 0x20, 0xC0, 0x60, 0x3F, 0xFD},
+		},
+		{
+			"30fps/df-0-userdata",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true, DropFrame: true, ExternalClockSync: true, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
+			[]byte{0x95, 0x7A, 0x03, 0x4C, 0x01, 0x39, 0xC2, 0x67, 0x3F, 0xFD},
+		},
+		{
+			"25fps-0-userdata",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 25, ColorFrame: true, ExternalClockSync: true, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
+			[]byte{0x05, 0x1A, 0x83, 0x5C, 0x01, 0x29, 0xC2, 0x77, 0x3F, 0xFD},
+		},
+		{
+			"30fps-bgf2",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true, BinaryGroupFlags: BGF2},
+			[]byte{0x00, 0x10, 0x80, 0x40, 0x20, 0x80, 0xC0, 0x50, 0x3F, 0xFD},
+		},
+		{
+			"25fps-bgf2",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 0, 0, 0, time.Local), FramesPerSecond: 25, ColorFrame: true, BinaryGroupFlags: BGF2},
+			[]byte{0x00, 0x10, 0x00, 0x00, 0x00, 0x10, 0xC0, 0x40, 0x3F, 0xFD},
+		},
+		{
+			"30fps-userdata-noflags",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true, UserBytes: &[4]byte{0x01, 0x00, 0x00, 0x00}},
+			[]byte{0x01, 0x10, 0x80, 0x40, 0x20, 0x80, 0xC0, 0x40, 0x3F, 0xFD},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frameBytes := c.Frame.EncodeFrame()
+			if diff := deep.Equal(frameBytes, c.ExpectedFrame); len(diff) > 0 {
+				st.Error("Encoded frame doesn't match expected value:")
+				st.Logf("Got time: %s", c.Frame.Frame())
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+
+}
+
+func TestFrameEncodeColorFrame(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+		// bit 11 and the parity bit are expected to change
+		ExpectedDiff []byte
+	}{
+		{
+			"25fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 0, 0, 0, time.Local), FramesPerSecond: 25},
+			[]byte{0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00},
+		},
+		{
+			"30fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30},
+			[]byte{0x00, 0x10, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			"30fps/df",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 0, 0, time.Local), FramesPerSecond: 30, DropFrame: true},
+			[]byte{0x00, 0x10, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			without := f.EncodeFrame()
+			f.ColorFrame = true
+			with := f.EncodeFrame()
+
+			if without[1]&0x10 != 0 {
+				st.Errorf("Color frame bit set without ColorFrame: %#x", without[1])
+			}
+			if with[1]&0x10 == 0 {
+				st.Errorf("Color frame bit not set with ColorFrame: %#x", with[1])
+			}
+
+			diff := make([]byte, len(with))
+			for i := range with {
+				diff[i] = with[i] ^ without[i]
+			}
+			if d := deep.Equal(diff, c.ExpectedDiff); len(d) > 0 {
+				st.Errorf("Unexpected bits changed by ColorFrame: %#v", diff)
+			}
+
+			decoded, err := c.Frame.DecodeFrame(without)
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if decoded.ColorFrame {
+				st.Errorf("Decoded ColorFrame set for frame without color framing")
+			}
+		})
+	}
+}
+
+func TestFrameFlags(t *testing.T) {
+	testCases := []struct {
+		Name string
+		FPS  float64
+		Set  func(f *LTCFrame)
+		// bit number counting from the first bit sent
+		Bit int
+	}{
+		{"30fps/dropframe", 30, func(f *LTCFrame) { f.DropFrame = true }, 10},
+		{"30fps/colorframe", 30, func(f *LTCFrame) { f.ColorFrame = true }, 11},
+		{"30fps/bgf0", 30, func(f *LTCFrame) { f.BinaryGroupFlags = BGF0 }, 43},
+		{"30fps/bgf1", 30, func(f *LTCFrame) { f.ExternalClockSync = true }, 58},
+		{"30fps/bgf2", 30, func(f *LTCFrame) { f.BinaryGroupFlags = BGF2 }, 59},
+		{"30fps/fieldmark", 30, func(f *LTCFrame) { f.FieldMark = true }, 27},
+		{"25fps/colorframe", 25, func(f *LTCFrame) { f.ColorFrame = true }, 11},
+		{"25fps/bgf0", 25, func(f *LTCFrame) { f.BinaryGroupFlags = BGF0 }, 27},
+		{"25fps/bgf1", 25, func(f *LTCFrame) { f.ExternalClockSync = true }, 58},
+		{"25fps/bgf2", 25, func(f *LTCFrame) { f.BinaryGroupFlags = BGF2 }, 43},
+		{"25fps/fieldmark", 25, func(f *LTCFrame) { f.FieldMark = true }, 59},
+	}
+
+	start := time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local)
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			// send the field mark so every bit other than the flag under test is fixed
+			f := LTCFrame{FramesPerSecond: c.FPS, SendFieldMark: true}
+			f.SetTimeCode(TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4}, start)
+			without := f.EncodeFrame()
+			c.Set(&f)
+			if f.DropFrame {
+				f.SetTimeCode(TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4, DropFrame: true}, start)
+			}
+			with := f.EncodeFrame()
+
+			expected := make([]byte, 10)
+			expected[c.Bit/8] = 0x80 >> uint(c.Bit%8)
+			diff := make([]byte, 10)
+			for i := range diff {
+				diff[i] = with[i] ^ without[i]
+			}
+			if d := deep.Equal(diff, expected); len(d) > 0 {
+				st.Errorf("Expected only bit %d to change, got %x", c.Bit, diff)
+			}
+
+			decoded, err := LTCFrame{FramesPerSecond: c.FPS, SendFieldMark: true, Time: start}.DecodeFrame(with)
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			// user bytes are only decoded with the flags, the remaining fields should round trip
+			decoded.UserBytes, f.UserBytes = nil, nil
+			if d := deep.Equal(decoded, f); len(d) > 0 {
+				st.Error("Decoded frame doesn't match encoded frame:")
+				for _, l := range d {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestFrameFieldMarkParity(t *testing.T) {
+	f := LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30}
+	for f.ParityBit() {
+		f.Time = f.Time.Add(f.FrameDuration())
+	}
+
+	// the field mark replaces phase correction, so setting it leaves the frame with odd parity
+	f.SendFieldMark = true
+	f.FieldMark = true
+	encoded := f.EncodeFrame()
+	if EvenParity(encoded) {
+		t.Fatalf("Expected odd parity with field mark set, got %x", encoded)
+	}
+	if _, err := (LTCFrame{FramesPerSecond: 30}).DecodeFrame(encoded); err != ErrParity {
+		t.Errorf("Expected %v decoding without SendFieldMark, got %v", ErrParity, err)
+	}
+	decoded, err := LTCFrame{FramesPerSecond: 30, SendFieldMark: true}.DecodeFrame(encoded)
+	if err != nil {
+		t.Fatalf("Unable to decode frame: %v", err)
+	}
+	if !decoded.FieldMark {
+		t.Errorf("Expected field mark to be decoded")
+	}
+}
+
+func TestFrameParity(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+		// byte index and mask of the parity bit
+		Index int
+		Mask  byte
+	}{
+		{"24fps", LTCFrame{FramesPerSecond: 24}, 3, 0x10},
+		{"25fps", LTCFrame{FramesPerSecond: 25}, 7, 0x10},
+		{"30fps", LTCFrame{FramesPerSecond: 30}, 3, 0x10},
+		{"30fps/df", LTCFrame{FramesPerSecond: 30, DropFrame: true}, 3, 0x10},
+		{"50fps", LTCFrame{FramesPerSecond: 50}, 3, 0x10},
+	}
+
+	start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := c.Frame
+			f.Time = start
+			var set, clear int
+			for i := 0; i < 100; i++ {
+				encoded := f.EncodeFrame()
+				if !EvenParity(encoded) {
+					st.Fatalf("Encoded frame %x has odd parity", encoded)
+				}
+				if bit := encoded[c.Index]&c.Mask != 0; bit != f.ParityBit() {
+					st.Fatalf("Parity bit in %x is %t, ParityBit() returned %t", encoded, bit, f.ParityBit())
+				}
+				if f.ParityBit() {
+					set++
+				} else {
+					clear++
+				}
+
+				encoded[c.Index] ^= c.Mask
+				if EvenParity(encoded) {
+					st.Fatalf("Frame %x with flipped parity bit has even parity", encoded)
+				}
+				f.Time = f.Time.Add(f.FrameDuration())
+			}
+			if set == 0 || clear == 0 {
+				st.Errorf("Parity bit never changed, set %d times and clear %d times", set, clear)
+			}
+		})
+	}
+}
+
+func TestFrameUserBytesParity25fps(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Flags    BinaryGroupFlags
+		TimeCode TimeCode
+		Expected []byte
+		// bits 27 and 43 carry binary group flags 0 and 2 at 25fps, bit 59 the parity
+		BGF0, BGF2, Parity int
+	}{
+		{"none/even", 0, TimeCode{Hour: 10, Frame: 1}, []byte{0x85, 0x0A, 0x03, 0x0C, 0x01, 0x09, 0x02, 0x87, 0x3F, 0xFD}, 0, 0, 0},
+		{"none/odd", 0, TimeCode{Hour: 10}, []byte{0x05, 0x0A, 0x03, 0x0C, 0x01, 0x09, 0x02, 0x97, 0x3F, 0xFD}, 0, 0, 1},
+		{"bgf0/even", BGF0, TimeCode{Hour: 10}, []byte{0x05, 0x0A, 0x03, 0x1C, 0x01, 0x09, 0x02, 0x87, 0x3F, 0xFD}, 1, 0, 0},
+		{"bgf0/odd", BGF0, TimeCode{Hour: 10, Frame: 1}, []byte{0x85, 0x0A, 0x03, 0x1C, 0x01, 0x09, 0x02, 0x97, 0x3F, 0xFD}, 1, 0, 1},
+		{"bgf2/even", BGF2, TimeCode{Hour: 23, Minute: 59, Second: 59, Frame: 24}, []byte{0x25, 0x4A, 0x93, 0xAC, 0x91, 0xB9, 0xC2, 0x47, 0x3F, 0xFD}, 0, 1, 0},
+		{"bgf2/odd", BGF2, TimeCode{Hour: 10, Frame: 1}, []byte{0x85, 0x0A, 0x03, 0x0C, 0x01, 0x19, 0x02, 0x97, 0x3F, 0xFD}, 0, 1, 1},
+		{"bgf0-bgf2/even", BGF0 | BGF2, TimeCode{Hour: 10, Frame: 1}, []byte{0x85, 0x0A, 0x03, 0x1C, 0x01, 0x19, 0x02, 0x87, 0x3F, 0xFD}, 1, 1, 0},
+		{"bgf0-bgf2/odd", BGF0 | BGF2, TimeCode{Hour: 23, Minute: 59, Second: 59, Frame: 24}, []byte{0x25, 0x4A, 0x93, 0xBC, 0x91, 0xB9, 0xC2, 0x57, 0x3F, 0xFD}, 1, 1, 1},
+	}
+
+	day := time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local)
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := LTCFrame{FramesPerSecond: 25, BinaryGroupFlags: c.Flags, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}}
+			f.SetTimeCode(c.TimeCode, day)
+			encoded := f.EncodeFrame()
+			if diff := deep.Equal(encoded, c.Expected); len(diff) > 0 {
+				st.Errorf("Encoded frame % X doesn't match expected % X", encoded, c.Expected)
+			}
+			bits := []int{frameBit(encoded, 27), frameBit(encoded, 43), frameBit(encoded, 59)}
+			if diff := deep.Equal(bits, []int{c.BGF0, c.BGF2, c.Parity}); len(diff) > 0 {
+				st.Errorf("Expected bits 27, 43 and 59 to be %d, %d and %d, got %v", c.BGF0, c.BGF2, c.Parity, bits)
+			}
+			if f.ParityBit() != (c.Parity == 1) {
+				st.Errorf("ParityBit() returned %t, expected bit 59 to be %d", f.ParityBit(), c.Parity)
+			}
+			if !EvenParity(encoded) {
+				st.Errorf("Encoded frame % X has odd parity", encoded)
+			}
+
+			decoded, err := LTCFrame{FramesPerSecond: 25, Time: day}.DecodeFrame(encoded)
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if decoded.BinaryGroupFlags != c.Flags || *decoded.UserBytes != *f.UserBytes {
+				st.Errorf("Decoded flags %#x and user bytes % X, expected %#x and % X",
+					uint8(decoded.BinaryGroupFlags), *decoded.UserBytes, uint8(c.Flags), *f.UserBytes)
+			}
+		})
+	}
+}
+
+func TestMidnightRollover(t *testing.T) {
+	day := time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local)
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+	}{
+		{"24fps", LTCFrame{FramesPerSecond: 24}},
+		{"25fps", LTCFrame{FramesPerSecond: 25}},
+		{"30fps", LTCFrame{FramesPerSecond: 30}},
+		{"30fps/df", LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+		{"60fps", LTCFrame{FramesPerSecond: 60}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			fps := int(c.Frame.FramesPerSecond)
+			df := c.Frame.DropFrame
+			n := c.Frame.FramesPerDay()
+			expected := []struct {
+				TimeCode   TimeCode
+				FrameIndex int
+			}{
+				{TimeCode{23, 59, 59, fps - 2, df}, n - 2},
+				{TimeCode{23, 59, 59, fps - 1, df}, n - 1},
+				{TimeCode{0, 0, 0, 0, df}, 0},
+				{TimeCode{0, 0, 0, 1, df}, 1},
+			}
+
+			f := c.Frame
+			f.SetTimeCode(expected[0].TimeCode, day)
+			for i, e := range expected {
+				if diff := deep.Equal(f.Frame(), e.TimeCode); len(diff) > 0 {
+					st.Errorf("Frame %d timecode doesn't match expected value:", i)
+					for _, l := range diff {
+						st.Log(l)
+					}
+				}
+				if index := f.FrameIndex(); index != e.FrameIndex {
+					st.Errorf("Frame %d: expected index %d, got %d", i, e.FrameIndex, index)
+				}
+
+				decoded, err := c.Frame.DecodeFrame(f.EncodeFrame())
+				if err != nil {
+					st.Fatalf("Unable to decode frame %d: %v", i, err)
+				}
+				// above 30fps frames are sent in pairs
+				expectedTimeCode := e.TimeCode
+				if fps > 30 {
+					expectedTimeCode.Frame -= expectedTimeCode.Frame % 2
+				}
+				if decoded.Frame() != expectedTimeCode {
+					st.Errorf("Frame %d: encoded as %s, expected %s", i, decoded.Frame(), expectedTimeCode)
+				}
+				f.Time = f.Time.Add(f.FrameDuration())
+			}
+		})
+	}
+
+	// the first frame of the day has only the drop frame flag, sync word and parity bit to set
+	f := LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	f.SetTimeCode(TimeCode{0, 0, 0, 0, true}, day)
+	expected := []byte{0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x3F, 0xFD}
+	if diff := deep.Equal(f.EncodeFrame(), expected); len(diff) > 0 {
+		t.Error("Encoded 00:00:00;00 doesn't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestFrameBeginTime(t *testing.T) {
+	zoneUSCentral, err := time.LoadLocation("US/Central")
+	if err != nil {
+		t.Fatalf("Unable to load US/Central Timezone: %v", err)
+	}
+
+	cases := []struct {
+		Name              string
+		Frame             LTCFrame
+		ExpectedBeginTime time.Time
+	}{
+		{
+			Name:              "midnight",
+			Frame:             LTCFrame{Time: time.Date(2019, 1, 1, 0, 0, 0, 0, zoneUSCentral), FramesPerSecond: 30},
+			ExpectedBeginTime: time.Date(2019, 1, 1, 0, 0, 0, 0, zoneUSCentral),
+		},
 	}
 
 	for _, c := range cases {
@@ -197,3 +1290,574 @@ func TestFrameBeginTime(t *testing.T) {
 		})
 	}
 }
+
+func TestFrameZeroTime(t *testing.T) {
+	zoneUSCentral, err := time.LoadLocation("US/Central")
+	if err != nil {
+		t.Fatalf("Unable to load US/Central Timezone: %v", err)
+	}
+	midnight := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, r := range Rates() {
+		for _, zero := range []time.Time{{}, time.Time{}.In(zoneUSCentral)} {
+			t.Run(fmt.Sprintf("%s/%s", r, zero.Location()), func(st *testing.T) {
+				frame := r.Frame()
+				frame.Time = zero
+				if tc, expected := frame.Frame(), (TimeCode{DropFrame: frame.DropFrame}); tc != expected {
+					st.Errorf("Expected timecode %s, got %s", expected, tc)
+				}
+				if index := frame.FrameIndex(); index != 0 {
+					st.Errorf("Expected frame index 0, got %d", index)
+				}
+				if begin := frame.FrameBeginTime(); !begin.IsZero() {
+					st.Errorf("Expected the zero time as the frame begin time, got %s", begin)
+				}
+
+				reference := r.Frame()
+				reference.Time = midnight
+				if diff := deep.Equal(frame.EncodeFrame(), reference.EncodeFrame()); len(diff) > 0 {
+					st.Errorf("Encoded frame doesn't match 00:00:00:00: %v", diff)
+				}
+			})
+		}
+	}
+}
+
+func TestFrameZeroRate(t *testing.T) {
+	at := time.Date(2018, 12, 1, 23, 14, 21, 500000000, time.Local)
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+	}{
+		{"Unset", LTCFrame{Time: at}},
+		{"DropFrame", LTCFrame{Time: at, DropFrame: true}},
+		{"PullDown", LTCFrame{Time: at, PullDown: true}},
+		{"Fractional", LTCFrame{Time: at, FramesPerSecond: 0.25}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := c.Frame
+			if err := frame.Validate(); err == nil {
+				st.Error("Expected a validation error")
+			}
+			if tc, expected := frame.Frame(), (TimeCode{DropFrame: frame.DropFrame}); tc != expected {
+				st.Errorf("Expected timecode %s, got %s", expected, tc)
+			}
+			if d := frame.FrameDuration(); d != 0 {
+				st.Errorf("Expected no frame duration, got %s", d)
+			}
+			if index := frame.FrameIndex(); index != 0 {
+				st.Errorf("Expected frame index 0, got %d", index)
+			}
+			if n := frame.FramesPerDay(); n != 0 {
+				st.Errorf("Expected no frames per day, got %d", n)
+			}
+			frame.FrameBeginTime()
+			frame.SetTimeCode(TimeCode{Hour: 1}, at)
+
+			buf := make([]byte, FrameBytes)
+			if err := frame.EncodeFrameInto(buf); err != ErrNoFrameRate {
+				st.Errorf("Expected %v encoding the frame, got %v", ErrNoFrameRate, err)
+			}
+			if diff := deep.Equal(frame.EncodeFrame(), buf); len(diff) > 0 {
+				st.Errorf("Frame doesn't encode the same way twice: %v", diff)
+			}
+			decoded, err := LTCFrame{FramesPerSecond: 30}.DecodeFrame(buf)
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if tc, expected := decoded.Frame(), (TimeCode{DropFrame: frame.DropFrame}); tc != expected {
+				st.Errorf("Expected the frame to encode %s, got %s", expected, tc)
+			}
+		})
+	}
+}
+
+func TestFrameDecode(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+	}{
+		{
+			"24fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 900000000, time.Local), FramesPerSecond: 24, ColorFrame: true},
+		},
+		{
+			"25fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 0, 0, 600000000, time.Local), FramesPerSecond: 25, ColorFrame: true},
+		},
+		{
+			"25fps-userdata",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 25, ColorFrame: true, ExternalClockSync: true, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
+		},
+		{
+			"23.976fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 900000000, time.Local), FramesPerSecond: 24, PullDown: true, ColorFrame: true},
+		},
+		{
+			"30fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true},
+		},
+		{
+			"30fps/df",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 0, 0, time.Local), FramesPerSecond: 30, DropFrame: true, ColorFrame: true},
+		},
+		{
+			"50fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 920000000, time.Local), FramesPerSecond: 50, ColorFrame: true},
+		},
+		{
+			"60fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 800000000, time.Local), FramesPerSecond: 60, ColorFrame: true},
+		},
+		{
+			"30fps/df-userdata",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 30, DropFrame: true, ColorFrame: true, ExternalClockSync: true, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
+		},
+		{
+			"30fps-bgf2",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true, BinaryGroupFlags: BGF2, UserBytes: &[4]byte{0x12, 0x34, 0x56, 0x78}},
+		},
+		{
+			"25fps-bgf0-bgf2",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 0, 0, 600000000, time.Local), FramesPerSecond: 25, ColorFrame: true, BinaryGroupFlags: BGF0 | BGF2, UserBytes: &[4]byte{0x12, 0x34, 0x56, 0x78}},
+		},
+		{
+			"30fps/df-userdata-zero",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 9, 59, 966633300, time.Local), FramesPerSecond: 30, DropFrame: true, ColorFrame: true, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{}},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			decoded, err := c.Frame.DecodeFrame(c.Frame.EncodeFrame())
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if diff := deep.Equal(decoded.Frame(), c.Frame.Frame()); len(diff) > 0 {
+				st.Error("Decoded timecode doesn't match original:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+			decoded.Time = c.Frame.Time
+			if diff := deep.Equal(decoded, c.Frame); len(diff) > 0 {
+				st.Error("Decoded frame doesn't match original:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeFrame(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Frame LTCFrame
+	}{
+		{
+			"24fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 900000000, time.Local), FramesPerSecond: 24, ColorFrame: true},
+		},
+		{
+			"30fps",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true},
+		},
+		{
+			"30fps/df-userdata",
+			LTCFrame{Time: time.Date(2018, 12, 1, 23, 40, 21, 0, time.Local), FramesPerSecond: 30, DropFrame: true, ColorFrame: true, ExternalClockSync: true, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
+		},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			decoded, err := DecodeFrame(c.Frame.EncodeFrame())
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if diff := deep.Equal(decoded.Frame(), c.Frame.Frame()); len(diff) > 0 {
+				st.Error("Decoded timecode doesn't match original:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+			// the rate isn't sent, frames are always decoded as 30fps
+			expected := c.Frame
+			expected.FramesPerSecond = 30
+			decoded.Time = expected.Time
+			if diff := deep.Equal(decoded, expected); len(diff) > 0 {
+				st.Error("Decoded frame doesn't match original:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+
+	parity := LTCFrame{FramesPerSecond: 30}.EncodeFrame()
+	parity[2] ^= 0x01
+	if _, err := DecodeFrame(parity); err != ErrParity {
+		t.Errorf("Incorrect error: got '%v' expected '%v'", err, ErrParity)
+	}
+}
+
+func TestFrameDecodeErrors(t *testing.T) {
+	frame := LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30}
+
+	parity := frame.EncodeFrame()
+	parity[2] ^= 0x01
+
+	noSync := frame.EncodeFrame()
+	noSync[9] = 0xFC
+
+	reversed := make([]byte, 10)
+	for i, b := range frame.EncodeFrame() {
+		reversed[9-i] = bits.Reverse8(b)
+	}
+
+	// withParity changes an encoded frame and sets the parity bit again, so the frame only fails the
+	// checks that come after parity
+	withParity := func(f LTCFrame, change func([]byte)) []byte {
+		b := f.EncodeFrame()
+		change(b)
+		if !EvenParity(b) {
+			i, mask := bitPosition(f.flagBits().parity)
+			b[i] ^= mask
+		}
+		return b
+	}
+
+	frameUnits := withParity(frame, func(b []byte) { b[0] |= 0xF0 })
+	hourUnits := withParity(frame, func(b []byte) { b[6] |= 0xF0 })
+	// frame tens are bits 8 and 9, setting both turns frame 9 into frame 39
+	frame39 := LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 300000000, time.Local), FramesPerSecond: 30}
+	frameTens := withParity(frame39, func(b []byte) { b[1] |= 0xC0 })
+	// 00:01:00;00 is never sent at 29.97df, bit 10 is the drop frame flag
+	minute := LTCFrame{Time: time.Date(2018, 12, 1, 0, 1, 0, 0, time.Local), FramesPerSecond: 30}
+	dropped := withParity(minute, func(b []byte) { b[1] |= 0x20 })
+
+	twentyFive := LTCFrame{FramesPerSecond: 25}
+	late := LTCFrame{FramesPerSecond: 30}
+	late.SetTimeCode(TimeCode{Hour: 1, Frame: 27}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	dropFrame := LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	dropFrame.SetTimeCode(TimeCode{Hour: 1, Frame: 3, DropFrame: true}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+
+	testCases := []struct {
+		Name          string
+		Frame         LTCFrame
+		Bytes         []byte
+		ExpectedError error
+	}{
+		{"parity", frame, parity, ErrParity},
+		{"sync", frame, noSync, ErrSyncWord},
+		{"reversed", frame, reversed, ErrReversed},
+		{"frame units", frame, frameUnits, ErrBCD},
+		{"hour units", frame, hourUnits, ErrBCD},
+		{"frame 39", frame, frameTens, ErrInvalidTimeCode},
+		{"dropped frame number", frame, dropped, ErrInvalidTimeCode},
+		{"frame 27 at 25fps", twentyFive, late.EncodeFrame(), ErrInvalidTimeCode},
+		{"drop frame at 25fps", twentyFive, dropFrame.EncodeFrame(), ErrInvalidTimeCode},
+		{"no frame rate", LTCFrame{}, frame.EncodeFrame(), ErrNoFrameRate},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if _, err := c.Frame.DecodeFrame(c.Bytes); err != c.ExpectedError {
+				st.Errorf("Incorrect error: got '%v' expected '%v'", err, c.ExpectedError)
+			}
+		})
+	}
+
+	if _, err := frame.DecodeFrame(make([]byte, 9)); err == nil {
+		t.Errorf("Expected error decoding short frame")
+	}
+}
+
+func TestReverseFrame(t *testing.T) {
+	frame := LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	frame.SetTimeCode(TimeCode{Hour: 10, Minute: 1, Second: 0, Frame: 3, DropFrame: true}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+
+	// play frames backwards, each frame's bits arrive in reverse order with the frames counting down
+	var decoded []TimeCode
+	for i := 0; i < 4; i++ {
+		reversed := ReverseFrame(frame.EncodeFrame())
+		if sync := int(reversed[0])<<8 | int(reversed[1]); sync != reverseSyncBits {
+			t.Fatalf("Expected reversed sync word %#x at start of frame, got %#x", reverseSyncBits, sync)
+		}
+		if _, err := frame.DecodeFrame(reversed); err != ErrReversed {
+			t.Fatalf("Expected %v decoding reversed frame, got %v", ErrReversed, err)
+		}
+
+		f, err := frame.DecodeFrame(ReverseFrame(reversed))
+		if err != nil {
+			t.Fatalf("Unable to decode frame: %v", err)
+		}
+		decoded = append(decoded, f.Frame())
+		frame.Time = frame.Time.Add(-frame.FrameDuration())
+	}
+
+	expected := []TimeCode{
+		{Hour: 10, Minute: 1, Second: 0, Frame: 3, DropFrame: true},
+		{Hour: 10, Minute: 1, Second: 0, Frame: 2, DropFrame: true},
+		{Hour: 10, Minute: 0, Second: 59, Frame: 29, DropFrame: true},
+		{Hour: 10, Minute: 0, Second: 59, Frame: 28, DropFrame: true},
+	}
+	if diff := deep.Equal(decoded, expected); len(diff) > 0 {
+		t.Error("Decoded frames don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestDateUserBits(t *testing.T) {
+	zoneUSCentral, err := time.LoadLocation("US/Central")
+	if err != nil {
+		t.Fatalf("Unable to load US/Central Timezone: %v", err)
+	}
+
+	frame := LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30, ColorFrame: true}
+	frame.SetDateUserBits(time.Date(2019, 3, 3, 12, 0, 0, 0, zoneUSCentral))
+
+	// 2019-03-03 UTC-6: day 0x03, month 0x03, year 0x19, -24 quarter hours 0xE8
+	expectedFrame := []byte{0x03, 0x10, 0x83, 0x50, 0x29, 0x81, 0xC8, 0x5E, 0x3F, 0xFD}
+	if diff := deep.Equal(frame.EncodeFrame(), expectedFrame); len(diff) > 0 {
+		t.Error("Encoded frame doesn't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	date, err := frame.GetDateUserBits()
+	if err != nil {
+		t.Fatalf("Unable to read date from user bits: %v", err)
+	}
+	expectedDate := time.Date(2019, 3, 3, 0, 0, 0, 0, zoneUSCentral)
+	if !date.Equal(expectedDate) {
+		t.Errorf("Incorrect date: got %s expected %s", date, expectedDate)
+	}
+	if _, offset := date.Zone(); offset != -6*3600 {
+		t.Errorf("Incorrect time zone offset: got %d expected %d", offset, -6*3600)
+	}
+
+	errorCases := []struct {
+		Name  string
+		Frame LTCFrame
+	}{
+		{"NoUserBits", LTCFrame{BinaryGroupFlags: BGF2}},
+		{"Characters", LTCFrame{BinaryGroupFlags: BGF0, UserBytes: &[4]byte{0x03, 0x03, 0x19, 0x00}}},
+		{"BCD", LTCFrame{BinaryGroupFlags: BGF2, UserBytes: &[4]byte{0x0A, 0x03, 0x19, 0x00}}},
+		{"Day", LTCFrame{BinaryGroupFlags: BGF2, UserBytes: &[4]byte{0x30, 0x02, 0x19, 0x00}}},
+		{"Month", LTCFrame{BinaryGroupFlags: BGF2, UserBytes: &[4]byte{0x01, 0x13, 0x19, 0x00}}},
+	}
+
+	for _, c := range errorCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if date, err := c.Frame.GetDateUserBits(); err == nil {
+				st.Errorf("Expected error, got %s", date)
+			}
+		})
+	}
+}
+
+func TestEncodeFrameInto(t *testing.T) {
+	start := time.Date(2019, 3, 1, 23, 59, 58, 0, time.UTC)
+	frames := []LTCFrame{
+		{FramesPerSecond: 25, BinaryGroupFlags: BGF0, UserBytes: &[4]byte{0xA5, 0xC3, 0x91, 0x72}},
+		{FramesPerSecond: 30, DropFrame: true, ExternalClockSync: true},
+		{FramesPerSecond: 24, PullDown: true, ColorFrame: true},
+		{FramesPerSecond: 60, SendFieldMark: true, FieldMark: true},
+	}
+
+	for _, f := range frames {
+		t.Run(fmt.Sprintf("%gfps", f.EffectiveFPS()), func(st *testing.T) {
+			// the buffer is dirty and longer than a frame, only the frame is overwritten
+			buf := []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xEE}
+			f.Time = start
+			for i := 0; i < 100; i++ {
+				if err := f.EncodeFrameInto(buf); err != nil {
+					st.Fatalf("Unable to encode frame: %v", err)
+				}
+				if diff := deep.Equal(buf[:FrameBytes], f.EncodeFrame()); len(diff) > 0 {
+					st.Fatalf("Frame %s doesn't match EncodeFrame: %v", f.Frame(), diff)
+				}
+				f.Time = f.Time.Add(f.FrameDuration())
+			}
+			if buf[FrameBytes] != 0xEE {
+				st.Errorf("Byte after the frame was overwritten")
+			}
+
+			allocs := testing.AllocsPerRun(100, func() { f.EncodeFrameInto(buf) })
+			if allocs != 0 {
+				st.Errorf("Expected no allocations, got %f", allocs)
+			}
+		})
+	}
+
+	if err := (LTCFrame{FramesPerSecond: 25}).EncodeFrameInto(make([]byte, FrameBytes-1)); err == nil {
+		t.Errorf("Expected an error encoding into a short buffer")
+	}
+}
+
+// benchmarkFrames are the rates benchmarked, covering the integer, drop frame and pulled down paths
+var benchmarkFrames = []struct {
+	Name  string
+	Frame LTCFrame
+}{
+	{"25", LTCFrame{FramesPerSecond: 25}},
+	{"29.97df", LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+	{"23.976", LTCFrame{FramesPerSecond: 24, PullDown: true}},
+}
+
+// benchmarkTime is part way through a 10 minute drop frame window, late enough in the day that
+// every field of the timecode is non-zero
+var benchmarkTime = time.Date(2019, 3, 1, 13, 47, 21, 123456789, time.UTC)
+
+// results are stored so the compiler can't discard the benchmarked calls
+var (
+	benchmarkEncoded  []byte
+	benchmarkTimeCode TimeCode
+	benchmarkIndex    int
+)
+
+func BenchmarkEncodeFrame(b *testing.B) {
+	for _, c := range benchmarkFrames {
+		b.Run(c.Name, func(sb *testing.B) {
+			f := c.Frame
+			f.Time = benchmarkTime
+			sb.ReportAllocs()
+			for i := 0; i < sb.N; i++ {
+				benchmarkEncoded = f.EncodeFrame()
+			}
+		})
+	}
+}
+
+func BenchmarkEncodeFrameInto(b *testing.B) {
+	for _, c := range benchmarkFrames {
+		b.Run(c.Name, func(sb *testing.B) {
+			f := c.Frame
+			f.Time = benchmarkTime
+			buf := make([]byte, FrameBytes)
+			sb.ReportAllocs()
+			for i := 0; i < sb.N; i++ {
+				f.EncodeFrameInto(buf)
+			}
+		})
+	}
+}
+
+func BenchmarkFrame(b *testing.B) {
+	for _, c := range benchmarkFrames {
+		b.Run(c.Name, func(sb *testing.B) {
+			f := c.Frame
+			f.Time = benchmarkTime
+			sb.ReportAllocs()
+			for i := 0; i < sb.N; i++ {
+				benchmarkTimeCode = f.Frame()
+			}
+		})
+	}
+}
+
+func BenchmarkFrameIndex(b *testing.B) {
+	for _, c := range benchmarkFrames {
+		b.Run(c.Name, func(sb *testing.B) {
+			f := c.Frame
+			f.Time = benchmarkTime
+			sb.ReportAllocs()
+			for i := 0; i < sb.N; i++ {
+				benchmarkIndex = f.FrameIndex()
+			}
+		})
+	}
+}
+
+func BenchmarkDropFrame10MinIndex(b *testing.B) {
+	f := LTCFrame{FramesPerSecond: 30, DropFrame: true, Time: benchmarkTime}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchmarkIndex = f.dropFrame10MinIndex()
+	}
+}
+
+func TestSpecVersion(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		FPS      float64
+		TimeCode TimeCode
+		Flags    BinaryGroupFlags
+		Version  SpecVersion
+		Expected string
+	}{
+		// flags are only assigned differently at 50fps
+		{"25/1999", 25, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4}, 0, SMPTE12M1999, "2000c000400080003ffd"},
+		{"25/2008", 25, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4}, 0, SMPTE12M2008, "2000c000400080003ffd"},
+		{"30/1999", 30, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, 0, SMPTE12M1999, "6000c010400080003ffd"},
+		{"30/2008", 30, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, 0, SMPTE12M2008, "6000c010400080003ffd"},
+		// the phase correction is bit 27 in 1999 and bit 59 in 2008
+		{"50/1999", 50, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, 0, SMPTE12M1999, "c000c010400080003ffd"},
+		{"50/2008", 50, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, 0, SMPTE12M2008, "c000c000400080103ffd"},
+		// binary group flag 0 is bit 43 in 1999 and bit 27 in 2008, clearing the phase correction
+		{"50/1999/BGF0", 50, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, BGF0, SMPTE12M1999, "c000c000401080003ffd"},
+		{"50/2008/BGF0", 50, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, BGF0, SMPTE12M2008, "c000c010400080003ffd"},
+		{"60/2008", 60, TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 6}, 0, SMPTE12M2008, "c000c010400080003ffd"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := LTCFrame{
+				FramesPerSecond:  c.FPS,
+				BinaryGroupFlags: c.Flags,
+				SpecVersion:      c.Version,
+				Time:             time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC),
+			}
+			if c.Flags != 0 {
+				f.UserBytes = &[4]byte{}
+			}
+			binaryFrame, err := f.EncodeTimeCode(c.TimeCode)
+			if err != nil {
+				st.Fatalf("Unable to encode frame: %v", err)
+			}
+			if encoded := fmt.Sprintf("%x", binaryFrame); encoded != c.Expected {
+				st.Errorf("Expected frame %s, got %s", c.Expected, encoded)
+			}
+
+			decoded, err := f.DecodeFrame(binaryFrame)
+			if err != nil {
+				st.Fatalf("Unable to decode frame: %v", err)
+			}
+			if decoded.Frame() != c.TimeCode || decoded.BinaryGroupFlags != c.Flags || decoded.SpecVersion != c.Version {
+				st.Errorf("Expected %s with flags %#x, got %s with flags %#x", c.TimeCode, c.Flags, decoded.Frame(), decoded.BinaryGroupFlags)
+			}
+		})
+	}
+}
+
+func TestParseSpecVersion(t *testing.T) {
+	testCases := []struct {
+		Value       string
+		Expected    SpecVersion
+		ExpectError bool
+	}{
+		{"1986", SMPTE12M1999, false},
+		{"1999", SMPTE12M1999, false},
+		{"2008", SMPTE12M2008, false},
+		{"2014", 0, true},
+		{"", 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Value, func(st *testing.T) {
+			v, err := ParseSpecVersion(c.Value)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if v != c.Expected {
+				st.Errorf("Expected %s, got %s", c.Expected, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package glitc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UserBitsProvider supplies the user bits of each frame as it is encoded.  A frame may be encoded
+// more than once, so providers must return the same bits when called again with the same timecode.
+type UserBitsProvider interface {
+	UserBits(tc TimeCode) [4]byte
+}
+
+// UserBitsFunc adapts a function to a UserBitsProvider
+type UserBitsFunc func(tc TimeCode) [4]byte
+
+// UserBits calls fn
+func (fn UserBitsFunc) UserBits(tc TimeCode) [4]byte {
+	return fn(tc)
+}
+
+// StaticUserBits sends the same user bits in every frame
+type StaticUserBits [4]byte
+
+// UserBits returns the static bits
+func (b StaticUserBits) UserBits(tc TimeCode) [4]byte {
+	return b
+}
+
+// ParseUserBytes parses user bits given as exactly 8 hex digits, e.g. A5C39172.  The first byte is
+// sent in groups 1 and 2, with group 1 in its low nibble, as with LTCFrame.UserBytes.
+func ParseUserBytes(s string) ([4]byte, error) {
+	var b [4]byte
+	if len(s) != 2*len(b) {
+		return b, fmt.Errorf("user bytes %q must be exactly 8 hex digits", s)
+	}
+	if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+		return b, fmt.Errorf("invalid user bytes %q: %v", s, err)
+	}
+	return b, nil
+}
+
+// maxCounter is the largest count that fits in the 8 user bit groups as BCD
+const maxCounter = 99999999
+
+// CounterUserBits counts frames in the user bits, e.g. as a take number or clip ID.  The count is
+// sent as 8 BCD digits so readers displaying the user bits show it in decimal, with the ones in
+// group 1.  The count advances each time a new timecode is encoded.
+type CounterUserBits struct {
+	mu      sync.Mutex
+	start   uint32
+	wrap    uint32
+	count   uint32
+	last    TimeCode
+	started bool
+}
+
+// NewCounterUserBits returns a counter starting from start.  After wrap the count starts again from
+// start, a wrap of 0 counts up to 99999999.
+func NewCounterUserBits(start uint32, wrap uint32) *CounterUserBits {
+	if wrap == 0 || wrap > maxCounter {
+		wrap = maxCounter
+	}
+	return &CounterUserBits{start: start, wrap: wrap}
+}
+
+// Reset starts counting from the start value again with the next frame
+func (c *CounterUserBits) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = false
+}
+
+// UserBits returns the count for tc, advancing it if tc differs from the previous timecode
+func (c *CounterUserBits) UserBits(tc TimeCode) [4]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case !c.started:
+		c.count = c.start
+		c.started = true
+	case tc != c.last:
+		c.count++
+		if c.count > c.wrap {
+			c.count = c.start
+		}
+	}
+	c.last = tc
+
+	var b [4]byte
+	n := int(c.count)
+	for i := range b {
+		tens, ones := asBCD(n % 100)
+		b[i] = byte(tens<<4 | ones)
+		n /= 100
+	}
+	return b
+}
+
+// DateUserBits sends the date in the user bits as SetDateUserBits does, moving on to the next day
+// when the timecode passes midnight.  Timecode must run forwards.  Frames using it should have
+// BinaryGroupFlags set to BGF2.
+type DateUserBits struct {
+	mu   sync.Mutex
+	date time.Time
+	last TimeCode
+}
+
+// NewDateUserBits returns a provider sending the date of t, in t's time zone
+func NewDateUserBits(t time.Time) *DateUserBits {
+	return &DateUserBits{date: t}
+}
+
+// UserBits returns the date of the frame with timecode tc
+func (d *DateUserBits) UserBits(tc TimeCode) [4]byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if tc.Hour < d.last.Hour {
+		d.date = d.date.AddDate(0, 0, 1)
+	}
+	d.last = tc
+	return dateUserBytes(d.date)
+}
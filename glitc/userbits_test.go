@@ -0,0 +1,150 @@
+package glitc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+)
+
+// sendFrames encodes n consecutive frames starting at tc and returns the user bits decoded from each
+func sendFrames(t *testing.T, f LTCFrame, tc TimeCode, n int) [][4]byte {
+	f.SetTimeCode(tc, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	var userBytes [][4]byte
+	for i := 0; i < n; i++ {
+		decoded, err := f.DecodeFrame(f.EncodeFrame())
+		if err != nil {
+			t.Fatalf("Unable to decode frame %d: %v", i, err)
+		}
+		var b [4]byte
+		if decoded.UserBytes != nil {
+			b = *decoded.UserBytes
+		}
+		userBytes = append(userBytes, b)
+		f.Time = f.Time.Add(f.FrameDuration())
+	}
+	return userBytes
+}
+
+func TestCounterUserBits(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Start    uint32
+		Wrap     uint32
+		Expected [][4]byte
+	}{
+		{"Count", 0, 0, [][4]byte{{0x00}, {0x01}, {0x02}, {0x03}, {0x04}}},
+		{"Start", 98, 0, [][4]byte{{0x98}, {0x99}, {0x00, 0x01}, {0x01, 0x01}, {0x02, 0x01}}},
+		{"Wrap", 1, 3, [][4]byte{{0x01}, {0x02}, {0x03}, {0x01}, {0x02}}},
+		{"Max", 99999998, 0, [][4]byte{{0x98, 0x99, 0x99, 0x99}, {0x99, 0x99, 0x99, 0x99}, {0x98, 0x99, 0x99, 0x99}, {0x99, 0x99, 0x99, 0x99}, {0x98, 0x99, 0x99, 0x99}}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			f := LTCFrame{FramesPerSecond: 25, UserBits: NewCounterUserBits(c.Start, c.Wrap)}
+			userBytes := sendFrames(st, f, TimeCode{Hour: 10, Frame: 20}, len(c.Expected))
+			if diff := deep.Equal(userBytes, c.Expected); len(diff) > 0 {
+				st.Error("User bits don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestCounterUserBitsRepeatAndReset(t *testing.T) {
+	c := NewCounterUserBits(5, 0)
+	tc := TimeCode{Hour: 10}
+
+	// encoding the same frame again doesn't advance the count
+	if b := c.UserBits(tc); b != [4]byte{0x05} {
+		t.Errorf("Expected count 5, got %x", b)
+	}
+	if b := c.UserBits(tc); b != [4]byte{0x05} {
+		t.Errorf("Expected repeated frame to keep count 5, got %x", b)
+	}
+	if b := c.UserBits(tc.Add(1, 30)); b != [4]byte{0x06} {
+		t.Errorf("Expected count 6, got %x", b)
+	}
+
+	c.Reset()
+	if b := c.UserBits(tc.Add(2, 30)); b != [4]byte{0x05} {
+		t.Errorf("Expected count 5 after reset, got %x", b)
+	}
+}
+
+func TestDateUserBitsProvider(t *testing.T) {
+	date := time.Date(2018, 12, 31, 23, 59, 59, 0, time.FixedZone("", 3600))
+	f := LTCFrame{FramesPerSecond: 30, BinaryGroupFlags: BGF2, UserBits: NewDateUserBits(date)}
+	userBytes := sendFrames(t, f, TimeCode{Hour: 23, Minute: 59, Second: 59, Frame: 29}, 2)
+
+	// the date moves on at midnight
+	expected := [][4]byte{{0x31, 0x12, 0x18, 0x04}, {0x01, 0x01, 0x19, 0x04}}
+	if diff := deep.Equal(userBytes, expected); len(diff) > 0 {
+		t.Error("User bits don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestStaticUserBits(t *testing.T) {
+	f := LTCFrame{FramesPerSecond: 30, UserBytes: &[4]byte{0xFF}, UserBits: StaticUserBits{0xA5, 0xC3, 0x91, 0x72}}
+	userBytes := sendFrames(t, f, TimeCode{Hour: 1}, 2)
+
+	// the provider takes precedence over UserBytes
+	expected := [][4]byte{{0xA5, 0xC3, 0x91, 0x72}, {0xA5, 0xC3, 0x91, 0x72}}
+	if diff := deep.Equal(userBytes, expected); len(diff) > 0 {
+		t.Error("User bits don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestParseUserBytes(t *testing.T) {
+	testCases := []struct {
+		Value       string
+		Expected    [4]byte
+		ExpectError bool
+	}{
+		{"A5C39172", [4]byte{0xA5, 0xC3, 0x91, 0x72}, false},
+		{"a5c39172", [4]byte{0xA5, 0xC3, 0x91, 0x72}, false},
+		{"00000000", [4]byte{}, false},
+		{"", [4]byte{}, true},
+		{"A5C391", [4]byte{}, true},
+		{"A5C3917200", [4]byte{}, true},
+		{"A5C3917G", [4]byte{}, true},
+		{"0xA5C391", [4]byte{}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Value, func(st *testing.T) {
+			b, err := ParseUserBytes(c.Value)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if err == nil && b != c.Expected {
+				st.Errorf("Expected %x, got %x", c.Expected, b)
+			}
+		})
+	}
+}
+
+func TestSetUserBytes(t *testing.T) {
+	f := LTCFrame{FramesPerSecond: 25, UserBits: NewDateUserBits(time.Now())}
+	f.SetDateUserBits(time.Now())
+	f.SetUserBytes([4]byte{0xA5, 0xC3, 0x91, 0x72})
+	if f.BinaryGroupFlags != 0 || f.UserBits != nil {
+		t.Errorf("Expected user bytes to replace the date, got flags %#x and provider %v", f.BinaryGroupFlags, f.UserBits)
+	}
+
+	userBytes := sendFrames(t, f, TimeCode{Hour: 1}, 1)
+	if diff := deep.Equal(userBytes, [][4]byte{{0xA5, 0xC3, 0x91, 0x72}}); len(diff) > 0 {
+		t.Error("User bits don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthChecker decides from Status whether ltcgen is sending good timecode, for liveness and
+// readiness probes
+type healthChecker struct {
+	status *Status
+	now    func() time.Time
+	// maxAge is the longest time since the last frame was sent
+	maxAge time.Duration
+	// maxErrorRate is the largest fraction of recent frames that may be dropped, duplicated or sent
+	// with a large offset
+	maxErrorRate float64
+	// window is how long frame errors are counted for
+	window time.Duration
+
+	mu       sync.Mutex
+	baseline StatusSnapshot
+	previous StatusSnapshot
+	rolled   time.Time
+}
+
+func newHealthChecker(status *Status) *healthChecker {
+	return &healthChecker{
+		status:       status,
+		now:          time.Now,
+		maxAge:       time.Second,
+		maxErrorRate: 0.01,
+		window:       time.Minute,
+	}
+}
+
+// Check returns nil if the audio device is open, a frame was sent recently and few of the frames
+// sent over the last one to two windows had errors
+func (h *healthChecker) Check() error {
+	now := h.now()
+	s := h.status.Snapshot()
+
+	h.mu.Lock()
+	if h.rolled.IsZero() {
+		h.rolled = now
+	} else if now.Sub(h.rolled) >= h.window {
+		h.baseline = h.previous
+		h.previous = s
+		h.rolled = now
+	}
+	baseline := h.baseline
+	h.mu.Unlock()
+
+	if !s.DeviceOpen {
+		return fmt.Errorf("audio device isn't open")
+	}
+	if s.LastSent.IsZero() {
+		return fmt.Errorf("no frames sent yet")
+	}
+	if age := now.Sub(s.LastSent); age > h.maxAge {
+		return fmt.Errorf("last frame sent %s ago", age)
+	}
+	sent := s.Sent - baseline.Sent
+	errors := s.Dropped + s.Duplicate + s.LargeOffset - baseline.Dropped - baseline.Duplicate - baseline.LargeOffset
+	if sent > 0 && float64(errors)/float64(sent) > h.maxErrorRate {
+		return fmt.Errorf("%d frame errors in the last %d frames", errors, sent)
+	}
+	return nil
+}
+
+// ServeHTTP responds 200 when healthy and 503 with the reason otherwise
+func (h *healthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.Check(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthChecker(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Setup    func(*Status)
+		Elapsed  time.Duration
+		Expected int
+	}{
+		{"Healthy", func(s *Status) {
+			s.SetDeviceOpen(true)
+			s.Sent(100 * time.Microsecond)
+		}, 0, 200},
+		{"DeviceClosed", func(s *Status) {
+			s.Sent(100 * time.Microsecond)
+		}, 0, 503},
+		{"NoFrames", func(s *Status) {
+			s.SetDeviceOpen(true)
+		}, 0, 503},
+		{"Stalled", func(s *Status) {
+			s.SetDeviceOpen(true)
+			s.Sent(100 * time.Microsecond)
+		}, 2 * time.Second, 503},
+		{"LargeOffset", func(s *Status) {
+			s.SetDeviceOpen(true)
+			s.Sent(2 * time.Millisecond)
+		}, 0, 503},
+		{"Dropped", func(s *Status) {
+			s.SetDeviceOpen(true)
+			for i := 0; i < 50; i++ {
+				s.Sent(100 * time.Microsecond)
+			}
+			s.Dropped(1)
+		}, 0, 503},
+		{"FewDropped", func(s *Status) {
+			s.SetDeviceOpen(true)
+			for i := 0; i < 200; i++ {
+				s.Sent(100 * time.Microsecond)
+			}
+			s.Dropped(1)
+		}, 0, 200},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			status := NewStatus(10)
+			c.Setup(status)
+			checker := newHealthChecker(status)
+			checker.now = func() time.Time { return time.Now().Add(c.Elapsed) }
+
+			recorder := httptest.NewRecorder()
+			checker.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+			if recorder.Code != c.Expected {
+				st.Errorf("Expected status %d, got %d: %s", c.Expected, recorder.Code, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestHealthCheckerRecovers(t *testing.T) {
+	status := NewStatus(10)
+	status.SetDeviceOpen(true)
+	status.Sent(100 * time.Microsecond)
+	status.Dropped(5)
+
+	now := time.Now()
+	checker := newHealthChecker(status)
+	checker.now = func() time.Time { return now }
+	if err := checker.Check(); err == nil {
+		t.Fatalf("Expected frame errors to be unhealthy")
+	}
+
+	// errors stop counting once two windows of good frames have been sent
+	for i := 0; i < 2; i++ {
+		now = now.Add(checker.window)
+		status.Sent(100 * time.Microsecond)
+		checker.Check()
+	}
+	status.Sent(100 * time.Microsecond)
+	checker.now = func() time.Time { return time.Now() }
+	if err := checker.Check(); err != nil {
+		t.Errorf("Expected to recover once errors were older than the window: %v", err)
+	}
+}
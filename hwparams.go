@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// sizeRange is the range of sizes, in sample frames, an ALSA device supports
+type sizeRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether size is within the range
+func (r sizeRange) Contains(size int) bool {
+	return size >= r.Min && size <= r.Max
+}
+
+func (r sizeRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// hwParams are the period and buffer sizes a device supports, as dumped by aplay --dump-hw-params
+type hwParams struct {
+	PeriodSize sizeRange
+	BufferSize sizeRange
+}
+
+// parseSizeRange parses a value from aplay's hardware parameter dump, either a single size or an
+// interval such as [32 16384] or (15 16384], where ( and ) exclude the bound
+func parseSizeRange(value string) (sizeRange, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.Atoi(value); err == nil {
+		return sizeRange{n, n}, nil
+	}
+	if len(value) < 2 {
+		return sizeRange{}, fmt.Errorf("unexpected size %q", value)
+	}
+
+	fields := strings.Fields(value[1 : len(value)-1])
+	if len(fields) != 2 {
+		return sizeRange{}, fmt.Errorf("unexpected size range %q", value)
+	}
+	var r sizeRange
+	var err error
+	if r.Min, err = strconv.Atoi(fields[0]); err != nil {
+		return sizeRange{}, fmt.Errorf("unexpected size range %q: %v", value, err)
+	}
+	if r.Max, err = strconv.Atoi(fields[1]); err != nil {
+		return sizeRange{}, fmt.Errorf("unexpected size range %q: %v", value, err)
+	}
+	if value[0] == '(' {
+		r.Min++
+	}
+	if value[len(value)-1] == ')' {
+		r.Max--
+	}
+	return r, nil
+}
+
+// parseHWParams reads the period and buffer size ranges from the output of aplay --dump-hw-params,
+// where each parameter is on a line like "PERIOD_SIZE: [16 16384]"
+func parseHWParams(r io.Reader) (hwParams, error) {
+	var params hwParams
+	var havePeriod, haveBuffer bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		var err error
+		switch strings.TrimSpace(fields[0]) {
+		case "PERIOD_SIZE":
+			params.PeriodSize, err = parseSizeRange(fields[1])
+			havePeriod = true
+		case "BUFFER_SIZE":
+			params.BufferSize, err = parseSizeRange(fields[1])
+			haveBuffer = true
+		}
+		if err != nil {
+			return hwParams{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return hwParams{}, err
+	}
+	if !havePeriod || !haveBuffer {
+		return hwParams{}, errors.New("no period and buffer sizes in hardware parameters")
+	}
+	return params, nil
+}
+
+// queryHWParams asks aplay for the period and buffer sizes supported by the named device when playing
+// with config's rate and channels.  aplay plays nothing as its input is empty.
+func queryHWParams(device string, config pipeConfig) (hwParams, error) {
+	cmd := exec.Command(aplayPath,
+		"-D", device,
+		"--dump-hw-params",
+		"-t", "raw",
+		"-f", "S32_LE",
+		fmt.Sprintf("-r%d", config.SampleRate),
+		fmt.Sprintf("-c%d", config.Channels),
+	)
+	cmd.Stdin = bytes.NewReader(nil)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return hwParams{}, fmt.Errorf("unable to query hardware parameters of %s: %v", device, err)
+	}
+	return parseHWParams(bytes.NewReader(out))
+}
+
+// checkBufferSizes checks a requested period and buffer size, either of which may be 0 to leave it to
+// the device.  ALSA needs at least two periods in the buffer.
+func checkBufferSizes(periodSize, bufferSize int) error {
+	switch {
+	case periodSize < 0:
+		return fmt.Errorf("period size must be positive, got %d", periodSize)
+	case bufferSize < 0:
+		return fmt.Errorf("buffer size must be positive, got %d", bufferSize)
+	case periodSize > 0 && bufferSize > 0 && bufferSize < 2*periodSize:
+		return fmt.Errorf("buffer size %d must hold at least two periods of %d", bufferSize, periodSize)
+	}
+	return nil
+}
+
+// Check returns an error if the device doesn't support a requested period or buffer size
+func (p hwParams) Check(periodSize, bufferSize int) error {
+	if periodSize > 0 && !p.PeriodSize.Contains(periodSize) {
+		return fmt.Errorf("period size %d isn't supported by the device, expected %s", periodSize, p.PeriodSize)
+	}
+	if bufferSize > 0 && !p.BufferSize.Contains(bufferSize) {
+		return fmt.Errorf("buffer size %d isn't supported by the device, expected %s", bufferSize, p.BufferSize)
+	}
+	return nil
+}
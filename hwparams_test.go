@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+// hwParamsDump is an excerpt of aplay --dump-hw-params for a USB interface
+const hwParamsDump = `Playing raw data 'stdin' : Signed 32 bit Little Endian, Rate 48000 Hz, Stereo
+HW Params of device "plughw:1,0":
+--------------------
+ACCESS:  MMAP_INTERLEAVED RW_INTERLEAVED
+FORMAT:  S32_LE
+CHANNELS: 2
+RATE: 48000
+PERIOD_TIME: (666 1000000]
+PERIOD_SIZE: [32 48000]
+PERIOD_BYTES: [256 384000]
+PERIODS: [2 1024]
+BUFFER_TIME: (1333 2000000]
+BUFFER_SIZE: [64 96000]
+BUFFER_BYTES: [512 768000]
+TICK_TIME: ALL
+--------------------
+`
+
+func TestParseHWParams(t *testing.T) {
+	params, err := parseHWParams(strings.NewReader(hwParamsDump))
+	if err != nil {
+		t.Fatalf("Unable to parse hardware parameters: %v", err)
+	}
+	expected := hwParams{PeriodSize: sizeRange{32, 48000}, BufferSize: sizeRange{64, 96000}}
+	if diff := deep.Equal(params, expected); len(diff) > 0 {
+		t.Errorf("Hardware parameters don't match: %v", diff)
+	}
+
+	if _, err := parseHWParams(strings.NewReader("PERIOD_SIZE: [32 48000]\n")); err == nil {
+		t.Errorf("Expected an error without a buffer size")
+	}
+}
+
+func TestParseSizeRange(t *testing.T) {
+	testCases := []struct {
+		Value       string
+		Expected    sizeRange
+		ExpectError bool
+	}{
+		{" [32 48000]", sizeRange{32, 48000}, false},
+		{" (31 48001)", sizeRange{32, 48000}, false},
+		{" 1024", sizeRange{1024, 1024}, false},
+		{" [32]", sizeRange{}, true},
+		{" [a 48000]", sizeRange{}, true},
+		{"", sizeRange{}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Value, func(st *testing.T) {
+			r, err := parseSizeRange(c.Value)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if r != c.Expected {
+				st.Errorf("Expected %s, got %s", c.Expected, r)
+			}
+		})
+	}
+}
+
+func TestBufferSizes(t *testing.T) {
+	params := hwParams{PeriodSize: sizeRange{32, 4096}, BufferSize: sizeRange{64, 8192}}
+	testCases := []struct {
+		Name        string
+		PeriodSize  int
+		BufferSize  int
+		ExpectError bool
+	}{
+		{"Default", 0, 0, false},
+		{"Both", 256, 1024, false},
+		{"PeriodOnly", 4096, 0, false},
+		{"BufferOnly", 0, 64, false},
+		{"OnePeriod", 512, 512, true},
+		{"Negative", -1, 0, true},
+		{"PeriodTooSmall", 16, 0, true},
+		{"BufferTooLarge", 0, 16384, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			err := checkBufferSizes(c.PeriodSize, c.BufferSize)
+			if err == nil {
+				err = params.Check(c.PeriodSize, c.BufferSize)
+			}
+			if (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryHWParams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// stand in for aplay, recording its arguments and dumping the hardware parameters to stderr
+	args := filepath.Join(dir, "args")
+	script := filepath.Join(dir, "aplay")
+	contents := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\ncat >&2 <<'EOF'\n%sEOF\n", args, hwParamsDump)
+	if err := ioutil.WriteFile(script, []byte(contents), 0755); err != nil {
+		t.Fatalf("Unable to write fake aplay: %v", err)
+	}
+	defer func(path string) { aplayPath = path }(aplayPath)
+	aplayPath = script
+
+	params, err := queryHWParams("plughw:1,0", pipeConfig{SampleRate: 48000, Channels: 2})
+	if err != nil {
+		t.Fatalf("Unable to query hardware parameters: %v", err)
+	}
+	if params.PeriodSize != (sizeRange{32, 48000}) {
+		t.Errorf("Expected period sizes 32-48000, got %s", params.PeriodSize)
+	}
+
+	used, err := ioutil.ReadFile(args)
+	if err != nil {
+		t.Fatalf("Unable to read aplay arguments: %v", err)
+	}
+	if expected := "-D plughw:1,0 --dump-hw-params -t raw -f S32_LE -r48000 -c2\n"; string(used) != expected {
+		t.Errorf("Expected aplay arguments %q, got %q", expected, used)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// aplayPath is the ALSA playback client used to reach IEC958 devices and devices selected with -device
+var aplayPath = "aplay"
+
+// IEC958 channel status bits, numbered as in alsa/asoundef.h
+const (
+	iec958Professional = 1 << 0
+
+	iec958ConNotCopyright = 1 << 2
+	iec958ConOriginal     = 1 << 7
+	iec958ConPCMCoder     = 0x02
+
+	iec958ProEmphasisNone = 1 << 2
+	iec958ProModeTwo      = 8 << 0
+)
+
+// iec958ConsumerRates maps sample rates to the consumer sample frequency code in channel status byte 3
+var iec958ConsumerRates = map[int]byte{
+	32000:  3,
+	44100:  0,
+	48000:  2,
+	88200:  8,
+	96000:  10,
+	176400: 12,
+	192000: 14,
+}
+
+// iec958ProfessionalRates maps sample rates to the professional sample frequency code in channel
+// status byte 0, only the base rates can be indicated
+var iec958ProfessionalRates = map[int]byte{
+	32000: 3 << 6,
+	44100: 1 << 6,
+	48000: 2 << 6,
+}
+
+// IEC958Status describes the channel status sent alongside the samples on an AES3 or S/PDIF output.
+// The non audio bit is always clear, LTC is carried as ordinary PCM.
+type IEC958Status struct {
+	Professional bool
+	SampleRate   int
+}
+
+// Bytes returns channel status bytes 0 to 3, AES0 to AES3 in ALSA's terms
+func (s IEC958Status) Bytes() ([4]byte, error) {
+	if s.Professional {
+		fs, ok := iec958ProfessionalRates[s.SampleRate]
+		if !ok {
+			return [4]byte{}, fmt.Errorf("sample rate %d can't be indicated in professional channel status", s.SampleRate)
+		}
+		return [4]byte{iec958Professional | iec958ProEmphasisNone | fs, iec958ProModeTwo, 0, 0}, nil
+	}
+
+	fs, ok := iec958ConsumerRates[s.SampleRate]
+	if !ok {
+		return [4]byte{}, fmt.Errorf("sample rate %d can't be indicated in consumer channel status", s.SampleRate)
+	}
+	return [4]byte{iec958ConNotCopyright, iec958ConOriginal | iec958ConPCMCoder, 0, fs}, nil
+}
+
+// DeviceName returns the name of the ALSA iec958 device on card with this channel status.  The
+// device converts PCM to IEC958 subframes and sets the channel status bits.
+func (s IEC958Status) DeviceName(card string) (string, error) {
+	b, err := s.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("iec958:CARD=%s,AES0=0x%02x,AES1=0x%02x,AES2=0x%02x,AES3=0x%02x", card, b[0], b[1], b[2], b[3]), nil
+}
+
+// IEC958Config describes the digital output LTC is played through
+type IEC958Config struct {
+	Card    string
+	Status  IEC958Status
+	Latency time.Duration
+	// PeriodSize and BufferSize are in sample frames, see pipeConfig
+	PeriodSize int
+	BufferSize int
+}
+
+// OpenIEC958Device plays LTC on both channels of an AES3 or S/PDIF output through aplay, feeding a
+// digital audio matrix without going through an analogue stage
+func OpenIEC958Device(ctx context.Context, config IEC958Config) (*pipeDevice, error) {
+	device, err := config.Status.DeviceName(config.Card)
+	if err != nil {
+		return nil, err
+	}
+	return openAplayDevice(ctx, device, pipeConfig{
+		SampleRate: config.Status.SampleRate,
+		Channels:   2,
+		Latency:    config.Latency,
+		PeriodSize: config.PeriodSize,
+		BufferSize: config.BufferSize,
+	})
+}
+
+// openAplayDevice plays samples on the named ALSA device through aplay, buffering config.Latency or
+// config.BufferSize sample frames
+func openAplayDevice(ctx context.Context, device string, config pipeConfig) (*pipeDevice, error) {
+	if config.Latency < time.Millisecond {
+		return nil, fmt.Errorf("latency must be at least 1ms, got %s", config.Latency)
+	}
+	if err := checkBufferSizes(config.PeriodSize, config.BufferSize); err != nil {
+		return nil, err
+	}
+	args := []string{
+		"-q",
+		"-D", device,
+		"-t", "raw",
+		"-f", "S32_LE",
+		fmt.Sprintf("-r%d", config.SampleRate),
+		fmt.Sprintf("-c%d", config.Channels),
+	}
+	if config.BufferSize > 0 {
+		args = append(args, fmt.Sprintf("--buffer-size=%d", config.BufferSize))
+	} else {
+		args = append(args, fmt.Sprintf("--buffer-time=%d", config.Latency/time.Microsecond))
+	}
+	if config.PeriodSize > 0 {
+		args = append(args, fmt.Sprintf("--period-size=%d", config.PeriodSize))
+	}
+	return openPipeDevice(ctx, config, aplayPath, args)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/go-test/deep"
+)
+
+func TestIEC958Status(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Status      IEC958Status
+		Expected    [4]byte
+		ExpectError bool
+	}{
+		{"Consumer/48k", IEC958Status{SampleRate: 48000}, [4]byte{0x04, 0x82, 0x00, 0x02}, false},
+		{"Consumer/44.1k", IEC958Status{SampleRate: 44100}, [4]byte{0x04, 0x82, 0x00, 0x00}, false},
+		{"Consumer/96k", IEC958Status{SampleRate: 96000}, [4]byte{0x04, 0x82, 0x00, 0x0A}, false},
+		{"Consumer/22.05k", IEC958Status{SampleRate: 22050}, [4]byte{}, true},
+		{"Professional/48k", IEC958Status{Professional: true, SampleRate: 48000}, [4]byte{0x85, 0x08, 0x00, 0x00}, false},
+		{"Professional/44.1k", IEC958Status{Professional: true, SampleRate: 44100}, [4]byte{0x45, 0x08, 0x00, 0x00}, false},
+		{"Professional/96k", IEC958Status{Professional: true, SampleRate: 96000}, [4]byte{}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			b, err := c.Status.Bytes()
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if diff := deep.Equal(b, c.Expected); len(diff) > 0 {
+				st.Error("Channel status doesn't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestIEC958Device(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// stand in for aplay, recording its arguments and the samples it is sent
+	output := filepath.Join(dir, "samples")
+	script := filepath.Join(dir, "aplay")
+	contents := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s.args\ncat > %s\n", output, output)
+	if err := ioutil.WriteFile(script, []byte(contents), 0755); err != nil {
+		t.Fatalf("Unable to write fake aplay: %v", err)
+	}
+	defer func(path string) { aplayPath = path }(aplayPath)
+	aplayPath = script
+
+	d, err := OpenIEC958Device(context.Background(), IEC958Config{
+		Card:    "1",
+		Status:  IEC958Status{Professional: true, SampleRate: 48000},
+		Latency: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Unable to open IEC958 device: %v", err)
+	}
+
+	d.Stream() <- []stream.Sample{0x12345678}
+	close(d.Stream())
+	for err := range d.Done() {
+		if err != nil {
+			t.Fatalf("Error playing samples: %v", err)
+		}
+	}
+
+	args, err := ioutil.ReadFile(output + ".args")
+	if err != nil {
+		t.Fatalf("Unable to read aplay arguments: %v", err)
+	}
+	expectedArgs := "-q -D iec958:CARD=1,AES0=0x85,AES1=0x08,AES2=0x00,AES3=0x00 -t raw -f S32_LE -r48000 -c2 --buffer-time=20000\n"
+	if string(args) != expectedArgs {
+		t.Errorf("Expected aplay arguments %q, got %q", expectedArgs, args)
+	}
+
+	// the sample is sent on both channels
+	samples, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Unable to read samples: %v", err)
+	}
+	expected := []byte{0x78, 0x56, 0x34, 0x12, 0x78, 0x56, 0x34, 0x12}
+	if diff := deep.Equal(samples, expected); len(diff) > 0 {
+		t.Error("Samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestAplayDeviceBufferSizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	args := filepath.Join(dir, "args")
+	script := filepath.Join(dir, "aplay")
+	contents := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\ncat > /dev/null\n", args)
+	if err := ioutil.WriteFile(script, []byte(contents), 0755); err != nil {
+		t.Fatalf("Unable to write fake aplay: %v", err)
+	}
+	defer func(path string) { aplayPath = path }(aplayPath)
+	aplayPath = script
+
+	d, err := openAplayDevice(context.Background(), "plughw:1,0", pipeConfig{
+		SampleRate: 48000,
+		Channels:   1,
+		Latency:    50 * time.Millisecond,
+		PeriodSize: 256,
+		BufferSize: 1024,
+	})
+	if err != nil {
+		t.Fatalf("Unable to open aplay device: %v", err)
+	}
+	close(d.Stream())
+	for err := range d.Done() {
+		if err != nil {
+			t.Fatalf("Error playing samples: %v", err)
+		}
+	}
+
+	used, err := ioutil.ReadFile(args)
+	if err != nil {
+		t.Fatalf("Unable to read aplay arguments: %v", err)
+	}
+	expected := "-q -D plughw:1,0 -t raw -f S32_LE -r48000 -c1 --buffer-size=1024 --period-size=256\n"
+	if string(used) != expected {
+		t.Errorf("Expected aplay arguments %q, got %q", expected, used)
+	}
+	// 1024 frames at 48kHz
+	if delay := d.OutputDelay(); delay != 21333333*time.Nanosecond {
+		t.Errorf("Expected an output delay of 21.333333ms from the buffer size, got %s", delay)
+	}
+
+	if _, err := openAplayDevice(context.Background(), "plughw:1,0", pipeConfig{
+		SampleRate: 48000,
+		Channels:   1,
+		Latency:    50 * time.Millisecond,
+		PeriodSize: 1024,
+		BufferSize: 1024,
+	}); err == nil {
+		t.Errorf("Expected an error for a buffer of one period")
+	}
+}
+
+func TestAplayDeviceXruns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// stand in for aplay reporting two underruns among its other messages
+	script := filepath.Join(dir, "aplay")
+	contents := "#!/bin/sh\ncat > /dev/null\necho 'underrun!!! (at least 1.234 ms long)' >&2\necho 'Warning: some other message' >&2\necho 'underrun!!! (at least 0.500 ms long)' >&2\n"
+	if err := ioutil.WriteFile(script, []byte(contents), 0755); err != nil {
+		t.Fatalf("Unable to write fake aplay: %v", err)
+	}
+	defer func(path string) { aplayPath = path }(aplayPath)
+	aplayPath = script
+
+	d, err := openAplayDevice(context.Background(), "hw:0", pipeConfig{SampleRate: 48000, Channels: 2, Latency: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unable to open aplay device: %v", err)
+	}
+	if xruns := d.Xruns(); xruns != 0 {
+		t.Errorf("Expected no xruns before playing, got %d", xruns)
+	}
+
+	close(d.Stream())
+	for err := range d.Done() {
+		if err != nil {
+			t.Fatalf("Error playing samples: %v", err)
+		}
+	}
+	if xruns := d.Xruns(); xruns != 2 {
+		t.Errorf("Expected 2 xruns, got %d", xruns)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "time"
+
+// leapStepTolerance is how far a step of the system clock may be from a whole second and still be
+// taken as a leap second
+const leapStepTolerance = 100 * time.Millisecond
+
+// leapSecondStep returns true if the system clock stepping by step just before now is a leap second.
+// Leap seconds are applied at the end of a UTC day.  The kernel inserts one by stepping the clock
+// back from 00:00:00 to 23:59:59, since time.Time has no 23:59:60, and removes one by stepping it
+// forward from 23:59:59 to 00:00:00.
+func leapSecondStep(now time.Time, step time.Duration) bool {
+	utc := now.UTC()
+	switch {
+	case step > -time.Second-leapStepTolerance && step < -time.Second+leapStepTolerance:
+		return utc.Hour() == 23 && utc.Minute() == 59 && utc.Second() == 59
+	case step > time.Second-leapStepTolerance && step < time.Second+leapStepTolerance:
+		return utc.Hour() == 0 && utc.Minute() == 0 && utc.Second() == 0
+	}
+	return false
+}
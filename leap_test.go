@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeapSecondStep(t *testing.T) {
+	central, err := time.LoadLocation("US/Central")
+	if err != nil {
+		t.Fatalf("Unable to load location: %v", err)
+	}
+	inserted := time.Date(2016, 12, 31, 23, 59, 59, 20000000, time.UTC)
+	removed := time.Date(2017, 1, 1, 0, 0, 0, 20000000, time.UTC)
+	testCases := []struct {
+		Name     string
+		Now      time.Time
+		Step     time.Duration
+		Expected bool
+	}{
+		{"Inserted", inserted, -time.Second, true},
+		{"InsertedLate", inserted, -time.Second + 20*time.Millisecond, true},
+		{"InsertedLocal", inserted.In(central), -time.Second, true},
+		{"Removed", removed, time.Second, true},
+		{"NoStep", inserted, 0, false},
+		{"ShortStep", inserted, -500 * time.Millisecond, false},
+		{"LongStep", inserted, -2 * time.Second, false},
+		{"MidDay", inserted.Add(-12 * time.Hour), -time.Second, false},
+		{"WrongDirection", inserted, time.Second, false},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if leap := leapSecondStep(c.Now, c.Step); leap != c.Expected {
+				st.Errorf("Expected leap second %v for a %s step at %s, got %v", c.Expected, c.Step, c.Now, leap)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// logFields are structured values attached to a log message when logging JSON.  Durations are
+// encoded as integer nanoseconds, so their keys end in _ns.
+type logFields map[string]interface{}
+
+// jsonLogger writes each message as a line of JSON with its time, level and fields
+type jsonLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	now func() time.Time
+}
+
+// jsonLog replaces glog when -log-format json is used
+var jsonLog *jsonLogger
+
+// setLogFormat selects glog or json logging
+func setLogFormat(format string) error {
+	switch format {
+	case "glog":
+		jsonLog = nil
+	case "json":
+		jsonLog = &jsonLogger{w: os.Stderr, now: time.Now}
+	default:
+		return fmt.Errorf("unknown log format %q, expected glog or json", format)
+	}
+	return nil
+}
+
+func (l *jsonLogger) log(level string, fields logFields, msg string) {
+	entry := make(map[string]interface{}, len(fields)+3)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = l.now().Format(time.RFC3339Nano)
+	entry["level"] = level
+	entry["msg"] = msg
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"time": l.now().Format(time.RFC3339Nano), "level": level, "msg": msg, "error": err.Error()})
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(b, '\n'))
+}
+
+// logInfof logs an informational message, fields are only written when logging JSON
+func logInfof(fields logFields, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if jsonLog != nil {
+		jsonLog.log("info", fields, msg)
+		return
+	}
+	glog.InfoDepth(1, msg)
+}
+
+// logWarningf logs a problem that doesn't stop timecode being sent, fields are only written when
+// logging JSON
+func logWarningf(fields logFields, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if jsonLog != nil {
+		jsonLog.log("warning", fields, msg)
+		return
+	}
+	glog.InfoDepth(1, "WARNING: "+msg)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestJSONLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *jsonLogger) { jsonLog = l }(jsonLog)
+	jsonLog = &jsonLogger{
+		w:   &buf,
+		now: func() time.Time { return time.Date(2018, 12, 1, 23, 14, 21, 0, time.UTC) },
+	}
+
+	logWarningf(logFields{"timecode": "23:14:21:05", "count": 2, "offset_ns": 1500 * time.Microsecond}, "Skipped %d frames at %s", 2, "23:14:21:05")
+	logInfof(nil, "Exiting")
+
+	expected := `{"count":2,"level":"warning","msg":"Skipped 2 frames at 23:14:21:05","offset_ns":1500000,"time":"2018-12-01T23:14:21Z","timecode":"23:14:21:05"}` + "\n" +
+		`{"level":"info","msg":"Exiting","time":"2018-12-01T23:14:21Z"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Incorrect JSON log:\ngot      %s\nexpected %s", buf.String(), expected)
+	}
+}
+
+func TestSetLogFormat(t *testing.T) {
+	defer func(l *jsonLogger) { jsonLog = l }(jsonLog)
+	for _, format := range []string{"glog", "json"} {
+		if err := setLogFormat(format); err != nil {
+			t.Errorf("Unexpected error setting log format %s: %v", format, err)
+		}
+	}
+	if jsonLog == nil {
+		t.Errorf("Expected JSON logging after setting the json format")
+	}
+	if err := setLogFormat("syslog"); err == nil {
+		t.Errorf("Expected an error setting an unknown log format")
+	}
+}
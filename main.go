@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,143 +16,1266 @@ import (
 	"github.com/azenk/audio/stream"
 	"github.com/azenk/audio/stream/encoding"
 
+	"github.com/azenk/ltcgen/control"
 	"github.com/azenk/ltcgen/glitc"
+	"github.com/azenk/ltcgen/mtc"
+	"github.com/azenk/ltcgen/osc"
+	"github.com/azenk/ltcgen/serial"
 	"github.com/golang/glog"
 	"github.com/spf13/viper"
 )
 
-func main() {
-	flag.Parse()
-	cfgFile := viper.New()
-	cfgFile.AddConfigPath("/etc/ltcgen")
-	cfgFile.SetConfigName("ltcgen")
-	cfgFile.SetDefault("fps", 29.97)
-	cfgFile.SetDefault("dropframe", true)
-	cfgFile.SetDefault("rateWindowMinutes", 2)
-	cfgFile.SetDefault("pid.p", 1)
-	cfgFile.SetDefault("pid.i", 1)
-	cfgFile.SetDefault("pid.d", 1)
-	cfgFile.SetDefault("pid.depth", 30)
-	cfgFile.ReadInConfig()
+var (
+	outputFile   = flag.String("output", "", "Write LTC to this WAV file instead of the audio device, or - to stream raw PCM to stdout")
+	duration     = flag.Duration("duration", 10*time.Second, "Length of timecode to write with -output or -self-test, 0 streams until interrupted with -output -")
+	noRealtime   = flag.Bool("no-realtime", false, "Stream samples with -output - as fast as they can be encoded instead of in real time")
+	dryRun       = flag.Bool("dry-run", false, "Run the frame loop without opening an audio device, printing each frame's timecode instead")
+	selfTestFlag = flag.Bool("self-test", false, "Encode -duration worth of timecode, decode it again and report any frames that don't match")
+	format       = flag.String("format", "S16_LE", "Sample format written with -output: S16_LE, S24_3LE, S32_LE or FLOAT_LE")
+	startTime    = flag.String("start", "", "Time of day (HH:MM:SS) to start timecode written with -output, defaults to now")
+	maxClockErr  = flag.Duration("max-clock-error", 0, "Refuse to start if the clock error measured from -ntp-server exceeds this, e.g. 1ms, 0 skips the check")
+	ntpServer    = flag.String("ntp-server", "127.0.0.1:123", "NTP server queried for -max-clock-error, usually the local chrony or ntpd")
+	offsetWindow = flag.Duration("offset-window", 0, "Largest intra frame offset expected before samples may reach the audio device late, 0 uses half the output delay")
+	offset       = flag.Duration("offset", 0, "Run timecode this far ahead of the system clock, or behind if negative, e.g. 1h or -10s")
+	phaseUS      = flag.Float64("phase-offset", 0, "Start each frame this many microseconds after the clock's frame boundary, or before if negative, less than a frame")
+	alignSecond  = flag.Bool("align-to-second", false, "Wait until the wall clock crosses a whole second, plus -align-phase, before sending the first frame, to start several generators together")
+	alignPhase   = flag.Duration("align-phase", 0, "Sub-second phase to start at with -align-to-second, e.g. 500ms starts half way through a second")
+	metricsAddr  = flag.String("metrics-addr", "", "Serve prometheus metrics on this address, e.g. :9100")
+	grpcAddr     = flag.String("grpc-addr", "", "Serve the gRPC control service on this address, e.g. :9101")
+	healthAddr   = flag.String("health-addr", "", "Serve /healthz on this address, responding 503 unless recent frames were sent on time, may be the same as -metrics-addr")
+	channels     = flag.String("channels", "signal", "Comma separated output mode for each channel: signal, inverted or silent")
+	outChannels  = flag.Int("output-channels", 0, "Number of output channels with LTC on -ltc-channel and silence on the rest, instead of -channels, 0 uses -channels")
+	ltcChannel   = flag.Int("ltc-channel", 0, "Channel counted from 0 that carries LTC with -output-channels")
+	muteFlag     = flag.Bool("mute", false, "Start with the output silenced while timecode keeps counting, send SIGUSR2 to unmute")
+	invert       = flag.Bool("invert", false, "Invert the polarity of the output signal, applied before -channels")
+	smpteVersion = flag.String("smpte-version", "1999", "SMPTE 12M revision whose flag bit assignments are sent: 1986, 1999 or 2008, which differ at 50fps")
+	userBytes    = flag.String("user-bytes", "", "Send these 8 hex digits in the user bits of every frame, e.g. A5C39172, with the binary group flags marking them user defined")
+	levelDBFS    = flag.Float64("level-dbfs", 0, "Peak output level in dBFS, 0 is full scale")
+	rateFlag     = flag.String("rate", "", "Frame rate, one of "+listRateNames()+", overrides fps, dropframe and pulldown from the config file")
+	rate2Flag    = flag.String("rate2", "", "Frame rate of a second generator sent on the signal2 and inverted2 channels, e.g. 25 alongside 29.97df")
+	forceFPS     = flag.Bool("force-fps", false, "Run at 29.97 fps drop frame when dropframe is set with another fps instead of exiting")
+	riseTimeUS   = flag.Float64("rise-time", 0, "Rise and fall time of transitions in microseconds, 0 sends a square wave")
+	leadIn       = flag.Int("lead-in", 0, "Frames of timecode counting up to the start before it is treated as authoritative, giving readers time to lock")
+	lookAhead    = flag.Int("look-ahead", 0, "Frames to encode ahead of the clock so late frame timer ticks don't starve the audio device, each adds a frame of output delay")
+	rtPriority   = flag.Int("rt-priority", 0, "Run the frame loop with SCHED_FIFO real-time priority 1-99 to reduce jitter, needs CAP_SYS_NICE")
+	rtCPU        = flag.Int("rt-cpu", -1, "Pin the frame loop to this CPU")
+	drainWait    = flag.Duration("shutdown-timeout", 2*time.Second, "Maximum time to wait for buffered audio to play out on shutdown")
+	freeRun      = flag.Bool("free-run", false, "Advance timecode by counting frames instead of following the system clock")
+	sampleClock  = flag.Bool("sample-clock", false, "Derive each frame from the number of samples sent to the audio device instead of when the frame timer fires, timecode follows the interface's clock")
+	monotonic    = flag.Bool("monotonic", false, "Follow the system clock at startup, then count elapsed time so later clock steps don't cause frame errors")
+	chaseDevice  = flag.String("chase", "", "Lock to LTC read from this ALSA capture device, e.g. hw:1,0, and send it out again reclocked, steered by pid in the config file")
+	chaseDelay   = flag.Duration("chase-input-delay", 0, "Time from LTC reaching the -chase input to its samples being read, compensated for when locking")
+	freeRunTC    = flag.String("free-run-start", "", "Timecode to start from with -free-run, defaults to the current time")
+	staticTC     = flag.String("static-tc", "", "Send this timecode on every frame instead of counting, e.g. 10:00:00:00, a freeze test pattern for readers")
+	statusEvery  = flag.Duration("status-interval", 10*time.Second, "How often to log status")
+	errorEvery   = flag.Duration("frame-error-interval", defaultFrameErrorInterval, "Shortest interval between frame error warnings, errors in between are counted in the next one, 0 warns about every frame error")
+	rateWindow   = flag.Duration("rate-window", 0, "Window the frame rate is averaged over, overrides rateWindowMinutes from the config file")
+	logFormat    = flag.String("log-format", "glog", "Log format: glog, or json for one JSON object per line on stderr")
+	statusJSON   = flag.Bool("status-json", false, "Log status as JSON instead of text")
+	reverse      = flag.Bool("reverse", false, "Count timecode down as if played backwards, requires -free-run")
+	listDevices  = flag.Bool("list-devices", false, "List the ALSA playback devices and exit")
+	deviceName   = flag.String("device", "", "ALSA playback device to use instead of the default, by index, hw:card,device or name as shown by -list-devices")
+	backend      = flag.String("audio-backend", "alsa", "Audio output backend: alsa, pulse or iec958")
+	iec958Card   = flag.String("iec958-card", "0", "ALSA card whose AES3 or S/PDIF output is used with -audio-backend iec958")
+	iec958Pro    = flag.Bool("iec958-professional", false, "Send professional (AES3) instead of consumer (S/PDIF) channel status with -audio-backend iec958")
+	mtcDevice    = flag.String("mtc-device", "", "Also send MIDI timecode to this raw MIDI device, e.g. /dev/snd/midiC1D0")
+	oscAddr      = flag.String("osc-addr", "", "Also send OSC /timecode messages to this host:port, e.g. 255.255.255.255:53000")
+	serialDevice = flag.String("serial-device", "", "Also write each frame's timecode as an hh:mm:ss:ff line to this serial port, e.g. /dev/ttyUSB0")
+	serialBaud   = flag.Int("serial-baud", 9600, "Baud rate of -serial-device")
+	reconnect    = flag.Bool("reconnect", false, "Reopen the audio device with backoff if it fails, e.g. when a USB interface is unplugged, instead of exiting")
+	pulseDelay   = flag.Duration("pulse-latency", 50*time.Millisecond, "Latency requested from the PulseAudio server with -audio-backend pulse, or the buffer time with iec958 or -device")
+	periodSize   = flag.Int("period-size", 0, "ALSA period size in sample frames with -audio-backend iec958 or -device, smaller lowers latency, larger tolerates scheduling delays, 0 lets the device choose")
+	bufferSize   = flag.Int("buffer-size", 0, "ALSA buffer size in sample frames with -audio-backend iec958 or -device, at least two periods, replaces -pulse-latency, 0 uses -pulse-latency")
+)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// outputDevice is an audio output LTC can be played through
+type outputDevice interface {
+	Stream() chan []stream.Sample
+	Done() chan error
+	SampleRate() int
+	OutputDelay() time.Duration
+}
+
+// xrunCounter is implemented by output devices that detect underruns, each one means the card was
+// starved of samples and the LTC being played was corrupted
+type xrunCounter interface {
+	Xruns() int64
+}
+
+// alsaDevice adapts a stream.StreamDevice to outputDevice
+type alsaDevice struct {
+	*stream.StreamDevice
+}
 
-	glog.Infof("Opening audio device")
-	streamDevice, err := stream.OpenDefaultDevice(ctx, &stream.Configuration{Channels: 1})
+func (d alsaDevice) SampleRate() int {
+	return d.Config().SampleRate()
+}
+
+func (d alsaDevice) OutputDelay() time.Duration {
+	return d.Config().OutputDelay()
+}
+
+// openOutputDevice opens the audio output selected by -audio-backend
+func openOutputDevice(ctx context.Context, cfgFile *viper.Viper, channelModes []ChannelMode) (outputDevice, error) {
+	preferred, err := configSampleRates(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	// the dry run device and pipe clients accept any rate, so they get the first preference
+	sampleRate, err := negotiateSampleRate(preferred, nil)
 	if err != nil {
+		return nil, err
+	}
+
+	if *dryRun {
+		logInfof(nil, "Dry run, samples will be discarded")
+		return newDryRunDevice(ctx, sampleRate), nil
+	}
+
+	if (*periodSize != 0 || *bufferSize != 0) && (*backend == "pulse" || *backend == "alsa" && *deviceName == "") {
+		return nil, errors.New("-period-size and -buffer-size need -device or -audio-backend iec958, the default device's sizes are chosen by the audio package")
+	}
+
+	switch *backend {
+	case "alsa":
+		if *deviceName != "" {
+			return openSelectedDevice(ctx, sampleRate, channelModes)
+		}
+
+		// the audio device writes every sample to all of its channels
+		for _, mode := range channelModes {
+			if mode != ChannelSignal {
+				return nil, fmt.Errorf("channel mode %s isn't supported by the alsa backend", mode)
+			}
+		}
+
+		logInfof(nil, "Opening audio device")
+		streamDevice, err := stream.OpenDefaultDevice(ctx, &stream.Configuration{Channels: len(channelModes)})
+		if err != nil {
+			return nil, err
+		}
+		logInfof(nil, "Device configuration -- %s", streamDevice.Config())
+		// the audio package picks the rate itself, the encoder follows whatever it chose
+		device := alsaDevice{streamDevice}
+		if _, err := negotiateSampleRate(preferred, []int{device.SampleRate()}); err != nil {
+			logWarningf(logFields{"sample_rate": device.SampleRate(), "preferred": preferred}, "Audio device opened at %d Hz, which isn't one of the preferred rates %v", device.SampleRate(), preferred)
+		}
+		return device, nil
+	case "pulse":
+		logInfof(nil, "Opening PulseAudio stream")
+		pulseDevice, err := OpenPulseDevice(ctx, pipeConfig{
+			SampleRate:   sampleRate,
+			Channels:     len(channelModes),
+			Latency:      *pulseDelay,
+			ChannelModes: channelModes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		logInfof(nil, "Stream configuration -- %s", pulseDevice.Config())
+		return pulseDevice, nil
+	case "iec958":
+		// the digital output carries the same samples on both channels
+		for _, mode := range channelModes {
+			if mode != ChannelSignal {
+				return nil, fmt.Errorf("channel mode %s isn't supported by the iec958 backend", mode)
+			}
+		}
+
+		config := IEC958Config{
+			Card:    *iec958Card,
+			Status:  IEC958Status{Professional: *iec958Pro, SampleRate: sampleRate},
+			Latency: *pulseDelay,
+		}
+		device, err := config.Status.DeviceName(config.Card)
+		if err != nil {
+			return nil, err
+		}
+		sizes, err := bufferSizes(device, pipeConfig{SampleRate: sampleRate, Channels: 2})
+		if err != nil {
+			return nil, err
+		}
+		config.PeriodSize, config.BufferSize = sizes.PeriodSize, sizes.BufferSize
+		logInfof(nil, "Opening IEC958 device %s", device)
+		iecDevice, err := OpenIEC958Device(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		logInfof(nil, "Stream configuration -- %s", iecDevice.Config())
+		return iecDevice, nil
+	}
+	return nil, fmt.Errorf("unknown audio backend %q, expected alsa, pulse or iec958", *backend)
+}
+
+// openSelectedDevice plays samples through aplay on the ALSA device chosen with -device
+func openSelectedDevice(ctx context.Context, sampleRate int, channelModes []ChannelMode) (outputDevice, error) {
+	devices, err := listPlaybackDevices()
+	if err != nil {
+		return nil, err
+	}
+	device, err := selectPlaybackDevice(devices, *deviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := bufferSizes(device.ALSAName(), pipeConfig{
+		SampleRate:   sampleRate,
+		Channels:     len(channelModes),
+		Latency:      *pulseDelay,
+		ChannelModes: channelModes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logInfof(nil, "Opening audio device %s", device)
+	aplayDevice, err := openAplayDevice(ctx, device.ALSAName(), config)
+	if err != nil {
+		return nil, err
+	}
+	logInfof(nil, "Stream configuration -- %s", aplayDevice.Config())
+	return aplayDevice, nil
+}
+
+// bufferSizes sets the period and buffer sizes from -period-size and -buffer-size in config, checking
+// them against the sizes the named device supports.  If the device can't be queried aplay is left to
+// pick the nearest sizes it supports.
+func bufferSizes(device string, config pipeConfig) (pipeConfig, error) {
+	if *periodSize == 0 && *bufferSize == 0 {
+		return config, nil
+	}
+	if err := checkBufferSizes(*periodSize, *bufferSize); err != nil {
+		return config, err
+	}
+
+	params, err := queryHWParams(device, config)
+	if err != nil {
+		logWarningf(logFields{"error": err.Error()}, "Unable to check period and buffer sizes: %v", err)
+	} else if err := params.Check(*periodSize, *bufferSize); err != nil {
+		return config, err
+	}
+	config.PeriodSize, config.BufferSize = *periodSize, *bufferSize
+	logInfof(logFields{"period_size": config.PeriodSize, "buffer_size": config.BufferSize, "buffer_time_ns": config.BufferTime()},
+		"Using period size %d and buffer size %d on %s", config.PeriodSize, config.BufferSize, device)
+	return config, nil
+}
+
+// dbfsAmplitude converts a peak level in dBFS to the fraction of full scale passed to the encoder.  Full
+// scale is 32767 for S16_LE and 2147483647 for S32_LE samples, so -6 dBFS peaks at roughly half of that
+// and -12 dBFS at roughly a quarter.
+func dbfsAmplitude(dbfs float64) (float64, error) {
+	if dbfs > 0 || math.IsNaN(dbfs) {
+		return 0, fmt.Errorf("level must be at or below 0 dBFS, got %f", dbfs)
+	}
+	return math.Pow(10, dbfs/20), nil
+}
+
+// maxRateWindowLen is the longest rate window, in frames, that is allowed without a warning.  Each
+// frame in the window takes around 64 bytes.
+const maxRateWindowLen = 1 << 20
+
+// rateWindowLen returns the number of frames sent at fps over window, the length of the ring used to
+// average the frame rate
+func rateWindowLen(fps float64, window time.Duration) (int, error) {
+	n := int(fps * window.Seconds())
+	if n < 2 {
+		return 0, fmt.Errorf("rate window %s is shorter than two frames at %0.2f fps", window, fps)
+	}
+	return n, nil
+}
+
+// riseTime returns -rise-time as a duration
+func riseTime() time.Duration {
+	return time.Duration(*riseTimeUS * float64(time.Microsecond))
+}
+
+// checkSampleRate returns an error if sampleRate is too low to encode frames at all, reports the bit
+// rate it gives, and warns when it doesn't divide evenly into frames.  The encoder spreads the
+// remainder across frames, truncating it instead would make the timecode drift.
+func checkSampleRate(frame glitc.LTCFrame, sampleRate float64) error {
+	if err := checkSamplesPerBit(sampleRate, frame.EffectiveFPS()); err != nil {
+		return err
+	}
+	logBitRate(frame, sampleRate)
+	samplesPerFrame := frame.SamplesPerFrame(sampleRate)
+	drift := DriftPerHour(int(sampleRate), frame.EffectiveFPS())
+	if drift == 0 {
+		logInfof(nil, "%0.f Hz is a whole %0.f samples per frame at %f fps, no drift from frame lengths",
+			sampleRate, samplesPerFrame, frame.EffectiveFPS())
+		return nil
+	}
+	fields := logFields{"sample_rate": sampleRate, "samples_per_frame": samplesPerFrame, "fps": frame.EffectiveFPS(), "drift_per_hour_ns": drift}
+	logWarningf(fields, "%0.f Hz is %f samples per frame at %f fps, frame lengths will vary by a sample "+
+		"to avoid drifting %s per hour (%.1f ppm)", sampleRate, samplesPerFrame, frame.EffectiveFPS(), drift, driftPPM(drift))
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if err := applyEnvFlags(flag.CommandLine, os.LookupEnv); err != nil {
 		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := setLogFormat(*logFormat); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if flag.NArg() > 0 {
+		var err error
+		switch flag.Arg(0) {
+		case "encode":
+			err = encodeCommand(flag.Args()[1:], os.Stdout)
+		case "decode":
+			err = decodeCommand(flag.Args()[1:], os.Stdout)
+		case "mix":
+			err = mixCommand(flag.Args()[1:], os.Stdout)
+		case "render":
+			err = renderCommand(flag.Args()[1:], os.Stdout)
+		default:
+			err = fmt.Errorf("unknown command %q, expected encode, decode, mix or render", flag.Arg(0))
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *listDevices {
+		devices, err := listPlaybackDevices()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for i, device := range devices {
+			fmt.Printf("%d: %s\n", i, device)
+		}
 		return
 	}
-	glog.Infof("Device configuration -- %s", streamDevice.Config())
+
+	cfgFile := newConfig()
+	cfgFile.ReadInConfig()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, os.Interrupt)
 	signal.Notify(signalCh, syscall.SIGTERM)
+	pauseCh := make(chan os.Signal, 1)
+	notifyPause(pauseCh)
+	muteCh := make(chan os.Signal, 1)
+	notifyMute(muteCh)
 
 	fps := cfgFile.GetFloat64("fps")
 	dropframe := cfgFile.GetBool("dropframe")
+	pulldown := cfgFile.GetBool("pulldown")
+	rateName := cfgFile.GetString("rate")
+	if *rateFlag != "" {
+		rateName = *rateFlag
+	}
 
-	if dropframe && fps != 29.97 {
-		glog.Infof("Dropframe is set to true and isn't supported for the specified framerate.  Overriding fps to 29.97")
-		fps = 29.97
+	frame, err := selectFrame(rateName, fps, dropframe, pulldown, *forceFPS)
+	if err == nil {
+		err = frame.Validate()
 	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	frame.ExternalClockSync = true
+	logInfof(logFields{"fps": frame.EffectiveFPS(), "drop_frame": frame.DropFrame}, "Configured for %f fps, dropframe: %v", frame.EffectiveFPS(), frame.DropFrame)
 
-	frame := glitc.LTCFrame{FramesPerSecond: fps, DropFrame: dropframe, ExternalClockSync: true}
-	glog.Infof("Configured for %f fps, dropframe: %v", frame.EffectiveFPS(), frame.DropFrame)
+	channelModes, err := selectChannelModes()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := checkAlignPhase(*alignPhase); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if frame.SpecVersion, err = glitc.ParseSpecVersion(*smpteVersion); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *userBytes != "" {
+		b, err := glitc.ParseUserBytes(*userBytes)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		frame.SetUserBytes(b)
+		logInfof(logFields{"user_bytes": *userBytes}, "Sending user bytes %X", b)
+	}
 
-	// override sample rate from config file
-	sampleRate := float64(streamDevice.Config().SampleRate())
-	if val := cfgFile.GetFloat64("samplerate"); val != 0 {
-		sampleRate = val
-		glog.Infof("Got sample rate from configuration file: %f", val)
+	var secondary *secondaryGenerator
+	if *rate2Flag != "" {
+		frame2, err := selectFrame(*rate2Flag, 0, false, false, false)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		frame2.ExternalClockSync = true
+		frame2.SpecVersion = frame.SpecVersion
+		if frame.UserBytes != nil {
+			frame2.SetUserBytes(*frame.UserBytes)
+		}
+		if err := checkSecondaryGenerator(channelModes); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		secondary = newSecondaryGenerator(frame2)
+		logInfof(logFields{"fps": frame2.EffectiveFPS(), "drop_frame": frame2.DropFrame}, "Second generator at %f fps, dropframe: %v", frame2.EffectiveFPS(), frame2.DropFrame)
+	} else if generatorCount(channelModes) > 1 {
+		fmt.Println("signal2 and inverted2 channels need a second generator set with -rate2")
+		os.Exit(1)
 	}
 
-	// Set up manchester encoder
-	rawFrameChan := make(chan byte, 160)
-	samplesPerFrame := streamDevice.Config().SampleRate() / int(frame.EffectiveFPS())
+	amplitude, err := dbfsAmplitude(*levelDBFS)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	logInfof(nil, "Output level %0.1f dBFS, amplitude %f of full scale", *levelDBFS, amplitude)
+
+	if err := checkRiseTime(riseTime(), frame.BitPeriod()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *statusEvery <= 0 {
+		fmt.Printf("Status interval must be positive, got %s\n", *statusEvery)
+		os.Exit(1)
+	}
+
+	window := *rateWindow
+	if window == 0 {
+		window = time.Duration(cfgFile.GetFloat64("rateWindowMinutes") * float64(time.Minute))
+	}
+	windowLen, err := rateWindowLen(frame.EffectiveFPS(), window)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if windowLen > maxRateWindowLen {
+		logWarningf(logFields{"window_ns": window, "frames": windowLen}, "Rate window %s holds %d frames and will use around %d MB", window, windowLen, windowLen*64>>20)
+	}
+
+	var static *glitc.TimeCode
+	if *staticTC != "" {
+		tc, err := glitc.ParseTimeCode(*staticTC)
+		if err == nil {
+			err = checkTimeCode(tc, frame)
+		}
+		if err == nil {
+			err = checkStaticTimeCode()
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		static = &tc
+	}
+
+	if *selfTestFlag {
+		if err := selfTest(ctx, cfgFile, frame, amplitude); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *outputFile != "" {
+		go func() {
+			<-signalCh
+			cancel()
+		}()
+		if err := render(ctx, cfgFile, frame, channelModes, amplitude, static); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var freeRunStart *glitc.TimeCode
+	if *freeRunTC != "" {
+		tc, err := glitc.ParseTimeCode(*freeRunTC)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if tc.DropFrame != frame.DropFrame {
+			fmt.Printf("Free run start %s doesn't match dropframe setting %v\n", tc, frame.DropFrame)
+			os.Exit(1)
+		}
+		if !tc.IsValid(frame.FramesPerSecond, frame.DropFrame) {
+			fmt.Printf("Free run start %s isn't sent at %g fps\n", tc, frame.EffectiveFPS())
+			os.Exit(1)
+		}
+		freeRunStart = &tc
+	}
+
+	if *leadIn < 0 {
+		fmt.Printf("-lead-in must not be negative, got %d\n", *leadIn)
+		os.Exit(1)
+	}
+	if freeRunStart != nil && *leadIn > 0 {
+		// lead-in frames count up to the requested start
+		tc := freeRunStart.Sub(*leadIn, frame.FramesPerSecond)
+		freeRunStart = &tc
+	}
+
+	if *reverse && !*freeRun {
+		fmt.Println("-reverse requires -free-run")
+		os.Exit(1)
+	}
+
+	var mtcWriter *mtc.Writer
+	var mtcDone chan error
+	if *mtcDevice != "" {
+		rate, err := mtc.RateFor(frame)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		midi, err := os.OpenFile(*mtcDevice, os.O_WRONLY, 0)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer midi.Close()
+		mtcWriter = mtc.NewWriter(ctx, midi, rate)
+		mtcDone = mtcWriter.Done()
+		logInfof(nil, "Sending MIDI timecode to %s", *mtcDevice)
+	}
+
+	var oscSender *osc.Sender
+	if *oscAddr != "" {
+		oscSender, err = osc.NewSender(ctx, *oscAddr)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		logInfof(nil, "Sending OSC timecode to %s", *oscAddr)
+	}
+
+	var serialWriter *serial.Writer
+	var serialDone chan error
+	if *serialDevice != "" {
+		port, err := serial.Open(*serialDevice, *serialBaud)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer port.Close()
+		serialWriter = serial.NewWriter(ctx, port)
+		serialDone = serialWriter.Done()
+		logInfof(nil, "Writing timecode to serial port %s at %d baud", *serialDevice, *serialBaud)
+	}
+
+	if *maxClockErr > 0 {
+		result, err := checkClockError(*ntpServer, *maxClockErr)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		logInfof(logFields{"clock_error_ns": result.ClockError(), "max_clock_error_ns": *maxClockErr, "offset_ns": result.Offset, "stratum": result.Stratum},
+			"Clock error %s is within -max-clock-error %s (%s)", result.ClockError(), *maxClockErr, result)
+	}
+
+	var observers *observerQueue
+	if len(frameObservers) != 0 {
+		observers = newObserverQueue(ctx, frameObservers, 64)
+		logInfof(nil, "Calling %d frame observers", len(frameObservers))
+	}
+
+	var streamDevice outputDevice
+	if *reconnect {
+		open := func(ctx context.Context) (outputDevice, error) {
+			return openOutputDevice(ctx, cfgFile, channelModes)
+		}
+		var reconnecting *reconnectingDevice
+		reconnecting, err = newReconnectingDevice(ctx, open, 100*time.Millisecond, 10*time.Second)
+		if err == nil {
+			streamDevice = reconnecting
+		}
+	} else {
+		streamDevice, err = openOutputDevice(ctx, cfgFile, channelModes)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// the encoder runs at whatever rate was negotiated with the device
+	sampleRate := float64(streamDevice.SampleRate())
+	logInfof(nil, "Encoding at %0.f Hz", sampleRate)
+
+	if *sampleClock {
+		if err := checkSampleClock(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	var chase *chaser
+	if *chaseDevice != "" {
+		if err := checkChase(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		pid, err := configPIDController(cfgFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		chase = newChaser(frame, *chaseDelay, pid)
+	}
+	if *lookAhead < 0 {
+		fmt.Printf("-look-ahead must not be negative, got %d\n", *lookAhead)
+		os.Exit(1)
+	}
+
+	// Set up manchester encoder, the frame channel holds the look-ahead frames with room to spare
+	rawFrameChan := make(chan byte, (16+*lookAhead)*frameBytes)
+	if err := checkSampleRate(frame, sampleRate); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	samplesPerFrame := int(math.Ceil(frame.SamplesPerFrame(sampleRate)))
 	encodedData := encoding.DifferentialManchester(context.Background(),
 		3*samplesPerFrame,
 		frame.EffectiveFPS()*80,
-		1.0,
+		amplitude,
 		sampleRate,
 		rawFrameChan)
 
+	// the second generator has its own encoder, its samples are paired with the primary's
+	var rawFrameChan2 chan byte
+	var encodedData2 chan stream.Sample
+	if secondary != nil {
+		if err := checkSampleRate(secondary.frame, sampleRate); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		rawFrameChan2 = make(chan byte, 2*(16+*lookAhead)*frameBytes)
+		encodedData2 = encoding.DifferentialManchester(context.Background(),
+			3*int(math.Ceil(secondary.frame.SamplesPerFrame(sampleRate))),
+			secondary.frame.EffectiveFPS()*80,
+			amplitude,
+			sampleRate,
+			rawFrameChan2)
+	}
+
 	// Copy manchester encoded frames to streamDevice for output
 	streamCh := streamDevice.Stream()
+	encoderDrained := make(chan struct{})
+	shaper := newSlewLimiter(riseTime(), sampleRate, amplitude)
+	outputPolarity := polarity(*invert)
+	mute := &muteSwitch{}
+	mute.Set(*muteFlag)
+	shaper2 := newSlewLimiter(riseTime(), sampleRate, amplitude)
+	samplesSent := &sampleCounter{}
 	go func() {
+		defer close(encoderDrained)
+		padding := -1
 		for sample := range encodedData {
-			streamCh <- []stream.Sample{sample}
+			samples := []stream.Sample{mute.Apply(outputPolarity.Apply(shaper.Apply(sample)))}
+			if secondary != nil {
+				// the first primary frame has been sent, so the padding before the first secondary
+				// frame is known
+				if padding < 0 {
+					padding = int(math.Round(secondary.Padding().Seconds() * sampleRate))
+				}
+				var sample2 stream.Sample
+				if padding > 0 {
+					padding--
+				} else {
+					sample2 = <-encodedData2
+				}
+				samples = append(samples, mute.Apply(outputPolarity.Apply(shaper2.Apply(sample2))))
+			}
+			select {
+			case streamCh <- samples:
+				samplesSent.Add(1)
+			case <-ctx.Done():
+				// the device has stopped reading, don't block forever on a full stream channel
+				return
+			}
 		}
 		close(streamCh)
 	}()
 
 	// Start Status Ticker
-	statusTick := time.NewTicker(10 * time.Second)
+	clock := Clock(realClock{})
+	statusTick := clock.NewTicker(*statusEvery)
 
-	outputDelay := streamDevice.Config().OutputDelay()
-	glog.Infof("Output delay estimated at %s, will attempt to compensate", outputDelay)
+	outputDelay := streamDevice.OutputDelay()
+	logInfof(logFields{"output_delay_ns": outputDelay}, "Output delay estimated at %s, will attempt to compensate", outputDelay)
+
+	status := NewStatus(windowLen)
+	status.SetMuted(mute.Muted())
+	if mute.Muted() {
+		logInfof(nil, "Output muted, send SIGUSR2 to unmute")
+	}
+	status.SetDeviceOpen(true)
+	if frame.UserBytes != nil {
+		status.SetUserBytes(*frame.UserBytes)
+	}
+	servers := make(map[string]map[string]http.Handler)
+	if *metricsAddr != "" {
+		servers[*metricsAddr] = map[string]http.Handler{"/metrics": metricsHandler(status)}
+	}
+	if *healthAddr != "" {
+		if servers[*healthAddr] == nil {
+			servers[*healthAddr] = make(map[string]http.Handler)
+		}
+		servers[*healthAddr]["/healthz"] = newHealthChecker(status)
+	}
+	var controlRequests <-chan controlRequest
+	var runtime *controlRuntime
+	if *grpcAddr != "" {
+		runtime = newControlRuntime(ctx, status, mute)
+		controlRequests = runtime.Requests()
+		serveGRPC(*grpcAddr, control.NewServer(runtime, *statusEvery))
+	}
+	for addr, handlers := range servers {
+		serveHTTP(addr, handlers)
+	}
 
 	// Calculate the time we should start our frame timing ticker
 	frameDuration := frame.FrameDuration()
-	frame.Time = time.Now()
-	glog.Infof("Sync time %s", frame.Frame())
-	syncTime := frame.FrameBeginTime().Add(2 * frameDuration).Add(-1 * outputDelay).Add(250 * time.Microsecond)
-	syncTimer := time.NewTimer(time.Until(syncTime))
-	glog.Infof("Waiting for next frame to start at: %s", syncTime)
-	<-syncTimer.C
-	frameTimer := time.NewTicker(frameDuration)
-	// Set prevFrameIndex to now, this should be one frame before the first frame output
-	frame.Time = time.Now().Add(outputDelay)
-	var prevFrameIndex int = frame.FrameIndex()
-	frame.Time = time.Now().Add(frameDuration).Add(outputDelay)
-	glog.Infof("Sending LTC frame every %s, first frame should be %s", frameDuration, frame.Frame())
-
-	status := NewStatus(int(frame.EffectiveFPS() * float64(60) * cfgFile.GetFloat64("rateWindowMinutes")))
+	phase, err := phaseOffset(*phaseUS, frameDuration)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if phase != 0 {
+		logInfof(logFields{"phase_offset_ns": phase}, "Frames will begin %s after the clock's frame boundaries", phase)
+	}
+	// frame boundaries follow the offset timecode, which only line up with the clock's frame boundaries
+	// when the offset is a whole number of frames, the phase offset shifts them by part of a frame
+	tcOffset := timecodeOffset(*offset, phase)
+	frame.Time = clock.Now().Add(tcOffset)
+	logInfof(nil, "Sync time %s", frame.Frame())
+	syncTime := frame.FrameBeginTime().Add(2 * frameDuration).Add(-1 * outputDelay).Add(-1 * tcOffset).Add(250 * time.Microsecond)
+	if *alignSecond {
+		// the first frame is played at the aligned instant, the samples are written the output delay before
+		start := alignedStart(clock.Now(), *alignPhase, outputDelay+2*frameDuration)
+		syncTime = start.Add(-1 * outputDelay).Add(250 * time.Microsecond)
+		logInfof(logFields{"start": start.Format(time.RFC3339Nano)}, "Aligning start, first frame will be played at %s", start.Format(time.RFC3339Nano))
+	}
+	syncTimer := clock.NewTimer(syncTime.Sub(clock.Now()))
+	logInfof(nil, "Waiting for next frame to start at: %s", syncTime)
+	<-syncTimer.C()
+	if *alignSecond {
+		started := clock.Now()
+		logInfof(logFields{"started": started.Format(time.RFC3339Nano), "late_ns": started.Sub(syncTime)},
+			"Started at %s, %s after the sync time", started.Format(time.RFC3339Nano), started.Sub(syncTime))
+	}
+	frameTimer := clock.NewTicker(frameDuration)
+	// frames are sent lookAheadDelay before they are due, the frames before them fill the buffer
+	lookAheadDelay := time.Duration(*lookAhead) * frameDuration
+	scheduler := newFrameScheduler(clock, frame, outputDelay+lookAheadDelay, tcOffset, status, *freeRun, freeRunStart, *reverse, *monotonic)
+	scheduler.SetOffsetWindow(*offsetWindow)
+	scheduler.SetFrameErrorInterval(*errorEvery)
+	if static != nil {
+		if err := scheduler.Hold(*static); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		logInfof(logFields{"timecode": static.String()}, "Sending static timecode %s on every frame, send SIGUSR1 to start counting", static)
+	}
+	if runtime != nil {
+		runtime.scheduler = scheduler
+	}
+	var sampleScheduler *sampleClockScheduler
+	if *sampleClock {
+		// two frames are encoded ahead so the encoder never runs dry between ticks
+		sampleScheduler = newSampleClockScheduler(frame, scheduler.Frame().Time, sampleRate, 2)
+		logInfof(logFields{"lead_ns": sampleScheduler.Lead()}, "Timecode follows the audio device's sample clock, frames are encoded %s ahead", sampleScheduler.Lead())
+	}
+	var chaseFrames <-chan chaseFrame
+	var chaseDone <-chan error
+	chaseWarnings := newLogThrottle(10 * time.Second)
+	if chase != nil {
+		capture, err := openLTCCapture(ctx, *chaseDevice, int(sampleRate), frame, clock)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		chaseFrames, chaseDone = capture.Frames(), capture.Done()
+		status.SetChase(false, 0)
+		logInfof(logFields{"device": *chaseDevice, "input_delay_ns": *chaseDelay}, "Chasing LTC from %s, PID %s", *chaseDevice, chase.pid)
+	}
+	prefill := *lookAhead
+	leadInFrames := *leadIn
+	status.SetOutputDelay(outputDelay)
+
+	// measure the output delay once audio is flowing if the device supports it, replacing the estimate
+	var delayTick <-chan time.Time
+	delayPID, err := configPIDController(cfgFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	calibrator := newDelayCalibrator(outputDelay, delayPID)
+	meter, _ := streamDevice.(delayMeter)
+	if meter != nil {
+		if _, err := meter.Delay(); err != errDelayUnsupported {
+			delayTicker := clock.NewTicker(time.Second)
+			defer delayTicker.Stop()
+			delayTick = delayTicker.C()
+			logInfof(nil, "Measuring output delay")
+		}
+	}
+	if *freeRun {
+		logInfof(nil, "Free running, timecode will no longer follow the system clock")
+	}
+	logInfof(logFields{"frame_duration_ns": frameDuration, "timecode": scheduler.Frame().Frame().String()},
+		"Sending LTC frame every %s, first frame should be %s", frameDuration, scheduler.Frame().Frame())
+
+	if *rtPriority != 0 || *rtCPU >= 0 {
+		if err := setRealTime(*rtPriority, *rtCPU); err != nil {
+			logWarningf(logFields{"error": err.Error()}, "%v, continuing with normal scheduling", err)
+		} else {
+			logInfof(nil, "Frame loop running with real-time priority %d on cpu %d", *rtPriority, *rtCPU)
+		}
+	}
+
+	// every frame is encoded into the same buffer, its bytes are copied onto the frame channels
+	binaryFrame := make([]byte, frameBytes)
+
+	// playFrame encodes a frame sent for the frame timer tick at t and passes it on to MIDI, OSC and
+	// observers.  The frame plays ahead after it is encoded, MIDI and OSC are sent the frame playing now.
+	playFrame := func(frame glitc.LTCFrame, t time.Time, ahead time.Duration) {
+		sendSecondary(rawFrameChan2, binaryFrame, secondary, frame)
+		sendEncoded(rawFrameChan, binaryFrame, frame, *reverse)
+		if *dryRun {
+			fmt.Println(dryRunLine(frame))
+		}
+		if leadInFrames > 0 {
+			if leadInFrames--; leadInFrames == 0 {
+				logInfof(nil, "Lead-in sent, timecode is authoritative from the next frame")
+			}
+		}
+		if observers != nil {
+			observers.Notify(frame.Frame(), t)
+		}
+
+		frame.Time = frame.Time.Add(-ahead)
+		if mtcWriter != nil {
+			mtcWriter.WriteFrame(frame)
+		}
+		if oscSender != nil {
+			oscSender.Send(frame)
+		}
+		if serialWriter != nil {
+			serialWriter.WriteFrame(frame)
+		}
+	}
+
+	// drainTimeout is armed once shutdown starts and bounds the wait for buffered audio to play out
+	var drainTimeout <-chan time.Time
 	for {
 		select {
-		case t := <-frameTimer.C:
-			frame.Time = t.Add(outputDelay)
-
-			intraFrameOffset := time.Now().Add(outputDelay).Sub(frame.FrameBeginTime())
-			// if intraFrameOffset > outputDelay/2 || intraFrameOffset <= time.Duration(0) {
-			// 	glog.Infof("WARNING: current intra frame offset outside stream output buffer window: %s", intraFrameOffset)
-			// }
-
-			thisFrameIndex := frame.FrameIndex()
-			if prevFrameIndex != 0 && thisFrameIndex != prevFrameIndex+1 {
-				glog.Infof("WARNING: Frame error detected: current intra frame offset: %s", intraFrameOffset)
-				if thisFrameIndex == prevFrameIndex {
-					glog.Infof("WARNING: Would have output duplicate frame number at %s, skipping", frame.Frame())
-					status.Duplicate()
-					continue
+		case t := <-frameTimer.C():
+			status.SetBuffered(len(rawFrameChan) / frameBytes)
+			if prefill > 0 {
+				for _, frame := range scheduler.Prefill(prefill) {
+					sendSecondary(rawFrameChan2, binaryFrame, secondary, frame)
+					sendEncoded(rawFrameChan, binaryFrame, frame, *reverse)
+					if *dryRun {
+						fmt.Println(dryRunLine(frame))
+					}
 				}
-				glog.Infof("WARNING: Skipped %d frames at %s", thisFrameIndex-(prevFrameIndex+1), frame.Frame())
-				status.Dropped(thisFrameIndex - (prevFrameIndex + 1))
+				prefill = 0
 			}
 
-			for _, b := range frame.EncodeFrame() {
-				rawFrameChan <- b
+			if sampleScheduler != nil {
+				position := samplesSent.Count()
+				for frame, ok := sampleScheduler.Next(position); ok; frame, ok = sampleScheduler.Next(position) {
+					status.Sent(clock.Now().Sub(t))
+					playFrame(frame, t, sampleScheduler.Lead())
+				}
+				continue
 			}
-			status.Sent(intraFrameOffset)
 
-			prevFrameIndex = thisFrameIndex
-		case <-statusTick.C:
-			glog.Infof("%s", status)
+			frame, ok := scheduler.Next(t)
+			if !ok {
+				continue
+			}
+			// MIDI and OSC aren't buffered, they are sent the frame that is playing now
+			ahead := lookAheadDelay
+			if scheduler.direction() < 0 {
+				ahead = -lookAheadDelay
+			}
+			playFrame(frame, t, ahead)
+		case <-pauseCh:
+			if sampleScheduler != nil {
+				logWarningf(nil, "Timecode can't be paused with -sample-clock")
+				continue
+			}
+			if scheduler.Paused() {
+				scheduler.Resume()
+				logInfof(nil, "Resumed timecode")
+			} else {
+				scheduler.Pause()
+				logInfof(logFields{"timecode": scheduler.Frame().Frame().String()}, "Paused timecode at %s, send SIGUSR1 again to resume", scheduler.Frame().Frame())
+			}
+		case in, more := <-chaseFrames:
+			if !more {
+				chaseFrames = nil
+				continue
+			}
+			next, jump, err := chase.Update(in, scheduler.Offset())
+			if err != nil {
+				if ok, suppressed := chaseWarnings.Allow(clock.Now()); ok {
+					logWarningf(logFields{"error": err.Error(), "suppressed": suppressed}, "Ignoring chase input: %v", err)
+				}
+				continue
+			}
+			scheduler.SetOffset(next, jump)
+			if jump {
+				logInfof(logFields{"timecode": in.TimeCode.String(), "phase_error_ns": chase.PhaseError()}, "Jammed to chase input at %s, %s away", in.TimeCode, chase.PhaseError())
+			}
+			status.SetChase(chase.Locked(clock.Now()), chase.PhaseError())
+		case err := <-chaseDone:
+			logWarningf(logFields{"error": fmt.Sprint(err)}, "Chase input stopped: %v, timecode continues from the clock", err)
+			chaseFrames, chaseDone = nil, nil
+			status.SetChase(false, chase.PhaseError())
+		case request := <-controlRequests:
+			request.reply <- request.apply()
+		case <-muteCh:
+			mute.Set(!mute.Muted())
+			status.SetMuted(mute.Muted())
+			if mute.Muted() {
+				logInfof(logFields{"timecode": scheduler.Frame().Frame().String()}, "Muted output at %s, timecode keeps counting, send SIGUSR2 again to unmute", scheduler.Frame().Frame())
+			} else {
+				logInfof(logFields{"timecode": scheduler.Frame().Frame().String()}, "Unmuted output at %s", scheduler.Frame().Frame())
+			}
+		case <-delayTick:
+			measured, err := meter.Delay()
+			if err != nil {
+				logWarningf(logFields{"error": err.Error()}, "Unable to measure output delay: %v", err)
+				continue
+			}
+			delay := calibrator.Update(measured)
+			scheduler.SetOutputDelay(delay + lookAheadDelay)
+			status.SetOutputDelay(delay)
+			status.SetDelayCorrection(calibrator.Correction())
+		case <-statusTick.C():
+			if counter, ok := streamDevice.(xrunCounter); ok {
+				if xruns := counter.Xruns(); xruns != status.Snapshot().Xruns {
+					logWarningf(logFields{"xruns": xruns}, "Audio device underran, %d xruns since start", xruns)
+					status.SetXruns(xruns)
+				}
+			}
+			if chase != nil && chaseDone != nil && !chase.Locked(clock.Now()) {
+				logWarningf(logFields{"device": *chaseDevice}, "Not locked to LTC from the chase input %s", *chaseDevice)
+				status.SetChase(false, chase.PhaseError())
+			}
+			if reconnecting, ok := streamDevice.(*reconnectingDevice); ok {
+				status.SetReconnects(reconnecting.Reconnects())
+				status.SetDeviceOpen(reconnecting.Connected())
+			}
+			if oscSender != nil && oscSender.Dropped() != 0 {
+				logWarningf(logFields{"count": oscSender.Dropped()}, "%d OSC messages dropped", oscSender.Dropped())
+			}
+			if serialWriter != nil && serialWriter.Dropped() != 0 {
+				logWarningf(logFields{"count": serialWriter.Dropped()}, "%d serial timecode lines dropped", serialWriter.Dropped())
+			}
+			if observers != nil && observers.Dropped() != 0 {
+				logWarningf(logFields{"count": observers.Dropped()}, "%d frames not passed to observers that fell behind", observers.Dropped())
+			}
+			logStatus(status)
 		case <-signalCh:
+			logInfof(nil, "Shutting down, waiting up to %s for buffered audio to play out", *drainWait)
 			frameTimer.Stop()
 			close(rawFrameChan)
+			if rawFrameChan2 != nil {
+				close(rawFrameChan2)
+			}
+			signalCh = nil
+			drainTimeout = time.After(*drainWait)
+		case <-encoderDrained:
+			logInfof(nil, "Encoder drained, waiting for audio device to finish writing")
+			encoderDrained = nil
+		case err, more := <-mtcDone:
+			if err != nil {
+				logWarningf(logFields{"error": err.Error()}, "Error sending MIDI timecode: %v", err)
+			}
+			if !more {
+				mtcWriter = nil
+				mtcDone = nil
+			}
+		case err, more := <-serialDone:
+			if err != nil {
+				logWarningf(logFields{"error": err.Error()}, "Error writing serial timecode: %v", err)
+			}
+			if !more {
+				serialWriter = nil
+				serialDone = nil
+			}
+		case <-drainTimeout:
+			logWarningf(nil, "Timed out waiting for buffered audio to play out, final frame may be truncated")
+			logStatus(status)
+			glog.Flush()
+			os.Exit(1)
 		case err, more := <-streamDevice.Done():
 			if err != nil {
-				glog.Infof("Error streaming data: %v", err)
+				logWarningf(logFields{"error": err.Error()}, "Error streaming data: %v", err)
 			}
 
 			if !more {
-				glog.Infof("%v", status)
-				glog.Info("Exiting")
+				logStatus(status)
+				logInfof(nil, "Exiting")
+				glog.Flush()
 				os.Exit(0)
 			}
 		}
 	}
 
 }
+
+// frameBytes is the length of an encoded frame
+const frameBytes = glitc.FrameBytes
+
+// sendEncoded encodes frame into binaryFrame and queues it for the manchester encoder, reversing the
+// bits if the timecode is running backwards.  binaryFrame is reused for every frame so encoding
+// doesn't allocate.
+func sendEncoded(rawFrameChan chan<- byte, binaryFrame []byte, frame glitc.LTCFrame, reverse bool) {
+	frame.EncodeFrameInto(binaryFrame)
+	if reverse {
+		binaryFrame = glitc.ReverseFrame(binaryFrame)
+	}
+	for _, b := range binaryFrame {
+		rawFrameChan <- b
+	}
+}
+
+// checkSampleClock returns an error if -sample-clock is used with options that take timecode away
+// from the sample position
+func checkSampleClock() error {
+	switch {
+	case *freeRun || *reverse || *monotonic:
+		return fmt.Errorf("-sample-clock can't be used with -free-run, -reverse or -monotonic")
+	case *lookAhead != 0:
+		return fmt.Errorf("-sample-clock can't be used with -look-ahead, frames are already encoded ahead of the device")
+	case *grpcAddr != "":
+		return fmt.Errorf("-sample-clock can't be used with -grpc-addr")
+	}
+	return nil
+}
+
+// checkStaticTimeCode returns an error if -static-tc is used with options that need timecode to count
+func checkStaticTimeCode() error {
+	switch {
+	case *freeRun || *reverse || *monotonic:
+		return fmt.Errorf("-static-tc can't be used with -free-run, -reverse or -monotonic")
+	case *sampleClock || *chaseDevice != "":
+		return fmt.Errorf("-static-tc can't be used with -sample-clock or -chase")
+	case *leadIn > 0 || *selfTestFlag:
+		return fmt.Errorf("-static-tc can't be used with -lead-in or -self-test")
+	}
+	return nil
+}
+
+// sendSecondary encodes the second generator's frames that begin while primary is playing, it does
+// nothing without a second generator
+func sendSecondary(rawFrameChan chan<- byte, binaryFrame []byte, secondary *secondaryGenerator, primary glitc.LTCFrame) {
+	if secondary == nil {
+		return
+	}
+	for _, frame := range secondary.Follow(primary) {
+		sendEncoded(rawFrameChan, binaryFrame, frame, false)
+	}
+}
+
+// selectChannelModes returns the output channel modes, set by -channels or by -output-channels and
+// -ltc-channel
+func selectChannelModes() ([]ChannelMode, error) {
+	if *outChannels == 0 {
+		return ParseChannelModes(*channels)
+	}
+	if *channels != "signal" {
+		return nil, fmt.Errorf("-output-channels can't be used with -channels")
+	}
+	return ltcChannelModes(*outChannels, *ltcChannel)
+}
+
+// checkSecondaryGenerator returns an error if a second generator can't be used with the other
+// options, its samples are only interleaved by the pipe based backends
+func checkSecondaryGenerator(channelModes []ChannelMode) error {
+	if generatorCount(channelModes) < 2 {
+		return fmt.Errorf("-rate2 needs a signal2 or inverted2 channel in -channels")
+	}
+	switch {
+	case *reverse:
+		return fmt.Errorf("-rate2 can't be used with -reverse")
+	case *outputFile != "" || *selfTestFlag:
+		return fmt.Errorf("-rate2 can't be used with -output or -self-test")
+	case *backend != "pulse" && !(*backend == "alsa" && *deviceName != "") && !*dryRun:
+		return fmt.Errorf("-rate2 needs -audio-backend pulse or -device")
+	}
+	return nil
+}
+
+// logStatus logs status as text, or as a single line of JSON with -status-json.  When logging JSON
+// the snapshot is a field of the message.
+func logStatus(status *Status) {
+	if jsonLog != nil {
+		logInfof(logFields{"status": status.Snapshot()}, "status")
+		return
+	}
+	if !*statusJSON {
+		logInfof(nil, "%s", status)
+		return
+	}
+	b, err := json.Marshal(status.Snapshot())
+	if err != nil {
+		logWarningf(logFields{"error": err.Error()}, "Unable to encode status: %v", err)
+		return
+	}
+	logInfof(nil, "%s", b)
+}
+
+// renderStartTime returns the time of day set by -start today, or the current time plus -offset if it
+// isn't set
+func renderStartTime() (time.Time, error) {
+	now := time.Now()
+	if *startTime == "" {
+		return now.Add(*offset), nil
+	}
+	start, err := time.ParseInLocation("15:04:05", *startTime, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start time: %v", err)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), start.Hour(), start.Minute(), start.Second(), 0, time.Local), nil
+}
+
+// renderSampleRate returns the sample rate used when not playing through an audio device, the first
+// of the preferred rates
+func renderSampleRate(cfgFile *viper.Viper) (int, error) {
+	preferred, err := configSampleRates(cfgFile)
+	if err != nil {
+		return 0, err
+	}
+	return negotiateSampleRate(preferred, nil)
+}
+
+// render writes duration worth of LTC to outputFile as fast as it can be encoded.  With an outputFile
+// of - raw samples are streamed to stdout in real time, unless -no-realtime is set, and a duration of
+// 0 streams until ctx is cancelled.  If static is set every frame holds that timecode.
+func render(ctx context.Context, cfgFile *viper.Viper, frame glitc.LTCFrame, channelModes []ChannelMode, amplitude float64, static *glitc.TimeCode) error {
+	var err error
+	if frame.Time, err = renderStartTime(); err != nil {
+		return err
+	}
+	if static != nil {
+		frame.SetTimeCode(*static, frame.Time)
+	}
+	start := frame.Frame()
+	if *leadIn > 0 {
+		// start in the middle of the first lead-in frame so frame boundaries are well clear of rounding
+		frame.Time = frame.FrameBeginTime().Add(frame.FrameDuration() / 2).Add(-time.Duration(*leadIn) * frame.FrameDuration())
+	}
+	sampleRate, err := renderSampleRate(cfgFile)
+	if err != nil {
+		return err
+	}
+
+	bitsPerSample, float, err := ParseSampleFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	config := WAVConfig{
+		SampleRate:    sampleRate,
+		BitsPerSample: bitsPerSample,
+		Float:         float,
+		Channels:      len(channelModes),
+		ChannelModes:  channelModes,
+	}
+	toStdout := *outputFile == "-"
+	dest := *outputFile
+	var wavWriter *WAVWriter
+	if toStdout {
+		dest = "stdout"
+		wavWriter, err = NewPCMWriter(ctx, os.Stdout, config)
+	} else {
+		if *duration <= 0 {
+			return fmt.Errorf("-duration must be positive when writing to a file")
+		}
+		wavWriter, err = CreateWAVFile(ctx, *outputFile, config)
+	}
+	if err != nil {
+		return err
+	}
+	if *duration > 0 {
+		logInfof(nil, "Writing %s of timecode starting at %s to %s -- %s", *duration, frame.Frame(), dest, wavWriter.Config())
+	} else {
+		logInfof(nil, "Writing timecode starting at %s to %s until interrupted -- %s", frame.Frame(), dest, wavWriter.Config())
+	}
+
+	rawFrameChan := make(chan byte, 160)
+	if err := checkSampleRate(frame, float64(sampleRate)); err != nil {
+		return err
+	}
+	samplesPerFrame := int(math.Ceil(frame.SamplesPerFrame(float64(sampleRate))))
+	encodedData := encoding.DifferentialManchester(ctx,
+		3*samplesPerFrame,
+		frame.EffectiveFPS()*80,
+		amplitude,
+		float64(sampleRate),
+		rawFrameChan)
+
+	streamCh := wavWriter.Stream()
+	shaper := newSlewLimiter(riseTime(), float64(sampleRate), amplitude)
+	outputPolarity := polarity(*invert)
+	go func() {
+		for sample := range encodedData {
+			streamCh <- []stream.Sample{outputPolarity.Apply(shaper.Apply(sample))}
+		}
+		close(streamCh)
+	}()
+
+	frames := int(duration.Seconds()*frame.EffectiveFPS()) + *leadIn
+	if *leadIn > 0 {
+		logInfof(nil, "Writing %d lead-in frames, timecode is authoritative from %s", *leadIn, start)
+	}
+	realtime := toStdout && !*noRealtime
+	begin := time.Now()
+	sent := 0
+frames:
+	for ; *duration <= 0 || sent < frames; sent++ {
+		if realtime {
+			// hold each frame back until the one before it has had time to play
+			select {
+			case <-time.After(time.Until(begin.Add(time.Duration(sent) * frame.FrameDuration()))):
+			case <-ctx.Done():
+				break frames
+			}
+		}
+		for _, b := range frame.EncodeFrame() {
+			select {
+			case rawFrameChan <- b:
+			case <-ctx.Done():
+				break frames
+			}
+		}
+		if static == nil {
+			frame.Time = frame.Time.Add(frame.FrameDuration())
+		}
+	}
+	close(rawFrameChan)
+
+	for err := range wavWriter.Done() {
+		if err != nil {
+			return err
+		}
+	}
+	logInfof(logFields{"frames": sent, "file": dest}, "Wrote %d frames to %s", sent, dest)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDBFSAmplitude(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		DBFS              float64
+		ExpectedAmplitude float64
+		ExpectError       bool
+	}{
+		{"FullScale", 0, 1, false},
+		{"-6dBFS", -6, 0.501187, false},
+		{"-12dBFS", -12, 0.251189, false},
+		{"-20dBFS", -20, 0.1, false},
+		{"Positive", 1, 0, true},
+		{"NaN", math.NaN(), 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			amplitude, err := dbfsAmplitude(c.DBFS)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error for %f dBFS", c.DBFS)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(amplitude-c.ExpectedAmplitude) > 1e-6 {
+				st.Errorf("Incorrect amplitude: got %f expected %f", amplitude, c.ExpectedAmplitude)
+			}
+		})
+	}
+}
+
+func TestRateWindowLen(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		FPS         float64
+		Window      time.Duration
+		ExpectedLen int
+		ExpectError bool
+	}{
+		{"2m@30", 30, 2 * time.Minute, 3600, false},
+		{"2m@29.97", 29.97, 2 * time.Minute, 3596, false},
+		{"1s@25", 25, time.Second, 25, false},
+		{"TwoFrames", 30, 67 * time.Millisecond, 2, false},
+		{"OneFrame", 30, 40 * time.Millisecond, 0, true},
+		{"Zero", 30, 0, 0, true},
+		{"Negative", 30, -time.Minute, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			n, err := rateWindowLen(c.FPS, c.Window)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error for %s at %f fps", c.Window, c.FPS)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unexpected error: %v", err)
+			}
+			if n != c.ExpectedLen {
+				st.Errorf("Incorrect window length: got %d expected %d", n, c.ExpectedLen)
+			}
+		})
+	}
+}
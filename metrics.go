@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// statusCollector exports a Status snapshot as prometheus metrics each time it is scraped
+type statusCollector struct {
+	status      *Status
+	sent        *prometheus.Desc
+	dropped     *prometheus.Desc
+	duplicate   *prometheus.Desc
+	largeOffset *prometheus.Desc
+	outside     *prometheus.Desc
+	fps         *prometheus.Desc
+	offset      *prometheus.Desc
+	skew        *prometheus.Desc
+	outputDelay *prometheus.Desc
+	correction  *prometheus.Desc
+	xruns       *prometheus.Desc
+	reconnects  *prometheus.Desc
+	paused      *prometheus.Desc
+	muted       *prometheus.Desc
+	buffered    *prometheus.Desc
+}
+
+func newStatusCollector(status *Status) *statusCollector {
+	return &statusCollector{
+		status:      status,
+		sent:        prometheus.NewDesc("ltcgen_frames_sent_total", "Frames sent to the audio device", nil, nil),
+		dropped:     prometheus.NewDesc("ltcgen_frames_dropped_total", "Frames skipped because the frame timer fired late", nil, nil),
+		duplicate:   prometheus.NewDesc("ltcgen_frames_duplicate_total", "Frames not sent because they would have repeated the previous frame", nil, nil),
+		largeOffset: prometheus.NewDesc("ltcgen_frames_large_offset_total", "Frames sent more than 1ms after the frame start", nil, nil),
+		outside:     prometheus.NewDesc("ltcgen_frames_outside_window_total", "Frames whose offset fell outside the output buffer window", nil, nil),
+		fps:         prometheus.NewDesc("ltcgen_frames_per_second", "Average frame rate over the rate window", nil, nil),
+		offset:      prometheus.NewDesc("ltcgen_frame_offset_seconds", "Offset between frame start and frame send time", []string{"stat"}, nil),
+		skew:        prometheus.NewDesc("ltcgen_clock_skew_seconds", "How far the timecode of each frame led the wall clock as it started playing, after output delay compensation", []string{"stat"}, nil),
+		outputDelay: prometheus.NewDesc("ltcgen_output_delay_seconds", "Output delay compensated for when scheduling frames", nil, nil),
+		correction:  prometheus.NewDesc("ltcgen_output_delay_correction_seconds", "Change in the output delay from the estimate at startup made by measuring it", nil, nil),
+		xruns:       prometheus.NewDesc("ltcgen_xruns_total", "Audio device underruns, each one corrupts the LTC being played", nil, nil),
+		reconnects:  prometheus.NewDesc("ltcgen_audio_reconnects_total", "Times the audio device was reopened after failing", nil, nil),
+		paused:      prometheus.NewDesc("ltcgen_paused", "1 while timecode is held on a single frame", nil, nil),
+		muted:       prometheus.NewDesc("ltcgen_muted", "1 while the output is silenced and timecode keeps counting", nil, nil),
+		buffered:    prometheus.NewDesc("ltcgen_lookahead_buffered_frames", "Frames waiting in the look-ahead buffer when the frame timer fired", nil, nil),
+	}
+}
+
+func (c *statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sent
+	ch <- c.dropped
+	ch <- c.duplicate
+	ch <- c.largeOffset
+	ch <- c.outside
+	ch <- c.fps
+	ch <- c.offset
+	ch <- c.skew
+	ch <- c.outputDelay
+	ch <- c.correction
+	ch <- c.xruns
+	ch <- c.reconnects
+	ch <- c.paused
+	ch <- c.muted
+	ch <- c.buffered
+}
+
+func (c *statusCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.status.Snapshot()
+	ch <- prometheus.MustNewConstMetric(c.sent, prometheus.CounterValue, float64(s.Sent))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.duplicate, prometheus.CounterValue, float64(s.Duplicate))
+	ch <- prometheus.MustNewConstMetric(c.largeOffset, prometheus.CounterValue, float64(s.LargeOffset))
+	ch <- prometheus.MustNewConstMetric(c.outside, prometheus.CounterValue, float64(s.OutsideWindow))
+	ch <- prometheus.MustNewConstMetric(c.fps, prometheus.GaugeValue, s.FPS)
+	ch <- prometheus.MustNewConstMetric(c.offset, prometheus.GaugeValue, s.OffsetMin.Seconds(), "min")
+	ch <- prometheus.MustNewConstMetric(c.offset, prometheus.GaugeValue, s.OffsetMean.Seconds(), "mean")
+	ch <- prometheus.MustNewConstMetric(c.offset, prometheus.GaugeValue, s.OffsetStdDev.Seconds(), "stddev")
+	ch <- prometheus.MustNewConstMetric(c.offset, prometheus.GaugeValue, s.OffsetMax.Seconds(), "max")
+	ch <- prometheus.MustNewConstMetric(c.skew, prometheus.GaugeValue, s.SkewMin.Seconds(), "min")
+	ch <- prometheus.MustNewConstMetric(c.skew, prometheus.GaugeValue, s.SkewMean.Seconds(), "mean")
+	ch <- prometheus.MustNewConstMetric(c.skew, prometheus.GaugeValue, s.SkewStdDev.Seconds(), "stddev")
+	ch <- prometheus.MustNewConstMetric(c.skew, prometheus.GaugeValue, s.SkewMax.Seconds(), "max")
+	ch <- prometheus.MustNewConstMetric(c.outputDelay, prometheus.GaugeValue, s.OutputDelay.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.correction, prometheus.GaugeValue, s.DelayCorrection.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.xruns, prometheus.CounterValue, float64(s.Xruns))
+	ch <- prometheus.MustNewConstMetric(c.reconnects, prometheus.CounterValue, float64(s.Reconnects))
+	paused := 0.0
+	if s.Paused {
+		paused = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.paused, prometheus.GaugeValue, paused)
+	muted := 0.0
+	if s.Muted {
+		muted = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.muted, prometheus.GaugeValue, muted)
+	ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(s.Buffered))
+}
+
+// metricsHandler returns an http.Handler serving status in the prometheus exposition format
+func metricsHandler(status *Status) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newStatusCollector(status))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// serveHTTP starts an HTTP server on addr serving each handler at its path, e.g. /metrics
+func serveHTTP(addr string, handlers map[string]http.Handler) {
+	mux := http.NewServeMux()
+	for path, handler := range handlers {
+		mux.Handle(path, handler)
+		logInfof(logFields{"addr": addr, "path": path}, "Serving %s on %s", path, addr)
+	}
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logWarningf(logFields{"error": err.Error()}, "HTTP server on %s stopped: %v", addr, err)
+		}
+	}()
+}
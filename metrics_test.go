@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	status := NewStatus(10)
+	status.Sent(500 * time.Microsecond)
+	status.Sent(2 * time.Millisecond)
+	status.Skew(-300 * time.Microsecond)
+	status.Dropped(3)
+	status.Duplicate()
+	status.OutsideWindow()
+	status.SetOutputDelay(20 * time.Millisecond)
+	status.SetDelayCorrection(-5 * time.Millisecond)
+	status.SetXruns(2)
+	status.SetReconnects(1)
+	status.SetBuffered(3)
+
+	recorder := httptest.NewRecorder()
+	metricsHandler(status).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+
+	for _, expected := range []string{
+		"ltcgen_frames_sent_total 2",
+		"ltcgen_frames_dropped_total 3",
+		"ltcgen_frames_duplicate_total 1",
+		"ltcgen_frames_large_offset_total 1",
+		"ltcgen_frames_outside_window_total 1",
+		`ltcgen_frame_offset_seconds{stat="min"} 0.0005`,
+		`ltcgen_frame_offset_seconds{stat="max"} 0.002`,
+		`ltcgen_clock_skew_seconds{stat="mean"} -0.0003`,
+		"ltcgen_output_delay_seconds 0.02",
+		"ltcgen_output_delay_correction_seconds -0.005",
+		"ltcgen_xruns_total 2",
+		"ltcgen_audio_reconnects_total 1",
+		"ltcgen_lookahead_buffered_frames 3",
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("Metrics output missing '%s':\n%s", expected, body)
+		}
+	}
+}
+
+func TestMetricsHandlerNoFrames(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	metricsHandler(NewStatus(10)).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if recorder.Code != 200 {
+		t.Errorf("Unexpected status code scraping empty status: %d", recorder.Code)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/audio/stream/encoding"
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// mixConfig returns the format of the file written by mixing LTC onto channel ltcChannel of program
+// audio in format in.  LTC replaces the program audio on that channel, or is added as a new last
+// channel if ltcChannel is the number of program channels.
+func mixConfig(in WAVConfig, ltcChannel int) (WAVConfig, error) {
+	if ltcChannel < 0 || ltcChannel > in.Channels {
+		return WAVConfig{}, fmt.Errorf("LTC channel %d is out of range for %d program channels, expected 0-%d", ltcChannel, in.Channels, in.Channels)
+	}
+	out := in
+	out.Interleaved = true
+	if ltcChannel == in.Channels {
+		out.Channels++
+	}
+	return out, nil
+}
+
+// mixLTC copies each sample frame from program to w with the next sample from ltc on channel
+// ltcChannel, until program ends.  The stream of w is closed once program has been copied and the
+// number of sample frames written is returned.
+func mixLTC(program *WAVReader, w *WAVWriter, ltcChannel int, ltc <-chan stream.Sample) (int, error) {
+	channels := w.Config().Channels
+	frames := 0
+	var err error
+	for {
+		var in []stream.Sample
+		if in, err = program.Read(); err != nil {
+			break
+		}
+		sample, more := <-ltc
+		if !more {
+			err = fmt.Errorf("LTC encoder stopped after %d samples", frames)
+			break
+		}
+
+		out := make([]stream.Sample, channels)
+		copy(out, in)
+		out[ltcChannel] = sample
+		w.Stream() <- out
+		frames++
+	}
+	close(w.Stream())
+
+	for writeErr := range w.Done() {
+		if writeErr != nil {
+			return frames, writeErr
+		}
+	}
+	if err != io.EOF {
+		return frames, err
+	}
+	return frames, nil
+}
+
+// mixCommand writes a copy of a WAV file with LTC starting at a timecode on one of its channels, e.g.
+// to print timecode onto program audio.  LTC is generated at the sample rate of the program audio.
+func mixCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("mix", flag.ContinueOnError)
+	flags.SetOutput(out)
+	inFlag := flags.String("in", "", "WAV file holding the program audio")
+	outFlag := flags.String("out", "", "WAV file to write")
+	tcFlag := flags.String("tc", "", "Timecode at the first sample, hh:mm:ss:ff or hh:mm:ss;ff for drop frame")
+	fps := flags.Float64("fps", 30, "Frame rate: "+listFPS(glitc.SupportedRates, "or"))
+	ltcChannel := flags.Int("ltc-channel", 0, "Channel to write LTC on, replacing the program audio, or the number of program channels to add a channel")
+	sampleRate := flags.Int("sample-rate", 0, "Sample rate LTC is expected at, it is an error if the program audio differs, 0 accepts any rate")
+	level := flags.Float64("level-dbfs", -12, "Peak LTC level in dBFS")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *inFlag == "" || *outFlag == "" {
+		return fmt.Errorf("both -in and -out are required")
+	}
+
+	tc, err := glitc.ParseTimeCode(*tcFlag)
+	if err != nil {
+		return err
+	}
+	frame, err := frameForRate(*fps, tc.DropFrame)
+	if err != nil {
+		return err
+	}
+	frame.Time = time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+	if _, err := frame.EncodeTimeCode(tc); err != nil {
+		return err
+	}
+	frame.SetTimeCode(tc, frame.Time)
+	amplitude, err := dbfsAmplitude(*level)
+	if err != nil {
+		return err
+	}
+
+	program, err := OpenWAVFile(*inFlag)
+	if err != nil {
+		return err
+	}
+	defer program.Close()
+	if *sampleRate != 0 && program.Config().SampleRate != *sampleRate {
+		return fmt.Errorf("%s is sampled at %d Hz but -sample-rate is %d Hz, resample the program audio first",
+			*inFlag, program.Config().SampleRate, *sampleRate)
+	}
+	config, err := mixConfig(program.Config(), *ltcChannel)
+	if err != nil {
+		return err
+	}
+	rate := float64(config.SampleRate)
+	if err := checkSampleRate(frame, rate); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := CreateWAVFile(ctx, *outFlag, config)
+	if err != nil {
+		return err
+	}
+
+	rawFrameChan := make(chan byte, 160)
+	encodedData := encoding.DifferentialManchester(ctx,
+		3*int(math.Ceil(frame.SamplesPerFrame(rate))),
+		frame.EffectiveFPS()*80,
+		amplitude,
+		rate,
+		rawFrameChan)
+	go func() {
+		defer close(rawFrameChan)
+		for {
+			for _, b := range frame.EncodeFrame() {
+				select {
+				case rawFrameChan <- b:
+				case <-ctx.Done():
+					return
+				}
+			}
+			frame.Time = frame.Time.Add(frame.FrameDuration())
+		}
+	}()
+
+	frames, err := mixLTC(program, w, *ltcChannel, encodedData)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote %s of LTC from %s on channel %d to %s -- %s\n",
+		time.Duration(float64(frames)/rate*float64(time.Second)), tc, *ltcChannel, *outFlag, config)
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/ltcgen/biphase"
+	"github.com/go-test/deep"
+)
+
+func TestMixConfig(t *testing.T) {
+	in := WAVConfig{SampleRate: 48000, BitsPerSample: 24, Channels: 2, Interleaved: true}
+	testCases := []struct {
+		Name        string
+		Channel     int
+		Channels    int
+		ExpectError bool
+	}{
+		{"Replace", 1, 2, false},
+		{"Add", 2, 3, false},
+		{"Negative", -1, 0, true},
+		{"OutOfRange", 3, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			out, err := mixConfig(in, c.Channel)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if err == nil && (out.Channels != c.Channels || out.SampleRate != in.SampleRate || out.Format() != in.Format()) {
+				st.Errorf("Expected %d channels in format %s, got %s", c.Channels, in, out)
+			}
+		})
+	}
+}
+
+func TestMixLTC(t *testing.T) {
+	ltc, err := biphase.Encode([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0x3F, 0xFD}, 2, 1<<30)
+	if err != nil {
+		t.Fatalf("Unable to encode frame: %v", err)
+	}
+
+	testCases := []struct {
+		Name    string
+		Channel int
+	}{
+		{"Replace", 0},
+		{"Add", 2},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			dir, err := ioutil.TempDir("", "ltcgen")
+			if err != nil {
+				st.Fatalf("Unable to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			in := WAVConfig{SampleRate: 48000, BitsPerSample: 32, Channels: 2, Interleaved: true}
+			var program [][]stream.Sample
+			for i := 0; i < 100; i++ {
+				program = append(program, []stream.Sample{stream.Sample(i), stream.Sample(-i)})
+			}
+			inPath, outPath := filepath.Join(dir, "in.wav"), filepath.Join(dir, "out.wav")
+			writeTestWAV(st, inPath, in, program)
+
+			r, err := OpenWAVFile(inPath)
+			if err != nil {
+				st.Fatalf("Unable to open wav file: %v", err)
+			}
+			defer r.Close()
+			config, err := mixConfig(r.Config(), c.Channel)
+			if err != nil {
+				st.Fatalf("Unable to configure mix: %v", err)
+			}
+			w, err := CreateWAVFile(context.Background(), outPath, config)
+			if err != nil {
+				st.Fatalf("Unable to create wav file: %v", err)
+			}
+
+			samples := make(chan stream.Sample, len(ltc))
+			for _, sample := range ltc {
+				samples <- stream.Sample(sample)
+			}
+			frames, err := mixLTC(r, w, c.Channel, samples)
+			if err != nil {
+				st.Fatalf("Unable to mix: %v", err)
+			}
+			if frames != len(program) {
+				st.Errorf("Expected %d sample frames, got %d", len(program), frames)
+			}
+
+			var expected [][]stream.Sample
+			for i, frame := range program {
+				out := make([]stream.Sample, config.Channels)
+				copy(out, frame)
+				out[c.Channel] = stream.Sample(ltc[i])
+				expected = append(expected, out)
+			}
+			_, mixed := readTestWAV(st, outPath)
+			if diff := deep.Equal(mixed, expected); len(diff) > 0 {
+				st.Errorf("Mixed samples don't match: %v", diff)
+			}
+		})
+	}
+}
+
+func TestMixLTCEncoderStopped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := WAVConfig{SampleRate: 48000, BitsPerSample: 16, Channels: 1, Interleaved: true}
+	inPath := filepath.Join(dir, "in.wav")
+	writeTestWAV(t, inPath, in, [][]stream.Sample{{0}, {0}, {0}})
+	r, err := OpenWAVFile(inPath)
+	if err != nil {
+		t.Fatalf("Unable to open wav file: %v", err)
+	}
+	defer r.Close()
+	w, err := CreateWAVFile(context.Background(), filepath.Join(dir, "out.wav"), r.Config())
+	if err != nil {
+		t.Fatalf("Unable to create wav file: %v", err)
+	}
+
+	samples := make(chan stream.Sample, 1)
+	samples <- 1
+	close(samples)
+	if _, err := mixLTC(r, w, 0, samples); err == nil {
+		t.Errorf("Expected an error when LTC runs out before the program audio")
+	}
+}
+
+func TestMixCommandSampleRate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	inPath, outPath := filepath.Join(dir, "in.wav"), filepath.Join(dir, "out.wav")
+	writeTestWAV(t, inPath, WAVConfig{SampleRate: 44100, BitsPerSample: 16, Channels: 2}, [][]stream.Sample{{0, 0}})
+
+	var out bytes.Buffer
+	err = mixCommand([]string{"-in", inPath, "-out", outPath, "-tc", "01:00:00:00", "-fps", "25", "-sample-rate", "48000"}, &out)
+	if err == nil {
+		t.Fatalf("Expected an error mixing 48000 Hz LTC onto 44100 Hz audio")
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no output file to be written")
+	}
+}
@@ -0,0 +1,173 @@
+// Package mtc generates MIDI Timecode from the same LTCFrame used to generate LTC
+package mtc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// Rate is the MTC frame rate code sent in the hours field
+type Rate byte
+
+const (
+	// Rate24 is 24fps, also used for 23.976fps
+	Rate24 Rate = iota
+	// Rate25 is 25fps
+	Rate25
+	// Rate2997DF is 29.97fps drop frame
+	Rate2997DF
+	// Rate30 is 30fps non drop frame, also used for 29.97fps non drop frame
+	Rate30
+)
+
+const (
+	quarterFrameStatus = 0xF1
+	quarterFrames      = 8
+)
+
+// RateFor returns the MTC rate used to send frame, MTC doesn't support rates above 30fps
+func RateFor(frame glitc.LTCFrame) (Rate, error) {
+	switch {
+	case frame.DropFrame && frame.FramesPerSecond == 30:
+		return Rate2997DF, nil
+	case frame.DropFrame:
+	case frame.FramesPerSecond == 24:
+		return Rate24, nil
+	case frame.FramesPerSecond == 25:
+		return Rate25, nil
+	case frame.FramesPerSecond == 30:
+		return Rate30, nil
+	}
+	return 0, fmt.Errorf("MTC doesn't support %f fps, dropframe: %v", frame.EffectiveFPS(), frame.DropFrame)
+}
+
+// QuarterFrame returns quarter frame message piece (0-7) of tc
+func QuarterFrame(tc glitc.TimeCode, rate Rate, piece int) []byte {
+	var value int
+	switch piece {
+	case 0:
+		value = tc.Frame & 0xF
+	case 1:
+		value = tc.Frame >> 4 & 0x1
+	case 2:
+		value = tc.Second & 0xF
+	case 3:
+		value = tc.Second >> 4 & 0x3
+	case 4:
+		value = tc.Minute & 0xF
+	case 5:
+		value = tc.Minute >> 4 & 0x3
+	case 6:
+		value = tc.Hour & 0xF
+	case 7:
+		value = tc.Hour>>4&0x1 | int(rate)<<1
+	}
+	return []byte{quarterFrameStatus, byte(piece<<4 | value)}
+}
+
+// FullFrame returns the full frame SysEx message for tc, sent when the timecode jumps
+func FullFrame(tc glitc.TimeCode, rate Rate) []byte {
+	return []byte{0xF0, 0x7F, 0x7F, 0x01, 0x01,
+		byte(int(rate)<<5 | tc.Hour), byte(tc.Minute), byte(tc.Second), byte(tc.Frame), 0xF7}
+}
+
+// Encoder converts a sequence of frames into MTC messages.  Quarter frame messages are sent four per
+// frame, so each set of eight describes the timecode of the frame the set started on.  A full frame
+// message is sent instead whenever the timecode doesn't follow on from the previous frame.
+type Encoder struct {
+	rate    Rate
+	started bool
+	next    glitc.TimeCode
+	current glitc.TimeCode
+	piece   int
+}
+
+// NewEncoder returns an encoder sending timecode at rate
+func NewEncoder(rate Rate) *Encoder {
+	return &Encoder{rate: rate}
+}
+
+// Encode returns the messages to send for frame
+func (e *Encoder) Encode(frame glitc.LTCFrame) [][]byte {
+	tc := frame.Frame()
+	defer func() {
+		e.next = tc.Add(1, frame.FramesPerSecond)
+	}()
+
+	if !e.started || tc != e.next {
+		e.started = true
+		e.piece = 0
+		return [][]byte{FullFrame(tc, e.rate)}
+	}
+
+	if e.piece == 0 {
+		e.current = tc
+	}
+	messages := make([][]byte, 0, quarterFrames/2)
+	for i := 0; i < quarterFrames/2; i++ {
+		messages = append(messages, QuarterFrame(e.current, e.rate, e.piece))
+		e.piece = (e.piece + 1) % quarterFrames
+	}
+	return messages
+}
+
+// Writer sends MTC for frames passed to WriteFrame, spacing quarter frame messages a quarter of a frame apart
+type Writer struct {
+	out     io.Writer
+	encoder *Encoder
+	frames  chan glitc.LTCFrame
+	doneCh  chan error
+}
+
+// NewWriter starts writing MTC messages to out, typically a raw MIDI device such as /dev/snd/midiC1D0.
+// Writing stops once ctx is cancelled.
+func NewWriter(ctx context.Context, out io.Writer, rate Rate) *Writer {
+	w := &Writer{
+		out:     out,
+		encoder: NewEncoder(rate),
+		frames:  make(chan glitc.LTCFrame, 2),
+		doneCh:  make(chan error, 1),
+	}
+	go w.write(ctx)
+	return w
+}
+
+// WriteFrame queues the MTC messages for frame, it should be called as each frame starts.  Frames are
+// dropped if the writer falls behind, the following frame is then sent as a full frame message.
+func (w *Writer) WriteFrame(frame glitc.LTCFrame) {
+	select {
+	case w.frames <- frame:
+	default:
+	}
+}
+
+// Done returns a channel that receives any write error and is closed once writing stops
+func (w *Writer) Done() chan error {
+	return w.doneCh
+}
+
+func (w *Writer) write(ctx context.Context) {
+	defer close(w.doneCh)
+
+	for {
+		select {
+		case frame := <-w.frames:
+			spacing := frame.FrameDuration() / 4
+			for i, message := range w.encoder.Encode(frame) {
+				if i != 0 {
+					time.Sleep(spacing)
+				}
+				if _, err := w.out.Write(message); err != nil {
+					w.doneCh <- err
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package mtc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+)
+
+func TestRateFor(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Frame    glitc.LTCFrame
+		Expected Rate
+		Error    bool
+	}{
+		{"24fps", glitc.LTCFrame{FramesPerSecond: 24}, Rate24, false},
+		{"23.976fps", glitc.LTCFrame{FramesPerSecond: 24, PullDown: true}, Rate24, false},
+		{"25fps", glitc.LTCFrame{FramesPerSecond: 25}, Rate25, false},
+		{"29.97fps/df", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, Rate2997DF, false},
+		{"30fps", glitc.LTCFrame{FramesPerSecond: 30}, Rate30, false},
+		{"50fps", glitc.LTCFrame{FramesPerSecond: 50}, 0, true},
+		{"60fps", glitc.LTCFrame{FramesPerSecond: 60}, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			rate, err := RateFor(c.Frame)
+			if c.Error {
+				if err == nil {
+					st.Errorf("Expected error, got rate %d", rate)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unexpected error: %v", err)
+			}
+			if rate != c.Expected {
+				st.Errorf("Expected rate %d, got %d", c.Expected, rate)
+			}
+		})
+	}
+}
+
+func TestFullFrame(t *testing.T) {
+	tc := glitc.TimeCode{Hour: 23, Minute: 14, Second: 21, Frame: 29, DropFrame: true}
+	expected := []byte{0xF0, 0x7F, 0x7F, 0x01, 0x01, 0x57, 14, 21, 29, 0xF7}
+	if diff := deep.Equal(FullFrame(tc, Rate2997DF), expected); len(diff) > 0 {
+		t.Error("Full frame message doesn't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 30}
+	frame.SetTimeCode(glitc.TimeCode{Hour: 23, Minute: 14, Second: 21}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	e := NewEncoder(Rate30)
+
+	var messages [][]byte
+	for i := 0; i < 3; i++ {
+		messages = append(messages, e.Encode(frame)...)
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+
+	// 23:14:21:00 full frame, then quarter frames for 23:14:21:01 over the next two frames
+	expected := [][]byte{
+		{0xF0, 0x7F, 0x7F, 0x01, 0x01, 0x77, 14, 21, 0, 0xF7},
+		{0xF1, 0x01}, {0xF1, 0x10}, {0xF1, 0x25}, {0xF1, 0x31},
+		{0xF1, 0x4E}, {0xF1, 0x50}, {0xF1, 0x67}, {0xF1, 0x77},
+	}
+	if diff := deep.Equal(messages, expected); len(diff) > 0 {
+		t.Error("Messages don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	// a jump restarts the sequence with a full frame message
+	frame.Time = frame.Time.Add(time.Second)
+	if diff := deep.Equal(e.Encode(frame), [][]byte{FullFrame(frame.Frame(), Rate30)}); len(diff) > 0 {
+		t.Error("Expected full frame message after jump:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestEncoderDropFrame(t *testing.T) {
+	// 00:00:59;29 is followed by 00:01:00;02, which mustn't be treated as a jump
+	frame := glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	frame.SetTimeCode(glitc.TimeCode{Second: 59, Frame: 29, DropFrame: true}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	e := NewEncoder(Rate2997DF)
+	e.Encode(frame)
+	frame.Time = frame.Time.Add(frame.FrameDuration())
+
+	messages := e.Encode(frame)
+	if len(messages) != 4 || messages[0][0] != quarterFrameStatus {
+		t.Errorf("Expected quarter frame messages, got %x", messages)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out bytes.Buffer
+	frame := glitc.LTCFrame{Time: time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local), FramesPerSecond: 30}
+	w := NewWriter(ctx, &out, Rate30)
+	w.WriteFrame(frame)
+	cancel()
+	for err := range w.Done() {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
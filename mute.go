@@ -0,0 +1,36 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/azenk/audio/stream"
+)
+
+// muteSwitch silences the output without stopping the frame loop, so timecode carries on from the
+// right frame when it is unmuted.  It is safe to use from the sample copy goroutine and the frame
+// loop at the same time.
+type muteSwitch struct {
+	muted int32
+}
+
+// Set mutes or unmutes the output
+func (m *muteSwitch) Set(muted bool) {
+	var v int32
+	if muted {
+		v = 1
+	}
+	atomic.StoreInt32(&m.muted, v)
+}
+
+// Muted returns true while the output is silenced
+func (m *muteSwitch) Muted() bool {
+	return atomic.LoadInt32(&m.muted) == 1
+}
+
+// Apply returns the sample to output, silence while muted
+func (m *muteSwitch) Apply(sample stream.Sample) stream.Sample {
+	if m.Muted() {
+		return 0
+	}
+	return sample
+}
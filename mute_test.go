@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/ltcgen/biphase"
+	"github.com/azenk/ltcgen/glitc"
+)
+
+func TestMuteSwitch(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 25}
+	frameDuration := frame.FrameDuration()
+	start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration / 2)
+	frame.Time = start
+	clock := newFakeClock(start)
+	s := newFrameScheduler(clock, frame, 0, 0, NewStatus(100), false, nil, false, false)
+	// 25fps at 40kHz is 20 samples per bit
+	encoder, err := biphase.NewEncoder(40000, frame.EffectiveFPS()*80, 1000)
+	if err != nil {
+		t.Fatalf("Unable to create encoder: %v", err)
+	}
+
+	// frames 2 and 3 are muted
+	mute := &muteSwitch{}
+	prevIndex := s.Frame().FrameIndex() - 1
+	for i := 0; i < 5; i++ {
+		mute.Set(i == 2 || i == 3)
+		clock.Advance(frameDuration)
+		f, ok := s.Next(clock.Now())
+		if !ok {
+			t.Fatalf("Frame %d not sent", i)
+		}
+		if f.FrameIndex() != prevIndex+1 {
+			t.Errorf("Frame %d has index %d, expected %d", i, f.FrameIndex(), prevIndex+1)
+		}
+		prevIndex = f.FrameIndex()
+
+		var silent, loud int
+		for _, sample := range encoder.Encode(nil, f.EncodeFrame()) {
+			if mute.Apply(stream.Sample(sample)) == 0 {
+				silent++
+			} else {
+				loud++
+			}
+		}
+		if mute.Muted() && loud != 0 {
+			t.Errorf("Frame %d has %d samples while muted", i, loud)
+		}
+		if !mute.Muted() && silent != 0 {
+			t.Errorf("Frame %d has %d silent samples while unmuted", i, silent)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ntpEpochOffset is the number of seconds from the NTP epoch, 1900, to the unix epoch
+const ntpEpochOffset = 2208988800
+
+// ntpLeapAlarm is the leap indicator sent by servers that aren't synchronized
+const ntpLeapAlarm = 3
+
+// sntpResult is the clock quality measured by an SNTP query
+type sntpResult struct {
+	// Offset is how far the server's clock is ahead of ours
+	Offset time.Duration
+	// RoundTrip is the network delay to the server and back
+	RoundTrip time.Duration
+	// RootDelay and RootDispersion are the server's own estimates of its distance from the reference clock
+	RootDelay      time.Duration
+	RootDispersion time.Duration
+	Stratum        int
+}
+
+// ClockError returns the largest error the local clock could have, the offset plus the server's
+// distance from its reference clock plus half the round trip to the server
+func (r sntpResult) ClockError() time.Duration {
+	offset := r.Offset
+	if offset < 0 {
+		offset = -offset
+	}
+	return offset + r.RootDelay/2 + r.RootDispersion + r.RoundTrip/2
+}
+
+func (r sntpResult) String() string {
+	return fmt.Sprintf("offset %s, round trip %s, root delay %s, root dispersion %s, stratum %d",
+		r.Offset, r.RoundTrip, r.RootDelay, r.RootDispersion, r.Stratum)
+}
+
+// ntpTime converts a 64 bit NTP timestamp to a time
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	fraction := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(seconds, fraction*1e9>>32)
+}
+
+// putNTPTime writes t as a 64 bit NTP timestamp to b
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32(int64(t.Nanosecond())<<32/1e9))
+}
+
+// ntpShort converts a 32 bit NTP short format duration to a duration
+func ntpShort(b []byte) time.Duration {
+	return time.Duration(int64(binary.BigEndian.Uint32(b)) * int64(time.Second) >> 16)
+}
+
+// parseSNTPResponse measures the clock from a server's response to a request sent at sent and
+// received at received
+func parseSNTPResponse(b []byte, sent, received time.Time) (sntpResult, error) {
+	if len(b) < 48 {
+		return sntpResult{}, fmt.Errorf("short SNTP response, %d bytes", len(b))
+	}
+	leap, mode := b[0]>>6, b[0]&0x7
+	if mode != 4 {
+		return sntpResult{}, fmt.Errorf("unexpected SNTP mode %d in response", mode)
+	}
+	stratum := int(b[1])
+	if leap == ntpLeapAlarm || stratum == 0 || stratum > 15 {
+		return sntpResult{}, errors.New("NTP server isn't synchronized")
+	}
+
+	serverReceived, serverSent := ntpTime(b[32:40]), ntpTime(b[40:48])
+	return sntpResult{
+		Offset:         (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2,
+		RoundTrip:      received.Sub(sent) - serverSent.Sub(serverReceived),
+		RootDelay:      ntpShort(b[4:8]),
+		RootDispersion: ntpShort(b[8:12]),
+		Stratum:        stratum,
+	}, nil
+}
+
+// querySNTP measures the local clock against the NTP server at addr, usually the local chrony or
+// ntpd, whose root delay and dispersion say how well it is synchronized upstream
+func querySNTP(addr string, timeout time.Duration) (sntpResult, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return sntpResult{}, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return sntpResult{}, err
+	}
+
+	request := make([]byte, 48)
+	// leap indicator 0, version 4, client mode
+	request[0] = 0<<6 | 4<<3 | 3
+	sent := time.Now()
+	putNTPTime(request[40:48], sent)
+	if _, err := conn.Write(request); err != nil {
+		return sntpResult{}, err
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	if err != nil {
+		return sntpResult{}, err
+	}
+	received := time.Now()
+	if n >= 32 && string(response[24:32]) != string(request[40:48]) {
+		return sntpResult{}, errors.New("SNTP response doesn't match request")
+	}
+	return parseSNTPResponse(response[:n], sent, received)
+}
+
+// checkClockError returns an error if the clock error measured from the NTP server at addr is
+// over limit
+func checkClockError(addr string, limit time.Duration) (sntpResult, error) {
+	result, err := querySNTP(addr, 2*time.Second)
+	if err != nil {
+		return result, fmt.Errorf("unable to measure clock error from %s: %v", addr, err)
+	}
+	if clockError := result.ClockError(); clockError > limit {
+		return result, fmt.Errorf("clock error %s exceeds -max-clock-error %s (%s)", clockError, limit, result)
+	}
+	return result, nil
+}
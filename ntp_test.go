@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// serveSNTP answers one SNTP request on a local port from a clock ahead of ours by offset
+func serveSNTP(t *testing.T, offset time.Duration, leap byte, stratum byte) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	go func() {
+		defer conn.Close()
+		request := make([]byte, 48)
+		_, addr, err := conn.ReadFrom(request)
+		if err != nil {
+			return
+		}
+		response := make([]byte, 48)
+		response[0] = leap<<6 | 4<<3 | 4
+		response[1] = stratum
+		// 1ms root delay and 2ms root dispersion in 16.16 fixed point seconds
+		response[7] = 66
+		response[11] = 131
+		copy(response[24:32], request[40:48])
+		now := time.Now().Add(offset)
+		putNTPTime(response[32:40], now)
+		putNTPTime(response[40:48], now)
+		conn.WriteTo(response, addr)
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQuerySNTP(t *testing.T) {
+	addr := serveSNTP(t, 50*time.Millisecond, 0, 2)
+	result, err := querySNTP(addr, time.Second)
+	if err != nil {
+		t.Fatalf("Unable to query server: %v", err)
+	}
+	if result.Offset < 40*time.Millisecond || result.Offset > 60*time.Millisecond {
+		t.Errorf("Expected an offset of around 50ms, got %s", result.Offset)
+	}
+	if result.RootDelay < 1000*time.Microsecond || result.RootDelay > 1010*time.Microsecond {
+		t.Errorf("Expected a root delay of 1ms, got %s", result.RootDelay)
+	}
+	if result.RootDispersion < 1990*time.Microsecond || result.RootDispersion > 2010*time.Microsecond {
+		t.Errorf("Expected a root dispersion of 2ms, got %s", result.RootDispersion)
+	}
+	if result.Stratum != 2 {
+		t.Errorf("Expected stratum 2, got %d", result.Stratum)
+	}
+}
+
+func TestCheckClockError(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Offset      time.Duration
+		Leap        byte
+		Stratum     byte
+		ExpectError bool
+	}{
+		{"Synchronized", 0, 0, 2, false},
+		{"Behind", -100 * time.Millisecond, 0, 2, true},
+		{"Ahead", 100 * time.Millisecond, 0, 2, true},
+		{"Unsynchronized", 0, ntpLeapAlarm, 2, true},
+		{"KissOfDeath", 0, 0, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			addr := serveSNTP(st, c.Offset, c.Leap, c.Stratum)
+			if _, err := checkClockError(addr, 50*time.Millisecond); (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
+
+func TestSNTPResultClockError(t *testing.T) {
+	result := sntpResult{
+		Offset:         -3 * time.Millisecond,
+		RoundTrip:      2 * time.Millisecond,
+		RootDelay:      4 * time.Millisecond,
+		RootDispersion: time.Millisecond,
+	}
+	if clockError := result.ClockError(); clockError != 7*time.Millisecond {
+		t.Errorf("Expected a clock error of 7ms, got %s", clockError)
+	}
+}
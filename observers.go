@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// FrameObserver is called with the timecode of each frame sent and the time it was sent
+type FrameObserver func(tc glitc.TimeCode, sent time.Time)
+
+// frameObservers are the observers registered with RegisterFrameObserver
+var frameObservers []FrameObserver
+
+// RegisterFrameObserver adds fn to the observers called for every frame sent to the audio device,
+// e.g. to log timecode or trigger GPIO.  Call it from an init function in a file added to this
+// package, observers must be registered before main runs.
+func RegisterFrameObserver(fn FrameObserver) {
+	frameObservers = append(frameObservers, fn)
+}
+
+// observedFrame is a frame waiting to be passed to the observers
+type observedFrame struct {
+	tc   glitc.TimeCode
+	sent time.Time
+}
+
+// observerQueue calls observers from their own goroutine so they can't hold up the audio path.
+// Frames are dropped if the observers fall more than the queue length behind.
+type observerQueue struct {
+	observers []FrameObserver
+	frames    chan observedFrame
+	dropped   int64
+}
+
+// newObserverQueue starts calling observers for each frame passed to Notify until ctx is cancelled
+func newObserverQueue(ctx context.Context, observers []FrameObserver, queueLen int) *observerQueue {
+	q := &observerQueue{
+		observers: observers,
+		frames:    make(chan observedFrame, queueLen),
+	}
+	go q.run(ctx)
+	return q
+}
+
+// Notify queues a frame for the observers without blocking
+func (q *observerQueue) Notify(tc glitc.TimeCode, sent time.Time) {
+	select {
+	case q.frames <- observedFrame{tc, sent}:
+	default:
+		atomic.AddInt64(&q.dropped, 1)
+	}
+}
+
+// Dropped returns the number of frames the observers weren't called for because the queue was full
+func (q *observerQueue) Dropped() int64 {
+	return atomic.LoadInt64(&q.dropped)
+}
+
+func (q *observerQueue) run(ctx context.Context) {
+	for {
+		select {
+		case frame := <-q.frames:
+			for _, observer := range q.observers {
+				observer(frame.tc, frame.sent)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
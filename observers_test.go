@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+)
+
+func TestObserverQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sent := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+	received := make(chan glitc.TimeCode, 2)
+	release := make(chan struct{})
+	first := func(tc glitc.TimeCode, at time.Time) {
+		if !at.Equal(sent) {
+			t.Errorf("Expected sent time %s, got %s", sent, at)
+		}
+		// hold up the queue until the test has filled it
+		<-release
+		received <- tc
+	}
+	second := func(tc glitc.TimeCode, at time.Time) {
+		received <- tc
+	}
+	q := newObserverQueue(ctx, []FrameObserver{first, second}, 1)
+
+	// the first frame is taken by the blocked observer, the second waits in the queue and the third is dropped
+	tcs := []glitc.TimeCode{{Hour: 1}, {Hour: 2}, {Hour: 3}}
+	q.Notify(tcs[0], sent)
+	deadline := time.Now().Add(time.Second)
+	for len(q.frames) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	q.Notify(tcs[1], sent)
+	q.Notify(tcs[2], sent)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Notify blocked for %s", elapsed)
+	}
+	if q.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped frame, got %d", q.Dropped())
+	}
+
+	close(release)
+	var got []glitc.TimeCode
+	for i := 0; i < 4; i++ {
+		got = append(got, <-received)
+	}
+	expected := []glitc.TimeCode{tcs[0], tcs[0], tcs[1], tcs[1]}
+	if diff := deep.Equal(got, expected); len(diff) > 0 {
+		t.Error("Observed frames don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
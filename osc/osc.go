@@ -0,0 +1,112 @@
+// Package osc broadcasts the current timecode as Open Sound Control messages
+package osc
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// Address is the OSC address timecode messages are sent to
+const Address = "/timecode"
+
+// writeTimeout bounds how long a single send may block
+const writeTimeout = 10 * time.Millisecond
+
+// appendString appends s as an OSC string, null terminated and padded to a multiple of 4 bytes
+func appendString(b []byte, s string) []byte {
+	b = append(b, s...)
+	for n := 4 - len(s)%4; n > 0; n-- {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// Message returns the OSC message for tc, /timecode followed by the hours, minutes, seconds and
+// frames as int32 arguments
+func Message(tc glitc.TimeCode) []byte {
+	b := appendString(nil, Address)
+	b = appendString(b, ",iiii")
+	arg := make([]byte, 4)
+	for _, v := range []int{tc.Hour, tc.Minute, tc.Second, tc.Frame} {
+		binary.BigEndian.PutUint32(arg, uint32(int32(v)))
+		b = append(b, arg...)
+	}
+	return b
+}
+
+// Sender sends a timecode message for each frame passed to Send over UDP.  Messages are sent from
+// their own goroutine and dropped if the network falls behind, so Send never blocks.
+type Sender struct {
+	// dropped is updated atomically so it comes first to keep it 64-bit aligned on 32-bit platforms
+	dropped  int64
+	conn     net.PacketConn
+	addr     net.Addr
+	messages chan []byte
+}
+
+// NewSender returns a sender for addr, a host:port that may be a broadcast address.  Sending stops
+// once ctx is cancelled.
+func NewSender(ctx context.Context, addr string) (*Sender, error) {
+	raddr, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// allow sending to broadcast addresses
+	lc := net.ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		if err := c.Control(func(fd uintptr) {
+			sockErr = setBroadcast(fd)
+		}); err != nil {
+			return err
+		}
+		return sockErr
+	}}
+	conn, err := lc.ListenPacket(ctx, "udp4", ":0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sender{
+		conn:     conn,
+		addr:     raddr,
+		messages: make(chan []byte, 4),
+	}
+	go s.send(ctx)
+	return s, nil
+}
+
+// Send queues the timecode message for frame
+func (s *Sender) Send(frame glitc.LTCFrame) {
+	select {
+	case s.messages <- Message(frame.Frame()):
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+// Dropped returns the number of messages that couldn't be sent
+func (s *Sender) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+func (s *Sender) send(ctx context.Context) {
+	defer s.conn.Close()
+	for {
+		select {
+		case message := <-s.messages:
+			s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := s.conn.WriteTo(message, s.addr); err != nil {
+				atomic.AddInt64(&s.dropped, 1)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
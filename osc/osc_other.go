@@ -0,0 +1,9 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package osc
+
+// setBroadcast is only supported on unix, elsewhere messages can only be sent to unicast addresses
+func setBroadcast(fd uintptr) error {
+	return nil
+}
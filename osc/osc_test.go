@@ -0,0 +1,65 @@
+package osc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+)
+
+func TestMessage(t *testing.T) {
+	expected := []byte{
+		'/', 't', 'i', 'm', 'e', 'c', 'o', 'd', 'e', 0, 0, 0,
+		',', 'i', 'i', 'i', 'i', 0, 0, 0,
+		0, 0, 0, 23,
+		0, 0, 0, 14,
+		0, 0, 0, 21,
+		0, 0, 0, 29,
+	}
+	tc := glitc.TimeCode{Hour: 23, Minute: 14, Second: 21, Frame: 29}
+	if diff := deep.Equal(Message(tc), expected); len(diff) > 0 {
+		t.Error("Message doesn't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestSender(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	defer conn.Close()
+
+	s, err := NewSender(ctx, conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Unable to create sender: %v", err)
+	}
+
+	frame := glitc.LTCFrame{FramesPerSecond: 30}
+	frame.SetTimeCode(glitc.TimeCode{Hour: 10, Minute: 1, Second: 2, Frame: 3}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	s.Send(frame)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Unable to receive message: %v", err)
+	}
+	if diff := deep.Equal(buf[:n], Message(frame.Frame())); len(diff) > 0 {
+		t.Error("Received message doesn't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+	if s.Dropped() != 0 {
+		t.Errorf("Expected no dropped messages, got %d", s.Dropped())
+	}
+}
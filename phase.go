@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// phaseOffset converts -phase-offset microseconds to a duration.  A shift of a frame or more would
+// just change the timecode, which is what -offset is for, so it must be less than a frame.
+func phaseOffset(us float64, frameDuration time.Duration) (time.Duration, error) {
+	phase := time.Duration(us * float64(time.Microsecond))
+	if phase <= -frameDuration || phase >= frameDuration {
+		return 0, fmt.Errorf("-phase-offset %s must be less than a frame (%s)", phase, frameDuration)
+	}
+	return phase, nil
+}
+
+// timecodeOffset returns how far timecode runs ahead of the clock when frames begin phase after the
+// clock's frame boundaries.  Timecode runs phase behind, so frame N begins at its nominal time plus
+// phase.  The shift is constant, it doesn't accumulate.
+func timecodeOffset(offset, phase time.Duration) time.Duration {
+	return offset - phase
+}
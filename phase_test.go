@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+func TestPhaseOffset(t *testing.T) {
+	frameDuration := 40 * time.Millisecond
+	testCases := []struct {
+		Name        string
+		US          float64
+		Expected    time.Duration
+		ExpectError bool
+	}{
+		{"Zero", 0, 0, false},
+		{"Positive", 250.5, 250500 * time.Nanosecond, false},
+		{"Negative", -10000, -10 * time.Millisecond, false},
+		{"Frame", 40000, 0, true},
+		{"NegativeFrame", -40000, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			phase, err := phaseOffset(c.US, frameDuration)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if phase != c.Expected {
+				st.Errorf("Expected %s, got %s", c.Expected, phase)
+			}
+		})
+	}
+}
+
+func TestPhaseShiftedBeginTime(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Frame  glitc.LTCFrame
+		Offset time.Duration
+		Phase  time.Duration
+	}{
+		{"25/none", glitc.LTCFrame{FramesPerSecond: 25}, 0, 0},
+		{"25/+10ms", glitc.LTCFrame{FramesPerSecond: 25}, 0, 10 * time.Millisecond},
+		{"25/-10ms", glitc.LTCFrame{FramesPerSecond: 25}, 0, -10 * time.Millisecond},
+		{"30/1h/+250us", glitc.LTCFrame{FramesPerSecond: 30}, time.Hour, 250 * time.Microsecond},
+		{"29.97df/+20ms", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, 0, 20 * time.Millisecond},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frameDuration := c.Frame.FrameDuration()
+			unshifted := c.Frame
+			unshifted.Time = time.Date(2018, 12, 1, 10, 14, 21, 0, time.Local).Add(c.Offset)
+			boundary := unshifted.FrameBeginTime().Add(-c.Offset)
+			tcOffset := timecodeOffset(c.Offset, c.Phase)
+
+			// every frame over an hour begins exactly phase after the unshifted boundary
+			for i := 0; i < 25*3600; i += 997 {
+				nominal := boundary.Add(time.Duration(i) * frameDuration)
+				shifted := c.Frame
+				shifted.Time = nominal.Add(c.Phase).Add(frameDuration / 2).Add(tcOffset)
+				if begin := shifted.FrameBeginTime().Add(-tcOffset); !begin.Equal(nominal.Add(c.Phase)) {
+					st.Fatalf("Frame %d expected to begin at %s, got %s", i, nominal.Add(c.Phase), begin)
+				}
+
+				reference := c.Frame
+				reference.Time = nominal.Add(frameDuration / 2).Add(c.Offset)
+				if shifted.Frame() != reference.Frame() {
+					st.Fatalf("Frame %d expected timecode %s, got %s", i, reference.Frame(), shifted.Frame())
+				}
+			}
+		})
+	}
+}
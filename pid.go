@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// pidController is a proportional-integral-derivative controller.  The integral term is the mean of
+// the last depth errors rather than a running sum, so old errors are forgotten and the gains of the
+// proportional and integral terms are comparable.  The derivative term is the change in error since
+// the previous update.
+type pidController struct {
+	p, i, d float64
+	errors  []float64
+	next    int
+	count   int
+	prev    float64
+}
+
+// newPIDController returns a controller with the given gains, averaging the integral over depth updates
+func newPIDController(p, i, d float64, depth int) (*pidController, error) {
+	if depth < 1 {
+		return nil, fmt.Errorf("PID depth must be at least 1, got %d", depth)
+	}
+	if p < 0 || i < 0 || d < 0 {
+		return nil, fmt.Errorf("PID gains must not be negative, got p %g i %g d %g", p, i, d)
+	}
+	return &pidController{p: p, i: i, d: d, errors: make([]float64, depth)}, nil
+}
+
+// configPIDController returns a controller set up by pid.p, pid.i, pid.d and pid.depth in the config file
+func configPIDController(cfgFile *viper.Viper) (*pidController, error) {
+	return newPIDController(cfgFile.GetFloat64("pid.p"), cfgFile.GetFloat64("pid.i"), cfgFile.GetFloat64("pid.d"), cfgFile.GetInt("pid.depth"))
+}
+
+// Update records the latest error and returns the correction to apply
+func (c *pidController) Update(err float64) float64 {
+	derivative := 0.0
+	if c.count > 0 {
+		derivative = err - c.prev
+	}
+	c.prev = err
+
+	c.errors[c.next] = err
+	c.next = (c.next + 1) % len(c.errors)
+	if c.count < len(c.errors) {
+		c.count++
+	}
+	var sum float64
+	for _, e := range c.errors[:c.count] {
+		sum += e
+	}
+	return c.p*err + c.i*sum/float64(c.count) + c.d*derivative
+}
+
+// Reset forgets the errors seen so far, e.g. after the controlled value jumps
+func (c *pidController) Reset() {
+	c.next = 0
+	c.count = 0
+	c.prev = 0
+}
+
+func (c *pidController) String() string {
+	return fmt.Sprintf("p %g i %g d %g depth %d", c.p, c.i, c.d, len(c.errors))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewPIDController(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		P, I, D     float64
+		Depth       int
+		ExpectError bool
+	}{
+		{"Default", 0.1, 0.05, 0, 30, false},
+		{"ProportionalOnly", 1, 0, 0, 1, false},
+		{"NoDepth", 0.1, 0.05, 0, 0, true},
+		{"NegativeGain", -0.1, 0, 0, 30, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if _, err := newPIDController(c.P, c.I, c.D, c.Depth); (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
+
+func TestPIDControllerTerms(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		P, I, D  float64
+		Depth    int
+		Errors   []float64
+		Expected float64
+	}{
+		{"Proportional", 0.5, 0, 0, 3, []float64{4, 2}, 1},
+		{"IntegralMean", 0, 1, 0, 3, []float64{1, 2, 3}, 2},
+		{"IntegralForgets", 0, 1, 0, 2, []float64{10, 2, 4}, 3},
+		{"Derivative", 0, 0, 1, 3, []float64{1, 4}, 3},
+		{"FirstDerivative", 0, 0, 1, 3, []float64{5}, 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			pid, err := newPIDController(c.P, c.I, c.D, c.Depth)
+			if err != nil {
+				st.Fatalf("Unable to create controller: %v", err)
+			}
+			var out float64
+			for _, e := range c.Errors {
+				out = pid.Update(e)
+			}
+			if math.Abs(out-c.Expected) > 1e-9 {
+				st.Errorf("Expected %g, got %g", c.Expected, out)
+			}
+		})
+	}
+}
+
+func TestPIDControllerConverges(t *testing.T) {
+	cfgFile := newConfig()
+	pid, err := configPIDController(cfgFile)
+	if err != nil {
+		t.Fatalf("Unable to create controller from the default config: %v", err)
+	}
+
+	// a constant error of 1 is corrected a little each step
+	value := 0.0
+	for i := 0; i < 200; i++ {
+		value += pid.Update(1 - value)
+	}
+	if math.Abs(1-value) > 1e-3 {
+		t.Errorf("Expected the default controller to converge on 1, got %g", value)
+	}
+
+	pid.Reset()
+	if out := pid.Update(0); out != 0 {
+		t.Errorf("Expected no correction after reset, got %g", out)
+	}
+}
+
+func TestConfigPIDController(t *testing.T) {
+	cfgFile := viper.New()
+	cfgFile.Set("pid.p", 0.2)
+	cfgFile.Set("pid.i", 0.1)
+	cfgFile.Set("pid.depth", 0)
+	if _, err := configPIDController(cfgFile); err == nil {
+		t.Errorf("Expected an error with a depth of 0")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"time"
+
+	"github.com/azenk/audio/stream"
+)
+
+// pipeConfig describes the stream requested from a playback client such as pacat or aplay.  Samples
+// are always sent as S32_LE, the client converts them to whatever the device uses.  PeriodSize and
+// BufferSize, in sample frames, are only used by aplay, a BufferSize replaces Latency.
+type pipeConfig struct {
+	SampleRate   int
+	Channels     int
+	Latency      time.Duration
+	PeriodSize   int
+	BufferSize   int
+	ChannelModes []ChannelMode
+}
+
+// BufferTime returns the time samples are buffered before being played, BufferSize sample frames if
+// set or Latency
+func (c pipeConfig) BufferTime() time.Duration {
+	if c.BufferSize > 0 && c.SampleRate > 0 {
+		return time.Duration(c.BufferSize) * time.Second / time.Duration(c.SampleRate)
+	}
+	return c.Latency
+}
+
+// ChannelMode returns the mode used for a channel
+func (c pipeConfig) ChannelMode(channel int) ChannelMode {
+	if channel < len(c.ChannelModes) {
+		return c.ChannelModes[channel]
+	}
+	return ChannelSignal
+}
+
+func (c pipeConfig) String() string {
+	s := fmt.Sprintf("Rate: %d Format: S32_LE Channels: %d Latency: %s", c.SampleRate, c.Channels, c.BufferTime())
+	if c.PeriodSize > 0 {
+		s += fmt.Sprintf(" Period: %d", c.PeriodSize)
+	}
+	if c.BufferSize > 0 {
+		s += fmt.Sprintf(" Buffer: %d", c.BufferSize)
+	}
+	return s
+}
+
+// underrunMessage starts the line aplay writes to stderr each time the device underruns, e.g.
+// "underrun!!! (at least 12.345 ms long)".  aplay prints it even when run with -q.
+var underrunMessage = []byte("underrun!!!")
+
+// underrunWatcher passes a playback client's stderr through to out, counting the underruns it reports
+type underrunWatcher struct {
+	// xruns is updated atomically so it comes first to keep it 64-bit aligned on 32-bit platforms
+	xruns int64
+	out   io.Writer
+	line  []byte
+}
+
+func (w *underrunWatcher) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' {
+			w.line = append(w.line, b)
+			continue
+		}
+		if bytes.HasPrefix(w.line, underrunMessage) {
+			atomic.AddInt64(&w.xruns, 1)
+		}
+		w.line = w.line[:0]
+	}
+	return w.out.Write(p)
+}
+
+// Xruns returns the number of underruns reported so far
+func (w *underrunWatcher) Xruns() int64 {
+	return atomic.LoadInt64(&w.xruns)
+}
+
+// pipeDevice plays samples by piping them to the stdin of a playback client that reads S32_LE samples,
+// pacat for PulseAudio or aplay for ALSA devices, filling the same role as a stream.StreamDevice.  See
+// OpenPulseDevice and OpenIEC958Device.
+type pipeDevice struct {
+	path     string
+	config   pipeConfig
+	cmd      *exec.Cmd
+	stdin    *os.File
+	stderr   *underrunWatcher
+	streamCh chan []stream.Sample
+	doneCh   chan error
+}
+
+// openPipeDevice starts the playback client at path with args and begins writing samples sent on
+// Stream() to its stdin
+func openPipeDevice(ctx context.Context, config pipeConfig, path string, args []string) (*pipeDevice, error) {
+	if config.Channels < 1 {
+		return nil, fmt.Errorf("unsupported channel count: %d", config.Channels)
+	}
+	if len(config.ChannelModes) != 0 && len(config.ChannelModes) != config.Channels {
+		return nil, fmt.Errorf("got %d channel modes for %d channels", len(config.ChannelModes), config.Channels)
+	}
+	if config.SampleRate < 1 {
+		return nil, fmt.Errorf("unsupported sample rate: %d", config.SampleRate)
+	}
+	if config.Latency < time.Millisecond {
+		return nil, fmt.Errorf("latency must be at least 1ms, got %s", config.Latency)
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdout = os.Stdout
+	stderr := &underrunWatcher{out: os.Stderr}
+	cmd.Stderr = stderr
+	// the write end of the pipe is kept rather than using StdinPipe so the samples queued in it can be
+	// measured
+	clientStdin, stdin, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdin = clientStdin
+	err = cmd.Start()
+	clientStdin.Close()
+	if err != nil {
+		stdin.Close()
+		return nil, fmt.Errorf("unable to start %s: %v", path, err)
+	}
+
+	d := &pipeDevice{
+		path:     path,
+		config:   config,
+		cmd:      cmd,
+		stdin:    stdin,
+		stderr:   stderr,
+		streamCh: make(chan []stream.Sample, 1024),
+		doneCh:   make(chan error, 1),
+	}
+	go d.write(ctx)
+	return d, nil
+}
+
+// Config returns the configuration of the playback stream
+func (d *pipeDevice) Config() pipeConfig {
+	return d.config
+}
+
+// SampleRate returns the sample rate of the playback stream
+func (d *pipeDevice) SampleRate() int {
+	return d.config.SampleRate
+}
+
+// OutputDelay returns the requested latency or buffer size, the time between writing a sample
+// and it being played
+func (d *pipeDevice) OutputDelay() time.Duration {
+	return d.config.BufferTime()
+}
+
+// Delay measures the time until a sample written now is played, the samples still queued in the pipe
+// to the playback client on top of the latency or buffer it was started with
+func (d *pipeDevice) Delay() (time.Duration, error) {
+	queued, err := pipeQueued(d.stdin)
+	if err != nil {
+		return 0, err
+	}
+	frames := queued / (4 * d.config.Channels)
+	return d.config.BufferTime() + time.Duration(frames)*time.Second/time.Duration(d.config.SampleRate), nil
+}
+
+// Xruns returns the number of underruns the playback client has reported.  aplay reports them, pacat
+// only logs them with --verbose so they aren't counted through PulseAudio.
+func (d *pipeDevice) Xruns() int64 {
+	return d.stderr.Xruns()
+}
+
+// Stream returns the channel samples should be sent on, close it to finish playback
+func (d *pipeDevice) Stream() chan []stream.Sample {
+	return d.streamCh
+}
+
+// Done returns a channel that receives any playback error and is closed once playback has finished
+func (d *pipeDevice) Done() chan error {
+	return d.doneCh
+}
+
+func (d *pipeDevice) write(ctx context.Context) {
+	defer close(d.doneCh)
+
+	if err := d.writeData(ctx); err != nil && ctx.Err() == nil {
+		d.doneCh <- err
+	}
+	d.stdin.Close()
+
+	if err := d.cmd.Wait(); err != nil && ctx.Err() == nil {
+		d.doneCh <- fmt.Errorf("%s exited: %v", d.path, err)
+	}
+}
+
+func (d *pipeDevice) writeData(ctx context.Context) error {
+	out := bufio.NewWriter(d.stdin)
+	buf := make([]byte, 4)
+	// with a second generator each pair of samples is one sample frame
+	generators := generatorCount(d.config.ChannelModes)
+
+	for {
+		select {
+		case samples, more := <-d.streamCh:
+			if !more {
+				return out.Flush()
+			}
+			for i := 0; i+generators <= len(samples); i += generators {
+				frame := samples[i : i+generators]
+				for c := 0; c < d.config.Channels; c++ {
+					binary.LittleEndian.PutUint32(buf, uint32(int32(d.config.ChannelMode(c).FrameSample(frame))))
+					if _, err := out.Write(buf); err != nil {
+						return err
+					}
+				}
+			}
+			// keep the client fed, buffering here would add to the output delay
+			if len(d.streamCh) == 0 {
+				if err := out.Flush(); err != nil {
+					return err
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
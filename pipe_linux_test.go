@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+)
+
+func TestPulseDeviceDelay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// stand in for a pacat that has stopped reading, everything written stays queued in the pipe
+	script := filepath.Join(dir, "pacat")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\nexec sleep 60\n"), 0755); err != nil {
+		t.Fatalf("Unable to write fake pacat: %v", err)
+	}
+	defer func(path string) { pacatPath = path }(pacatPath)
+	pacatPath = script
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := OpenPulseDevice(ctx, pipeConfig{SampleRate: 48000, Channels: 2, Latency: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unable to open pulse device: %v", err)
+	}
+	if delay, err := d.Delay(); err != nil || delay != 20*time.Millisecond {
+		t.Errorf("Expected the 20ms latency before writing, got %s: %v", delay, err)
+	}
+
+	// 100ms of samples fit in the pipe without blocking
+	d.Stream() <- make([]stream.Sample, 4800)
+	expected := 120 * time.Millisecond
+	timeout := time.After(5 * time.Second)
+	for {
+		delay, err := d.Delay()
+		if err != nil {
+			t.Fatalf("Unable to measure delay: %v", err)
+		}
+		if delay == expected {
+			return
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("Expected a delay of %s with 100ms queued, got %s", expected, delay)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "os"
+
+// pipeQueued is only supported on linux
+func pipeQueued(f *os.File) (int, error) {
+	return 0, errDelayUnsupported
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// pacatPath is the PulseAudio playback client samples are piped through
+var pacatPath = "pacat"
+
+// pacatArgs returns the pacat arguments used to open a playback stream with this configuration
+func (c pipeConfig) pacatArgs() []string {
+	return []string{
+		"--playback",
+		"--raw",
+		"--format=s32le",
+		fmt.Sprintf("--rate=%d", c.SampleRate),
+		fmt.Sprintf("--channels=%d", c.Channels),
+		fmt.Sprintf("--latency-msec=%d", c.Latency/time.Millisecond),
+		"--client-name=ltcgen",
+		"--stream-name=LTC",
+	}
+}
+
+// OpenPulseDevice starts a PulseAudio playback stream and begins playing samples sent on Stream().
+// The stream is drained and closed once the stream channel is closed.  Cancelling ctx stops playback
+// immediately, pacat is killed so a write blocked on a stalled server returns.
+func OpenPulseDevice(ctx context.Context, config pipeConfig) (*pipeDevice, error) {
+	return openPipeDevice(ctx, config, pacatPath, config.pacatArgs())
+}
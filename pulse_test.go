@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/go-test/deep"
+)
+
+func TestPulseDevice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// stand in for pacat, recording its arguments and the samples it is sent
+	output := filepath.Join(dir, "samples")
+	script := filepath.Join(dir, "pacat")
+	contents := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s.args\ncat > %s\n", output, output)
+	if err := ioutil.WriteFile(script, []byte(contents), 0755); err != nil {
+		t.Fatalf("Unable to write fake pacat: %v", err)
+	}
+	defer func(path string) { pacatPath = path }(pacatPath)
+	pacatPath = script
+
+	config := pipeConfig{
+		SampleRate:   48000,
+		Channels:     2,
+		Latency:      20 * time.Millisecond,
+		ChannelModes: []ChannelMode{ChannelSignal, ChannelInverted},
+	}
+	d, err := OpenPulseDevice(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Unable to open pulse device: %v", err)
+	}
+
+	d.Stream() <- []stream.Sample{0x12345678, -1}
+	close(d.Stream())
+	for err := range d.Done() {
+		if err != nil {
+			t.Fatalf("Error playing samples: %v", err)
+		}
+	}
+
+	args, err := ioutil.ReadFile(output + ".args")
+	if err != nil {
+		t.Fatalf("Unable to read pacat arguments: %v", err)
+	}
+	expectedArgs := "--playback --raw --format=s32le --rate=48000 --channels=2 --latency-msec=20 --client-name=ltcgen --stream-name=LTC\n"
+	if string(args) != expectedArgs {
+		t.Errorf("Expected pacat arguments %q, got %q", expectedArgs, args)
+	}
+
+	samples, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Unable to read samples: %v", err)
+	}
+	expected := []byte{
+		0x78, 0x56, 0x34, 0x12, 0x88, 0xA9, 0xCB, 0xED,
+		0xFF, 0xFF, 0xFF, 0xFF, 0x01, 0x00, 0x00, 0x00,
+	}
+	if diff := deep.Equal(samples, expected); len(diff) > 0 {
+		t.Error("Samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestPulseDeviceConfig(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Config pipeConfig
+	}{
+		{"Channels", pipeConfig{SampleRate: 48000, Channels: 0, Latency: time.Millisecond}},
+		{"SampleRate", pipeConfig{SampleRate: 0, Channels: 1, Latency: time.Millisecond}},
+		{"Latency", pipeConfig{SampleRate: 48000, Channels: 1}},
+		{"ChannelModes", pipeConfig{SampleRate: 48000, Channels: 2, Latency: time.Millisecond, ChannelModes: []ChannelMode{ChannelSignal}}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if _, err := OpenPulseDevice(context.Background(), c.Config); err == nil {
+				st.Errorf("Expected error for config %s", c.Config)
+			}
+		})
+	}
+}
+
+func TestPulseDeviceCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// stand in for a pacat stuck on a stalled server, it never reads its input
+	script := filepath.Join(dir, "pacat")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\nexec sleep 60\n"), 0755); err != nil {
+		t.Fatalf("Unable to write fake pacat: %v", err)
+	}
+	defer func(path string) { pacatPath = path }(pacatPath)
+	pacatPath = script
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := OpenPulseDevice(ctx, pipeConfig{SampleRate: 48000, Channels: 2, Latency: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unable to open pulse device: %v", err)
+	}
+
+	// more than a pipe buffer worth of samples, the write blocks until pacat is killed
+	d.Stream() <- make([]stream.Sample, 1<<16)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case err, more := <-d.Done():
+			if err != nil {
+				t.Errorf("Unexpected error after cancel: %v", err)
+			}
+			if !more {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Device didn't stop after its context was cancelled")
+		}
+	}
+}
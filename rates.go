@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/spf13/viper"
+)
+
+// specFPS returns the frame rate a configuration file gives for spec, e.g. 29.97 for both 29.97 fps
+// drop frame and non drop frame
+func specFPS(spec glitc.RateSpec) float64 {
+	if spec.DropFrame || spec.PullDown {
+		return math.Round(spec.EffectiveFPS*1000) / 1000
+	}
+	return spec.FPS
+}
+
+// specMatchesFPS returns true if fps from a configuration file selects spec.  Drop frame rates are
+// also accepted by their nominal rate, e.g. 30 fps drop frame is 29.97 fps drop frame.
+func specMatchesFPS(spec glitc.RateSpec, fps float64) bool {
+	return fps == specFPS(spec) || fps == spec.FPS && !spec.PullDown
+}
+
+// listFPS returns the distinct frame rates a configuration file gives for specs, e.g. "29.97 and
+// 59.94" joined with conjunction
+func listFPS(specs []glitc.RateSpec, conjunction string) string {
+	var names []string
+	seen := make(map[float64]bool)
+	for _, spec := range specs {
+		fps := specFPS(spec)
+		if !seen[fps] {
+			seen[fps] = true
+			names = append(names, strconv.FormatFloat(fps, 'g', -1, 64))
+		}
+	}
+	return joinList(names, conjunction)
+}
+
+// listRateNames returns the names of the supported rates, e.g. for flag help
+func listRateNames() string {
+	var names []string
+	for _, spec := range glitc.SupportedRates {
+		names = append(names, spec.Name)
+	}
+	return joinList(names, "or")
+}
+
+// joinList joins names with commas and conjunction before the last, e.g. "a, b or c"
+func joinList(names []string, conjunction string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " " + conjunction + " " + names[len(names)-1]
+}
+
+// dropFrameRates returns the supported drop frame rates
+func dropFrameRates() []glitc.RateSpec {
+	var specs []glitc.RateSpec
+	for _, spec := range glitc.SupportedRates {
+		if spec.DropFrame {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// checkDropFrame returns an error if drop frame isn't supported at fps.  Drop frame is only defined
+// for 29.97 and 59.94 fps, 30 and 60 fps drop frame are accepted as other names for them.
+func checkDropFrame(fps float64, dropFrame bool) error {
+	if !dropFrame {
+		return nil
+	}
+	for _, spec := range dropFrameRates() {
+		if specMatchesFPS(spec, fps) {
+			return nil
+		}
+	}
+	return fmt.Errorf("drop frame is only defined for %s fps, not %g", listFPS(dropFrameRates(), "and"), fps)
+}
+
+// frameForRate returns a frame for a rate given on the command line, 23.976, 29.97 and 59.94 are
+// pulled down versions of the nominal rate and 29.97 and 59.94 may also be drop frame
+func frameForRate(fps float64, dropFrame bool) (glitc.LTCFrame, error) {
+	if err := checkDropFrame(fps, dropFrame); err != nil {
+		return glitc.LTCFrame{}, err
+	}
+
+	for _, spec := range glitc.SupportedRates {
+		if spec.DropFrame == dropFrame && specMatchesFPS(spec, fps) {
+			return spec.Frame(), nil
+		}
+	}
+	return glitc.LTCFrame{}, fmt.Errorf("unsupported frame rate %g, expected one of %s", fps, listFPS(glitc.SupportedRates, "or"))
+}
+
+// configuredFrame returns a frame for the rate from the configuration file.  Unless force is set a
+// drop frame setting that doesn't match the rate is an error, with force the rate is changed to
+// 29.97 instead.
+func configuredFrame(fps float64, dropFrame bool, pullDown bool, force bool) (glitc.LTCFrame, error) {
+	if err := checkDropFrame(fps, dropFrame); err != nil {
+		if !force {
+			return glitc.LTCFrame{}, fmt.Errorf("%v, set dropframe to false or use -force-fps to run at 29.97 fps drop frame", err)
+		}
+		logWarningf(logFields{"error": err.Error()}, "%v, forcing 29.97 fps drop frame", err)
+		fps = 29.97
+	}
+
+	frame, err := frameForRate(fps, dropFrame)
+	if err != nil {
+		return glitc.LTCFrame{}, err
+	}
+	if pullDown && !dropFrame {
+		frame.PullDown = true
+	}
+	return frame, nil
+}
+
+// selectFrame returns a frame for the named rate if one is given, otherwise for the fps, dropframe and
+// pulldown settings from the configuration file, see configuredFrame
+func selectFrame(rateName string, fps float64, dropFrame bool, pullDown bool, force bool) (glitc.LTCFrame, error) {
+	if rateName == "" {
+		return configuredFrame(fps, dropFrame, pullDown, force)
+	}
+	rate, err := glitc.ParseRate(rateName)
+	if err != nil {
+		return glitc.LTCFrame{}, err
+	}
+	return rate.Frame(), nil
+}
+
+// DriftPerHour returns how far timecode would run ahead of the clock each hour if every frame were
+// truncated to a whole number of samples at sampleRate.  Zero means fps divides the sample rate
+// evenly, e.g. 25 fps at 48kHz.
+func DriftPerHour(sampleRate int, fps float64) time.Duration {
+	if sampleRate <= 0 || fps <= 0 {
+		return 0
+	}
+	samplesPerFrame := float64(sampleRate) / fps
+	// allow for rounding error in rates like 30000/1001
+	if math.Abs(samplesPerFrame-math.Round(samplesPerFrame)) < 1e-6 {
+		return 0
+	}
+	truncated := math.Floor(samplesPerFrame)
+	return time.Duration((samplesPerFrame - truncated) / samplesPerFrame * float64(time.Hour))
+}
+
+// minSamplesPerBit is the fewest samples per bit the encoder can work with, a 1 bit changes level
+// half way through so there must be a sample either side
+const minSamplesPerBit = 2
+
+// checkSamplesPerBit returns an error unless sampleRate gives the encoder at least minSamplesPerBit
+// samples for each of the 80 bits of a frame at fps.  Fewer leave it with no samples to put some bits
+// or frames in.
+func checkSamplesPerBit(sampleRate float64, fps float64) error {
+	if !(sampleRate > 0) || math.IsInf(sampleRate, 0) {
+		return fmt.Errorf("invalid sample rate %g Hz", sampleRate)
+	}
+	if !(fps > 0) || math.IsInf(fps, 0) {
+		return fmt.Errorf("invalid frame rate %g fps", fps)
+	}
+	if samplesPerBit := sampleRate / (fps * 80); samplesPerBit < minSamplesPerBit {
+		return fmt.Errorf("%g Hz is only %.2f samples per bit at %g fps, at least %d are needed, use a sample rate of %.0f Hz or more",
+			sampleRate, samplesPerBit, fps, minSamplesPerBit, math.Ceil(fps*80*minSamplesPerBit))
+	}
+	return nil
+}
+
+// bitRateTolerancePPM is the largest error in the bit rate treated as within SMPTE 12M tolerance.  LTC
+// is locked to the video reference, which is held to a few ppm, e.g. the NTSC colour subcarrier's
+// ±10 Hz is ±2.8 ppm, and this leaves some room for the reader's clock.
+const bitRateTolerancePPM = 10
+
+// roundedBitRate returns the number of whole samples per bit nearest to the ideal bit period at fps,
+// and the error in ppm of bits that long, positive when they are longer than ideal and the bit rate
+// is slow
+func roundedBitRate(sampleRate float64, fps float64) (int, float64) {
+	ideal := sampleRate / (fps * 80)
+	samplesPerBit := int(math.Round(ideal))
+	return samplesPerBit, (float64(samplesPerBit) - ideal) / ideal * 1e6
+}
+
+// logBitRate reports the bit period achievable with whole samples per bit at sampleRate and whether it
+// is within bitRateTolerancePPM.  The encoder doesn't round bits, it spreads the remainder between
+// them, so outside the tolerance the average bit rate is still exact but edges move by up to a sample.
+func logBitRate(frame glitc.LTCFrame, sampleRate float64) {
+	samplesPerBit, ppm := roundedBitRate(sampleRate, frame.EffectiveFPS())
+	achieved := time.Duration(float64(samplesPerBit) / sampleRate * float64(time.Second))
+	fields := logFields{"sample_rate": sampleRate, "fps": frame.EffectiveFPS(), "bit_period_ns": frame.BitPeriod(),
+		"samples_per_bit": samplesPerBit, "rounded_bit_period_ns": achieved, "bit_rate_error_ppm": ppm}
+	if math.Abs(ppm) <= bitRateTolerancePPM {
+		logInfof(fields, "Bit period %s is %d samples at %0.f Hz, %.1f ppm from ideal, within the ±%d ppm SMPTE tolerance",
+			frame.BitPeriod(), samplesPerBit, sampleRate, ppm, bitRateTolerancePPM)
+		return
+	}
+	jitter := time.Duration(float64(time.Second) / sampleRate)
+	logWarningf(fields, "Bit period %s would be %d samples (%s) at %0.f Hz, %.1f ppm from ideal and outside the ±%d ppm SMPTE "+
+		"tolerance, bit lengths will vary so the bit rate is right on average but edges move by up to %s",
+		frame.BitPeriod(), samplesPerBit, achieved, sampleRate, ppm, bitRateTolerancePPM, jitter)
+}
+
+// driftPPM converts a drift per hour to parts per million
+func driftPPM(drift time.Duration) float64 {
+	return float64(drift) / float64(time.Hour) * 1e6
+}
+
+// defaultSampleRates are the sample rates tried, in order, when the config file doesn't list any.
+// 48kHz is the professional standard and is a whole number of samples per frame at 24, 25 and 30 fps.
+var defaultSampleRates = []int{48000, 44100}
+
+// configSampleRates returns the sample rates to try in order of preference, from the samplerates list
+// in the config file or a single samplerate
+func configSampleRates(cfgFile *viper.Viper) ([]int, error) {
+	if val := cfgFile.GetInt("samplerate"); val != 0 {
+		return []int{val}, nil
+	}
+	names := cfgFile.GetStringSlice("samplerates")
+	if len(names) == 0 {
+		return defaultSampleRates, nil
+	}
+	rates := make([]int, 0, len(names))
+	for _, name := range names {
+		rate, err := strconv.Atoi(name)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid sample rate %q in samplerates", name)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// negotiateSampleRate returns the first of the preferred sample rates that a device supports.  A nil
+// supported list means the device accepts any rate, e.g. a sound server that resamples.
+func negotiateSampleRate(preferred, supported []int) (int, error) {
+	for _, rate := range preferred {
+		if supported == nil {
+			return rate, nil
+		}
+		for _, s := range supported {
+			if s == rate {
+				return rate, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("none of the sample rates %v are supported, device supports %v", preferred, supported)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+	"github.com/spf13/viper"
+)
+
+func TestCheckDropFrame(t *testing.T) {
+	testCases := []struct {
+		FPS         float64
+		DropFrame   bool
+		ExpectError bool
+	}{
+		{29.97, true, false},
+		{30, true, false},
+		{29.97, false, false},
+		{25, false, false},
+		{25, true, true},
+		{24, true, true},
+		{23.976, true, true},
+		{59.94, true, false},
+		{60, true, false},
+		{50, true, true},
+	}
+
+	for _, c := range testCases {
+		if err := checkDropFrame(c.FPS, c.DropFrame); (err != nil) != c.ExpectError {
+			t.Errorf("Unexpected result for %g fps, dropframe %v: %v", c.FPS, c.DropFrame, err)
+		}
+	}
+}
+
+func TestFrameForSupportedRates(t *testing.T) {
+	for _, spec := range glitc.SupportedRates {
+		t.Run(spec.Name, func(st *testing.T) {
+			frame, err := frameForRate(specFPS(spec), spec.DropFrame)
+			if err != nil {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if diff := deep.Equal(frame, spec.Frame()); len(diff) > 0 {
+				st.Error("Frame doesn't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLists(t *testing.T) {
+	if names := listRateNames(); names != "23.976, 24, 25, 29.97df, 29.97nd, 30nd, 50, 59.94df, 59.94nd or 60" {
+		t.Errorf("Unexpected rate names: %s", names)
+	}
+	if fps := listFPS(glitc.SupportedRates, "or"); fps != "23.976, 24, 25, 29.97, 30, 50, 59.94 or 60" {
+		t.Errorf("Unexpected frame rates: %s", fps)
+	}
+	if fps := listFPS(dropFrameRates(), "and"); fps != "29.97 and 59.94" {
+		t.Errorf("Unexpected drop frame rates: %s", fps)
+	}
+}
+
+func TestConfiguredFrame(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		FPS         float64
+		DropFrame   bool
+		PullDown    bool
+		Force       bool
+		Expected    glitc.LTCFrame
+		ExpectError bool
+	}{
+		{"29.97df", 29.97, true, false, false, glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, false},
+		{"29.97", 29.97, false, false, false, glitc.LTCFrame{FramesPerSecond: 30, PullDown: true}, false},
+		{"23.976", 23.976, false, false, false, glitc.LTCFrame{FramesPerSecond: 24, PullDown: true}, false},
+		{"24/pulldown", 24, false, true, false, glitc.LTCFrame{FramesPerSecond: 24, PullDown: true}, false},
+		{"25", 25, false, false, false, glitc.LTCFrame{FramesPerSecond: 25}, false},
+		{"25df", 25, true, false, false, glitc.LTCFrame{}, true},
+		{"25df/force", 25, true, false, true, glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, false},
+		{"59.94df", 59.94, true, false, false, glitc.LTCFrame{FramesPerSecond: 60, DropFrame: true}, false},
+		{"unsupported", 48, false, false, false, glitc.LTCFrame{}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame, err := configuredFrame(c.FPS, c.DropFrame, c.PullDown, c.Force)
+			if c.ExpectError {
+				if err == nil {
+					st.Errorf("Expected error, got %+v", frame)
+				}
+				return
+			}
+			if err != nil {
+				st.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := deep.Equal(frame, c.Expected); len(diff) > 0 {
+				st.Error("Frame doesn't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectFrame(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Rate        string
+		Expected    glitc.LTCFrame
+		ExpectError bool
+	}{
+		// the named rate wins over the 25 fps drop frame settings, which would otherwise be refused
+		{"29.97df", "29.97df", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, false},
+		{"29.97nd", "29.97nd", glitc.LTCFrame{FramesPerSecond: 30, PullDown: true}, false},
+		{"30nd", "30nd", glitc.LTCFrame{FramesPerSecond: 30}, false},
+		{"Ambiguous", "30", glitc.LTCFrame{}, true},
+		{"Settings", "", glitc.LTCFrame{}, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame, err := selectFrame(c.Rate, 25, true, false, false)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if diff := deep.Equal(frame, c.Expected); len(diff) > 0 {
+				st.Error("Frame doesn't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftPerHour(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		SampleRate int
+		FPS        float64
+		Expected   time.Duration
+	}{
+		{"48000@25", 48000, 25, 0},
+		// 1601.6 samples per frame truncated to 1601
+		{"48000@29.97df", 48000, glitc.Rate2997DF.EffectiveFPS(), 1352250000},
+		{"44100@30", 44100, 30, 0},
+		// 1471.47 samples per frame truncated to 1471
+		{"44100@29.97df", 44100, glitc.Rate2997DF.EffectiveFPS(), 1153469387},
+		// 1837.5 samples per frame truncated to 1837
+		{"44100@24", 44100, 24, 979591836},
+		{"ZeroFPS", 48000, 0, 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if drift := DriftPerHour(c.SampleRate, c.FPS); drift != c.Expected {
+				st.Errorf("Expected drift of %s per hour, got %s", c.Expected, drift)
+			}
+		})
+	}
+}
+
+func TestDriftPPM(t *testing.T) {
+	if ppm := driftPPM(36 * time.Millisecond); math.Abs(ppm-10) > 1e-9 {
+		t.Errorf("Expected 36ms per hour to be 10ppm, got %f", ppm)
+	}
+}
+
+func TestCheckSamplesPerBit(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		SampleRate  float64
+		FPS         float64
+		ExpectError bool
+	}{
+		{"48k", 48000, 30, false},
+		{"NTSC", 48000, 30000.0 / 1001, false},
+		{"TwoPerBit", 4800, 30, false},
+		{"TooFewPerBit", 3000, 30, true},
+		{"ZeroRate", 0, 30, true},
+		{"NegativeRate", -48000, 30, true},
+		{"NaNRate", math.NaN(), 30, true},
+		{"InfRate", math.Inf(1), 30, true},
+		{"ZeroFPS", 48000, 0, true},
+		{"NaNFPS", 48000, math.NaN(), true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if err := checkSamplesPerBit(c.SampleRate, c.FPS); (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
+
+func TestRoundedBitRate(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		SampleRate            float64
+		FPS                   float64
+		ExpectedSamplesPerBit int
+		ExpectedPPM           float64
+	}{
+		{"48k@25", 48000, 25, 24, 0},
+		{"48k@30", 48000, 30, 20, 0},
+		{"96k@50", 96000, 50, 24, 0},
+		// 20.02 samples per bit rounds down, bits are 0.1% short
+		{"48k@29.97", 48000, 30000.0 / 1001, 20, -999.000999},
+		// 18.375 samples per bit rounds down
+		{"44.1k@30", 44100, 30, 18, -20408.163265},
+		// 22.97 samples per bit rounds up
+		{"44.1k@24", 44100, 24, 23, 1360.544218},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			samplesPerBit, ppm := roundedBitRate(c.SampleRate, c.FPS)
+			if samplesPerBit != c.ExpectedSamplesPerBit {
+				st.Errorf("Expected %d samples per bit, got %d", c.ExpectedSamplesPerBit, samplesPerBit)
+			}
+			if math.Abs(ppm-c.ExpectedPPM) > 1e-3 {
+				st.Errorf("Expected %f ppm, got %f", c.ExpectedPPM, ppm)
+			}
+		})
+	}
+}
+
+func TestNegotiateSampleRate(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Preferred   []int
+		Supported   []int
+		Expected    int
+		ExpectError bool
+	}{
+		{"Prefers48k", defaultSampleRates, []int{44100, 48000, 96000}, 48000, false},
+		{"FallsBackTo44k1", defaultSampleRates, []int{22050, 44100}, 44100, false},
+		{"AnyRate", defaultSampleRates, nil, 48000, false},
+		{"ConfigOrder", []int{96000, 48000}, []int{48000, 96000}, 96000, false},
+		{"Unsupported", defaultSampleRates, []int{22050}, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			rate, err := negotiateSampleRate(c.Preferred, c.Supported)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if rate != c.Expected {
+				st.Errorf("Expected %d Hz, got %d Hz", c.Expected, rate)
+			}
+		})
+	}
+}
+
+func TestConfigSampleRates(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Settings    map[string]interface{}
+		Expected    []int
+		ExpectError bool
+	}{
+		{"Default", map[string]interface{}{}, defaultSampleRates, false},
+		{"Single", map[string]interface{}{"samplerate": 96000}, []int{96000}, false},
+		{"List", map[string]interface{}{"samplerates": []interface{}{44100, 48000}}, []int{44100, 48000}, false},
+		{"Invalid", map[string]interface{}{"samplerates": []interface{}{"fast"}}, nil, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			cfgFile := viper.New()
+			for k, v := range c.Settings {
+				cfgFile.Set(k, v)
+			}
+			rates, err := configSampleRates(cfgFile)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if diff := deep.Equal(rates, c.Expected); len(diff) > 0 {
+				st.Errorf("Sample rates don't match expected value: %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+// recentFrameWindow is the number of recently sent frames remembered when counting frame errors
+const recentFrameWindow = 128
+
+// recentFrames remembers the indexes of the last frames sent, so a frame sent again after timecode
+// jumps back is counted as a duplicate, and frames already sent aren't counted as skipped when it
+// jumps forward again
+type recentFrames struct {
+	ring  []int
+	next  int
+	count map[int]int
+}
+
+func newRecentFrames(size int) *recentFrames {
+	r := &recentFrames{ring: make([]int, size), count: make(map[int]int, size)}
+	for i := range r.ring {
+		r.ring[i] = noFrame
+	}
+	return r
+}
+
+// Add remembers that index was sent, forgetting the oldest frame once the window is full
+func (r *recentFrames) Add(index int) {
+	if old := r.ring[r.next]; old != noFrame {
+		if r.count[old]--; r.count[old] == 0 {
+			delete(r.count, old)
+		}
+	}
+	r.ring[r.next] = index
+	r.count[index]++
+	r.next = (r.next + 1) % len(r.ring)
+}
+
+// Contains returns true if index is one of the frames in the window
+func (r *recentFrames) Contains(index int) bool {
+	return r.count[index] > 0
+}
+
+// Between returns the number of distinct frames in the window that are more than 0 and less than
+// distance frames after from, as measured by distanceTo
+func (r *recentFrames) Between(from, distance int, distanceTo func(a, b int) int) int {
+	n := 0
+	for index := range r.count {
+		if d := distanceTo(from, index); d > 0 && d < distance {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset forgets every frame
+func (r *recentFrames) Reset() {
+	for i := range r.ring {
+		r.ring[i] = noFrame
+	}
+	r.count = make(map[int]int, len(r.ring))
+}
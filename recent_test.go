@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestRecentFrames(t *testing.T) {
+	r := newRecentFrames(3)
+	for _, index := range []int{1, 2, 3, 4} {
+		r.Add(index)
+	}
+	if r.Contains(1) {
+		t.Errorf("Expected frame 1 to have left the window")
+	}
+	for _, index := range []int{2, 3, 4} {
+		if !r.Contains(index) {
+			t.Errorf("Expected frame %d in the window", index)
+		}
+	}
+	distance := func(a, b int) int { return b - a }
+	if n := r.Between(1, 3, distance); n != 2 {
+		t.Errorf("Expected 2 frames between 1 and 4, got %d", n)
+	}
+	r.Reset()
+	if r.Contains(4) {
+		t.Errorf("Expected no frames after reset")
+	}
+}
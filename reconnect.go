@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/azenk/audio/stream"
+)
+
+// errDeviceClosed is reported when a device stops before its stream channel is closed
+var errDeviceClosed = errors.New("audio device closed unexpectedly")
+
+// openFunc opens an output device that plays until ctx is cancelled
+type openFunc func(ctx context.Context) (outputDevice, error)
+
+// reconnectingDevice is an output device that reopens the device it wraps when it fails, e.g. when a
+// USB interface is unplugged.  Samples sent while the device is being reopened are discarded.
+type reconnectingDevice struct {
+	// reconnects is updated atomically so it comes first to keep it 64-bit aligned on 32-bit platforms
+	reconnects int64
+	open       openFunc
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	streamCh   chan []stream.Sample
+	doneCh     chan error
+	// reopening is non-zero while the device is being reopened
+	reopening int32
+
+	mu     sync.Mutex
+	device outputDevice
+	cancel context.CancelFunc
+	// xruns counted by devices that have been replaced
+	xruns int64
+}
+
+// newReconnectingDevice opens a device with open and begins playing samples sent on Stream().  When
+// the device fails it is reopened, waiting minBackoff before the first attempt and doubling the wait
+// after each failed attempt up to maxBackoff.  Opening the first device isn't retried.
+func newReconnectingDevice(ctx context.Context, open openFunc, minBackoff, maxBackoff time.Duration) (*reconnectingDevice, error) {
+	d := &reconnectingDevice{
+		open:       open,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		streamCh:   make(chan []stream.Sample, 1024),
+		doneCh:     make(chan error),
+	}
+	deviceCtx, cancel := context.WithCancel(ctx)
+	device, err := open(deviceCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	d.device = device
+	d.cancel = cancel
+	go d.run(ctx)
+	return d, nil
+}
+
+func (d *reconnectingDevice) Stream() chan []stream.Sample {
+	return d.streamCh
+}
+
+func (d *reconnectingDevice) Done() chan error {
+	return d.doneCh
+}
+
+func (d *reconnectingDevice) SampleRate() int {
+	return d.current().SampleRate()
+}
+
+func (d *reconnectingDevice) OutputDelay() time.Duration {
+	return d.current().OutputDelay()
+}
+
+// Delay returns the delay measured by the current device if it supports it
+func (d *reconnectingDevice) Delay() (time.Duration, error) {
+	if meter, ok := d.current().(delayMeter); ok {
+		return meter.Delay()
+	}
+	return 0, errDelayUnsupported
+}
+
+// Xruns returns the number of underruns reported by all of the devices opened so far
+func (d *reconnectingDevice) Xruns() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	xruns := d.xruns
+	if counter, ok := d.device.(xrunCounter); ok {
+		xruns += counter.Xruns()
+	}
+	return xruns
+}
+
+// Connected returns false while a failed device is being reopened
+func (d *reconnectingDevice) Connected() bool {
+	return atomic.LoadInt32(&d.reopening) == 0
+}
+
+// Reconnects returns the number of times the device has been reopened
+func (d *reconnectingDevice) Reconnects() int64 {
+	return atomic.LoadInt64(&d.reconnects)
+}
+
+func (d *reconnectingDevice) current() outputDevice {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.device
+}
+
+// run copies samples to the current device until the stream channel is closed, reopening the device
+// whenever it fails
+func (d *reconnectingDevice) run(ctx context.Context) {
+	defer close(d.doneCh)
+	for {
+		device := d.current()
+		select {
+		case samples, more := <-d.streamCh:
+			if !more {
+				d.drain(device)
+				return
+			}
+			select {
+			case device.Stream() <- samples:
+			case err, more := <-device.Done():
+				if !d.lost(ctx, device, err, more) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		case err, more := <-device.Done():
+			if !d.lost(ctx, device, err, more) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drain closes the device's stream and waits for it to finish playing
+func (d *reconnectingDevice) drain(device outputDevice) {
+	close(device.Stream())
+	for err := range device.Done() {
+		if err != nil {
+			d.doneCh <- err
+		}
+	}
+}
+
+// lost replaces a device that reported err, or stopped if more is false, with a newly opened one.  It
+// returns false if ctx was cancelled before a device could be opened.
+func (d *reconnectingDevice) lost(ctx context.Context, device outputDevice, err error, more bool) bool {
+	if err == nil && !more {
+		err = errDeviceClosed
+	}
+	if err == nil {
+		return true
+	}
+	logWarningf(logFields{"error": err.Error()}, "Audio device failed, reopening: %v", err)
+
+	atomic.StoreInt32(&d.reopening, 1)
+	d.mu.Lock()
+	d.cancel()
+	if counter, ok := device.(xrunCounter); ok {
+		d.xruns += counter.Xruns()
+	}
+	d.mu.Unlock()
+
+	backoff := d.minBackoff
+	for {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		}
+
+		deviceCtx, cancel := context.WithCancel(ctx)
+		replacement, err := d.open(deviceCtx)
+		if err == nil {
+			d.mu.Lock()
+			d.device = replacement
+			d.cancel = cancel
+			d.mu.Unlock()
+			atomic.StoreInt32(&d.reopening, 0)
+			reconnects := atomic.AddInt64(&d.reconnects, 1)
+			if replacement.SampleRate() != device.SampleRate() {
+				logWarningf(logFields{"sample_rate": replacement.SampleRate(), "expected_sample_rate": device.SampleRate()},
+					"Reopened audio device runs at %d Hz instead of %d Hz, timecode will be off speed",
+					replacement.SampleRate(), device.SampleRate())
+			}
+			logInfof(logFields{"reconnects": reconnects}, "Audio device reopened, %d reconnects since start", reconnects)
+			return true
+		}
+		cancel()
+
+		if backoff *= 2; backoff > d.maxBackoff {
+			backoff = d.maxBackoff
+		}
+		logWarningf(logFields{"error": err.Error(), "backoff_ns": backoff}, "Unable to reopen audio device, retrying in %s: %v", backoff, err)
+	}
+}
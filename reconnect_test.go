@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+)
+
+// fakeDevice is an output device whose samples and failures are driven by the test
+type fakeDevice struct {
+	streamCh chan []stream.Sample
+	doneCh   chan error
+}
+
+func newFakeDevice() *fakeDevice {
+	return &fakeDevice{streamCh: make(chan []stream.Sample), doneCh: make(chan error, 1)}
+}
+
+func (d *fakeDevice) Stream() chan []stream.Sample { return d.streamCh }
+func (d *fakeDevice) Done() chan error             { return d.doneCh }
+func (d *fakeDevice) SampleRate() int              { return 48000 }
+func (d *fakeDevice) OutputDelay() time.Duration   { return 0 }
+
+func expectSample(t *testing.T, device *fakeDevice, expected stream.Sample) {
+	t.Helper()
+	select {
+	case samples := <-device.streamCh:
+		if len(samples) != 1 || samples[0] != expected {
+			t.Errorf("Expected sample %d, got %v", expected, samples)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for sample %d", expected)
+	}
+}
+
+func TestReconnectingDevice(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, second := newFakeDevice(), newFakeDevice()
+	opens := 0
+	open := func(ctx context.Context) (outputDevice, error) {
+		opens++
+		switch opens {
+		case 1:
+			return first, nil
+		case 2:
+			return nil, errors.New("no such device")
+		}
+		return second, nil
+	}
+
+	device, err := newReconnectingDevice(ctx, open, time.Millisecond, 4*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unable to open device: %v", err)
+	}
+
+	device.Stream() <- []stream.Sample{1}
+	expectSample(t, first, 1)
+
+	// unplugged, the first attempt to reopen fails and the second succeeds
+	first.doneCh <- errors.New("write failed")
+	device.Stream() <- []stream.Sample{2}
+	expectSample(t, second, 2)
+	if opens != 3 {
+		t.Errorf("Expected 3 attempts to open the device, got %d", opens)
+	}
+	if device.Reconnects() != 1 {
+		t.Errorf("Expected 1 reconnect, got %d", device.Reconnects())
+	}
+	if !device.Connected() {
+		t.Errorf("Expected device to be connected once reopened")
+	}
+
+	close(device.Stream())
+	select {
+	case _, more := <-second.streamCh:
+		if more {
+			t.Fatalf("Expected replacement device stream to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for replacement device stream to be closed")
+	}
+	close(second.doneCh)
+	select {
+	case <-device.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out waiting for device to finish")
+	}
+}
+
+func TestReconnectingDeviceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first := newFakeDevice()
+	open := func(ctx context.Context) (outputDevice, error) {
+		if first != nil {
+			device := first
+			first = nil
+			return device, nil
+		}
+		return nil, errors.New("no such device")
+	}
+
+	device, err := newReconnectingDevice(ctx, open, time.Millisecond, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unable to open device: %v", err)
+	}
+	device.current().Done() <- errors.New("write failed")
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-device.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("Device kept retrying after the context was cancelled")
+	}
+}
+
+func TestReconnectingDeviceOpenError(t *testing.T) {
+	open := func(ctx context.Context) (outputDevice, error) {
+		return nil, errors.New("no such device")
+	}
+	if _, err := newReconnectingDevice(context.Background(), open, time.Millisecond, time.Millisecond); err == nil {
+		t.Errorf("Expected an error when the first device can't be opened")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/azenk/audio/stream/encoding"
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// rangeFrames returns the number of frames sent at rate from start up to but not including end.  The
+// timecodes are taken as drop frame if the rate is, whichever separator they were written with, and a
+// range whose end is before its start runs through midnight.
+func rangeFrames(start, end glitc.TimeCode, rate glitc.Rate) (int, error) {
+	frame := rate.Frame()
+	start.DropFrame, end.DropFrame = frame.DropFrame, frame.DropFrame
+	for _, tc := range []glitc.TimeCode{start, end} {
+		if !tc.IsValid(float64(frame.FramesPerSecond), frame.DropFrame) {
+			return 0, fmt.Errorf("timecode %s isn't sent at %s fps", tc, rate)
+		}
+	}
+
+	// timecode wraps after a full day of frames even where pull down cuts the wall clock day short
+	frame.PullDown = false
+	day := frame.FramesPerDay()
+	frames := end.ToFrames(float64(frame.FramesPerSecond), frame.DropFrame) - start.ToFrames(float64(frame.FramesPerSecond), frame.DropFrame)
+	frames = (frames%day + day) % day
+	if frames == 0 {
+		return 0, fmt.Errorf("end %s must differ from start %s", end, start)
+	}
+	return frames, nil
+}
+
+// encodeRange encodes frames frames counting on from frame, set to the first timecode with
+// SetTimeCode so pulled down timecode runs through its own midnight, and passes each to send.  The
+// slice passed to send is reused for the next frame.
+func encodeRange(frame glitc.LTCFrame, frames int, send func(binaryFrame []byte)) error {
+	binaryFrame := make([]byte, glitc.FrameBytes)
+	for i := 0; i < frames; i++ {
+		if err := frame.EncodeFrameInto(binaryFrame); err != nil {
+			return err
+		}
+		send(binaryFrame)
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+	return nil
+}
+
+// renderCommand writes the LTC for a range of timecode to a WAV file, frame by frame rather than in
+// real time, e.g. to build test media that crosses a drop frame or midnight boundary
+func renderCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(out)
+	startFlag := flags.String("start", "", "Timecode of the first frame, hh:mm:ss:ff")
+	endFlag := flags.String("end", "", "Timecode to stop before, hh:mm:ss:ff, before -start to render through midnight")
+	rateFlag := flags.String("fps", "30nd", "Frame rate, one of "+listRateNames())
+	outFlag := flags.String("out", "", "WAV file to write")
+	sampleRate := flags.Int("sample-rate", 48000, "Sample rate of the WAV file")
+	sampleFormat := flags.String("format", "S16_LE", "Sample format: S16_LE, S24_3LE, S32_LE or FLOAT_LE")
+	level := flags.Float64("level-dbfs", -12, "Peak LTC level in dBFS")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *startFlag == "" || *endFlag == "" || *outFlag == "" {
+		return fmt.Errorf("-start, -end and -out are required")
+	}
+
+	rate, err := glitc.ParseRate(*rateFlag)
+	if err != nil {
+		return err
+	}
+	start, err := glitc.ParseTimeCode(*startFlag)
+	if err != nil {
+		return err
+	}
+	end, err := glitc.ParseTimeCode(*endFlag)
+	if err != nil {
+		return err
+	}
+	frames, err := rangeFrames(start, end, rate)
+	if err != nil {
+		return err
+	}
+	frame := rate.Frame()
+	start.DropFrame, end.DropFrame = frame.DropFrame, frame.DropFrame
+	frame.SetTimeCode(start, time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local))
+
+	amplitude, err := dbfsAmplitude(*level)
+	if err != nil {
+		return err
+	}
+	bitsPerSample, float, err := ParseSampleFormat(*sampleFormat)
+	if err != nil {
+		return err
+	}
+	sr := float64(*sampleRate)
+	if err := checkSampleRate(frame, sr); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := CreateWAVFile(ctx, *outFlag, WAVConfig{
+		SampleRate:    *sampleRate,
+		BitsPerSample: bitsPerSample,
+		Float:         float,
+		Channels:      1,
+	})
+	if err != nil {
+		return err
+	}
+
+	rawFrameChan := make(chan byte, 160)
+	encodedData := encoding.DifferentialManchester(ctx,
+		3*int(math.Ceil(frame.SamplesPerFrame(sr))),
+		frame.EffectiveFPS()*80,
+		amplitude,
+		sr,
+		rawFrameChan)
+	go func() {
+		for sample := range encodedData {
+			w.Stream() <- []stream.Sample{sample}
+		}
+		close(w.Stream())
+	}()
+
+	err = encodeRange(frame, frames, func(binaryFrame []byte) {
+		for _, b := range binaryFrame {
+			rawFrameChan <- b
+		}
+	})
+	close(rawFrameChan)
+	if err != nil {
+		return err
+	}
+
+	for err := range w.Done() {
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Wrote %d frames of %s LTC from %s to %s to %s -- %s\n", frames, rate, start, end, *outFlag, w.Config())
+	return nil
+}
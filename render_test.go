@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+)
+
+func TestRangeFrames(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Start       string
+		End         string
+		Rate        glitc.Rate
+		Expected    int
+		ExpectError bool
+	}{
+		{"TenMinuteBoundary", "00:59:50:00", "01:00:10:00", glitc.Rate2997DF, 600, false},
+		{"DroppedMinute", "00:00:59;00", "00:01:01;00", glitc.Rate2997DF, 58, false},
+		{"DropFrameTenMinutes", "00:00:00;00", "00:10:00;00", glitc.Rate2997DF, 17982, false},
+		{"NonDropMinute", "00:00:59:00", "00:01:01:00", glitc.Rate30ND, 60, false},
+		{"OneFrame", "01:00:00:00", "01:00:00:01", glitc.Rate25, 1, false},
+		{"Midnight", "23:59:50:00", "00:00:10:00", glitc.Rate25, 500, false},
+		{"DropFrameMidnight", "23:59:59;00", "00:00:01;00", glitc.Rate2997DF, 60, false},
+		{"Empty", "01:00:00:00", "01:00:00:00", glitc.Rate25, 0, true},
+		{"DroppedStart", "00:01:00:00", "00:01:10:00", glitc.Rate2997DF, 0, true},
+		{"FrameOutOfRange", "00:00:00:25", "00:00:10:00", glitc.Rate25, 0, true},
+		{"FramePairMidnight", "23:59:59:48", "00:00:00:02", glitc.Rate50, 4, false},
+		{"FramePairOutOfRange", "00:00:00:30", "00:00:10:00", glitc.Rate30ND, 0, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			start, err := glitc.ParseTimeCode(c.Start)
+			if err != nil {
+				st.Fatalf("Unable to parse start: %v", err)
+			}
+			end, err := glitc.ParseTimeCode(c.End)
+			if err != nil {
+				st.Fatalf("Unable to parse end: %v", err)
+			}
+			frames, err := rangeFrames(start, end, c.Rate)
+			if (err != nil) != c.ExpectError {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if frames != c.Expected {
+				st.Errorf("Expected %d frames, got %d", c.Expected, frames)
+			}
+		})
+	}
+}
+
+func TestEncodeRange(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Start    string
+		End      string
+		Rate     glitc.Rate
+		Expected []string
+	}{
+		{"30nd", "23:59:59:28", "00:00:00:02", glitc.Rate30ND,
+			[]string{"23:59:59:28", "23:59:59:29", "00:00:00:00", "00:00:00:01"}},
+		{"29.97df", "23:59:59;28", "00:00:00;02", glitc.Rate2997DF,
+			[]string{"23:59:59;28", "23:59:59;29", "00:00:00;00", "00:00:00;01"}},
+		{"29.97nd", "23:59:59:28", "00:00:00:02", glitc.Rate2997ND,
+			[]string{"23:59:59:28", "23:59:59:29", "00:00:00:00", "00:00:00:01"}},
+		{"23.976", "23:59:59:22", "00:00:00:02", glitc.Rate23976,
+			[]string{"23:59:59:22", "23:59:59:23", "00:00:00:00", "00:00:00:01"}},
+		{"59.94nd", "23:59:59:56", "00:00:00:02", glitc.Rate5994ND,
+			// only the frame pair is sent above 30fps
+			[]string{"23:59:59:56", "23:59:59:56", "23:59:59:58", "23:59:59:58", "00:00:00:00", "00:00:00:00"}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			start, err := glitc.ParseTimeCode(c.Start)
+			if err != nil {
+				st.Fatalf("Unable to parse start: %v", err)
+			}
+			end, err := glitc.ParseTimeCode(c.End)
+			if err != nil {
+				st.Fatalf("Unable to parse end: %v", err)
+			}
+			frames, err := rangeFrames(start, end, c.Rate)
+			if err != nil {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+
+			day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local)
+			frame := c.Rate.Frame()
+			frame.SetTimeCode(start, day)
+			template := glitc.LTCFrame{Time: day, FramesPerSecond: frame.FramesPerSecond, PullDown: frame.PullDown}
+			var sent []string
+			err = encodeRange(frame, frames, func(binaryFrame []byte) {
+				decoded, err := template.DecodeFrame(binaryFrame)
+				if err != nil {
+					st.Fatalf("Unable to decode frame: %v", err)
+				}
+				sent = append(sent, decoded.Frame().String())
+			})
+			if err != nil {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			if diff := deep.Equal(sent, c.Expected); len(diff) > 0 {
+				st.Error("Encoded timecodes don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderCommandArgs(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Args []string
+	}{
+		{"NoOutput", []string{"-start", "01:00:00:00", "-end", "01:00:01:00"}},
+		{"AmbiguousRate", []string{"-start", "01:00:00:00", "-end", "01:00:01:00", "-fps", "29.97", "-out", "x.wav"}},
+		{"Empty", []string{"-start", "01:00:00:00", "-end", "01:00:00:00", "-out", "x.wav"}},
+		{"TooFewSamples", []string{"-start", "01:00:00:00", "-end", "01:00:01:00", "-sample-rate", "1000", "-out", "x.wav"}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			var out bytes.Buffer
+			if err := renderCommand(c.Args, &out); err == nil {
+				st.Errorf("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package main
+
+import "fmt"
+
+// checkRTPriority returns an error if priority isn't 0, which leaves the scheduling policy alone, or
+// a SCHED_FIFO priority from 1 to 99
+func checkRTPriority(priority int) error {
+	if priority < 0 || priority > 99 {
+		return fmt.Errorf("real-time priority must be between 1 and 99, got %d", priority)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// schedFIFO is the SCHED_FIFO real-time scheduling policy
+const schedFIFO = 1
+
+// setRealTime locks the calling goroutine to its thread and moves the thread to SCHED_FIFO at
+// priority, so the frame loop isn't preempted by ordinary processes.  A cpu of -1 leaves the thread
+// free to run on any CPU, otherwise it is pinned to that CPU.  Setting a real-time policy needs
+// CAP_SYS_NICE or an RLIMIT_RTPRIO allowance.
+func setRealTime(priority int, cpu int) error {
+	if err := checkRTPriority(priority); err != nil {
+		return err
+	}
+	runtime.LockOSThread()
+	tid := syscall.Gettid()
+
+	if cpu >= 0 {
+		var mask [16]uint64
+		if cpu >= len(mask)*64 {
+			return fmt.Errorf("cpu %d out of range", cpu)
+		}
+		mask[cpu/64] |= 1 << uint(cpu%64)
+		_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(tid), unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+		if errno != 0 {
+			return fmt.Errorf("unable to pin frame loop to cpu %d: %v", cpu, errno)
+		}
+	}
+
+	if priority > 0 {
+		param := struct{ priority int32 }{int32(priority)}
+		_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER, uintptr(tid), schedFIFO, uintptr(unsafe.Pointer(&param)))
+		if errno == syscall.EPERM {
+			return fmt.Errorf("unable to set real-time priority %d, CAP_SYS_NICE is needed: %v", priority, errno)
+		}
+		if errno != 0 {
+			return fmt.Errorf("unable to set real-time priority %d: %v", priority, errno)
+		}
+	}
+	return nil
+}
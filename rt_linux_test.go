@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestSetRealTime(t *testing.T) {
+	done := make(chan error)
+	go func() {
+		// the goroutine's thread is left locked and pinned, it exits along with the goroutine
+		err := setRealTime(0, 0)
+		done <- err
+	}()
+	if err := <-done; err != nil {
+		t.Errorf("Unable to pin to cpu 0: %v", err)
+	}
+
+	for _, priority := range []int{-1, 100} {
+		if err := setRealTime(priority, -1); err == nil {
+			t.Errorf("Expected error for priority %d", priority)
+		}
+	}
+}
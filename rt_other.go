@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+// setRealTime is only supported on linux
+func setRealTime(priority int, cpu int) error {
+	if err := checkRTPriority(priority); err != nil {
+		return err
+	}
+	if priority == 0 && cpu < 0 {
+		return nil
+	}
+	return errors.New("real-time scheduling is only supported on linux")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// sampleCounter counts the samples handed to the audio device, it is safe for concurrent use
+type sampleCounter struct {
+	n int64
+}
+
+// Add counts n more samples
+func (c *sampleCounter) Add(n int) {
+	atomic.AddInt64(&c.n, int64(n))
+}
+
+// Count returns the number of samples counted so far
+func (c *sampleCounter) Count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
+
+// sampleClockScheduler derives each frame from its position in the sample stream rather than from
+// when the frame timer fires.  Frame i begins at sample i*SamplesPerFrame, rounded, and carries the
+// timecode origin plus i frames, so frame boundaries line up with sample counts and late ticks can't
+// repeat or skip frames.  Timecode follows the audio interface's sample clock instead of the system
+// clock.
+type sampleClockScheduler struct {
+	frame      glitc.LTCFrame
+	origin     time.Time
+	sampleRate float64
+	// lead is how far ahead of the samples already sent frames are encoded
+	lead int64
+	next int
+}
+
+// newSampleClockScheduler returns a scheduler whose first frame begins at origin, encoding frames
+// up to lead frames ahead of the samples sent to the device
+func newSampleClockScheduler(frame glitc.LTCFrame, origin time.Time, sampleRate float64, lead int) *sampleClockScheduler {
+	frame.Time = origin
+	return &sampleClockScheduler{
+		frame:      frame,
+		origin:     frame.FrameBeginTime(),
+		sampleRate: sampleRate,
+		lead:       int64(math.Ceil(float64(lead) * frame.SamplesPerFrame(sampleRate))),
+	}
+}
+
+// beginSample returns the sample frame i begins at
+func (s *sampleClockScheduler) beginSample(i int) int64 {
+	return int64(math.Round(float64(i) * s.frame.SamplesPerFrame(s.sampleRate)))
+}
+
+// Next returns the next frame if it begins within the lead of position, the number of samples sent
+// to the device so far.  ok is false once the lead is full.
+func (s *sampleClockScheduler) Next(position int64) (frame glitc.LTCFrame, ok bool) {
+	if s.beginSample(s.next) > position+s.lead {
+		return s.frame, false
+	}
+	frameDuration := s.frame.FrameDuration()
+	frame = s.frame
+	frame.Time = s.origin.Add(time.Duration(s.next) * frameDuration).Add(frameDuration / 2)
+	s.next++
+	return frame, true
+}
+
+// Lead returns how long before it is due each frame is encoded
+func (s *sampleClockScheduler) Lead() time.Duration {
+	return time.Duration(float64(s.lead) / s.sampleRate * float64(time.Second))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+func TestSampleClockScheduler(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Frame      glitc.LTCFrame
+		SampleRate float64
+	}{
+		{"25/48000", glitc.LTCFrame{FramesPerSecond: 25}, 48000},
+		{"29.97df/48000", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, 48000},
+		{"29.97df/44100", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, 44100},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			origin := time.Date(2018, 12, 1, 10, 14, 21, 0, time.Local)
+			s := newSampleClockScheduler(c.Frame, origin, c.SampleRate, 2)
+			samplesPerFrame := c.Frame.SamplesPerFrame(c.SampleRate)
+
+			// the device takes samples in uneven chunks, as the copy loop gets to run
+			var position int64
+			var sent []glitc.LTCFrame
+			for i := 0; len(sent) < 3000; i++ {
+				position += int64(300 + 517*(i%7))
+				for frame, ok := s.Next(position); ok; frame, ok = s.Next(position) {
+					sent = append(sent, frame)
+				}
+				// never more than the lead plus a frame ahead of the samples sent
+				ahead := float64(len(sent))*samplesPerFrame - float64(position)
+				if ahead > 3*samplesPerFrame+2 {
+					st.Fatalf("Encoded %f samples ahead at position %d", ahead, position)
+				}
+			}
+
+			first := c.Frame
+			first.Time = origin
+			if sent[0].Frame() != first.Frame() {
+				st.Errorf("Expected first frame %s, got %s", first.Frame(), sent[0].Frame())
+			}
+			for i := 1; i < len(sent); i++ {
+				if next := sent[i-1].Frame().Add(1, c.Frame.FramesPerSecond); sent[i].Frame() != next {
+					st.Fatalf("Frame %d is %s, expected %s", i, sent[i].Frame(), next)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleCounter(t *testing.T) {
+	var c sampleCounter
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			c.Add(2)
+		}
+		close(done)
+	}()
+	<-done
+	if c.Count() != 2000 {
+		t.Errorf("Expected 2000 samples, got %d", c.Count())
+	}
+}
@@ -0,0 +1,416 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// noFrame is the previous frame index before any frame has been sent
+const noFrame = -1
+
+// defaultFrameErrorInterval is the shortest interval between frame error warnings unless set with
+// SetFrameErrorInterval
+const defaultFrameErrorInterval = 10 * time.Second
+
+// frameScheduler decides which frame to send on each tick of the frame timer, detecting frames that
+// would be repeated or skipped because the timer fired early or late
+type frameScheduler struct {
+	clock          Clock
+	frame          glitc.LTCFrame
+	outputDelay    time.Duration
+	offset         time.Duration
+	status         *Status
+	prevFrameIndex int
+	// recent holds the frames sent lately, so duplicates and skips that aren't between adjacent
+	// frames are counted correctly
+	recent *recentFrames
+
+	// In free run mode the timecode is derived from the number of frames sent since freeRunBase,
+	// counting down instead of up in reverse
+	freeRun      bool
+	reverse      bool
+	freeRunBase  time.Time
+	freeRunCount int
+
+	// While paused the last frame sent is repeated on every tick
+	paused bool
+
+	// Frames sent with an intra frame offset outside 0 to offsetWindow are counted and warned about,
+	// by default the window is half the output delay
+	offsetWindow   time.Duration
+	windowWarnings *logThrottle
+
+	// Frame error warnings are limited to one per interval, with the errors held back summed up in the
+	// next warning, or once the interval passes without another
+	frameErrorWarnings *logThrottle
+
+	// In monotonic mode the time of day is the clock at anchor plus the monotonic time elapsed since,
+	// so steps in the system clock don't cause frame errors
+	monotonic  bool
+	anchor     time.Time
+	anchorMono time.Duration
+
+	// The system clock and monotonic clock at the previous tick, to spot the clock stepping by a
+	// leap second
+	lastWall time.Time
+	lastMono time.Duration
+}
+
+// newFrameScheduler returns a scheduler whose first frame is the one following the current time plus
+// offset, which runs timecode ahead of the clock or behind it if negative.  In free run mode the
+// first frame is freeRunStart, or the current timecode if freeRunStart is nil, and reverse counts
+// down from there.  Monotonic follows the time of day at the start without following later steps
+// of the system clock.
+func newFrameScheduler(clock Clock, frame glitc.LTCFrame, outputDelay time.Duration, offset time.Duration, status *Status,
+	freeRun bool, freeRunStart *glitc.TimeCode, reverse bool, monotonic bool) *frameScheduler {
+	s := &frameScheduler{
+		clock:       clock,
+		outputDelay: outputDelay,
+		offset:      offset,
+		status:      status,
+		freeRun:     freeRun,
+		reverse:     freeRun && reverse,
+		monotonic:   monotonic,
+		anchor:      clock.Now(),
+		anchorMono:  clock.Monotonic(),
+		recent:      newRecentFrames(recentFrameWindow),
+
+		windowWarnings:     newLogThrottle(10 * time.Second),
+		frameErrorWarnings: newLogThrottle(defaultFrameErrorInterval),
+	}
+
+	// Set prevFrameIndex to now, this should be one frame before the first frame output
+	frame.Time = s.timecodeTime(clock.Now())
+	s.prevFrameIndex = frame.FrameIndex()
+	frame.Time = s.timecodeTime(clock.Now().Add(frame.FrameDuration()))
+
+	if freeRun {
+		if freeRunStart != nil {
+			frame.SetTimeCode(*freeRunStart, frame.Time)
+		} else {
+			frame.SetTimeCode(frame.Frame(), frame.Time)
+		}
+		s.freeRunBase = frame.Time
+		s.prevFrameIndex = frame.FrameIndex() - s.direction()
+	}
+	s.frame = frame
+	return s
+}
+
+// timecodeTime returns the time of day encoded in the frame that is playing when a sample written at t
+// comes out of the device
+func (s *frameScheduler) timecodeTime(t time.Time) time.Time {
+	return s.clockTime(t).Add(s.outputDelay).Add(s.offset)
+}
+
+// clockTime returns the time of day at t, in monotonic mode this is measured from the anchor
+func (s *frameScheduler) clockTime(t time.Time) time.Time {
+	if !s.monotonic {
+		return t
+	}
+	// t is when the frame timer fired, usually a moment before now
+	elapsed := s.clock.Monotonic() - s.anchorMono - s.clock.Now().Sub(t)
+	return s.anchor.Add(elapsed)
+}
+
+// frameDistance returns the number of frames from index a to b in the direction of play.  Frame
+// indexes restart at midnight, so 00:00:00:00 is one frame after the last frame of the day.
+func (s *frameScheduler) frameDistance(a, b int) int {
+	day := s.frame.FramesPerDay()
+	distance := ((b-a)*s.direction()%day + day) % day
+	if distance > day/2 {
+		distance -= day
+	}
+	return distance
+}
+
+// direction returns the change in frame index from one frame to the next
+func (s *frameScheduler) direction() int {
+	if s.reverse {
+		return -1
+	}
+	return 1
+}
+
+// SetOutputDelay changes the delay compensated for from the next frame
+func (s *frameScheduler) SetOutputDelay(delay time.Duration) {
+	s.outputDelay = delay
+}
+
+// Offset returns the offset of timecode from the clock
+func (s *frameScheduler) Offset() time.Duration {
+	return s.offset
+}
+
+// SetOffset changes the offset of timecode from the clock from the next frame.  A jump, e.g. to follow
+// chased timecode that relocated, isn't counted as a frame error.
+func (s *frameScheduler) SetOffset(offset time.Duration, jump bool) {
+	s.offset = offset
+	if jump {
+		s.prevFrameIndex = noFrame
+		s.recent.Reset()
+	}
+}
+
+// SetOffsetWindow sets the largest intra frame offset expected before samples may reach the device
+// too late, 0 uses half the output delay
+func (s *frameScheduler) SetOffsetWindow(window time.Duration) {
+	s.offsetWindow = window
+}
+
+// SetFrameErrorInterval sets the shortest interval between frame error warnings, 0 warns about
+// every frame error
+func (s *frameScheduler) SetFrameErrorInterval(interval time.Duration) {
+	s.frameErrorWarnings = newLogThrottle(interval)
+}
+
+// bufferWindow returns the largest intra frame offset expected
+func (s *frameScheduler) bufferWindow() time.Duration {
+	if s.offsetWindow > 0 {
+		return s.offsetWindow
+	}
+	return s.outputDelay / 2
+}
+
+// outsideWindow returns true if a frame sent offset into the frame falls outside window.  Offsets
+// aren't checked when the window isn't known, e.g. for devices that report no output delay.
+func outsideWindow(offset, window time.Duration) bool {
+	if window <= 0 {
+		return false
+	}
+	return offset < 0 || offset > window
+}
+
+// Pause holds the timecode on the most recently scheduled frame until Resume is called
+func (s *frameScheduler) Pause() {
+	s.paused = true
+	s.status.SetPaused(true)
+}
+
+// Resume continues sending timecode after Pause.  In free run mode counting continues from the held
+// frame, otherwise timecode jumps back to the clock.
+func (s *frameScheduler) Resume() {
+	if !s.paused {
+		return
+	}
+	s.paused = false
+	s.status.SetPaused(false)
+	if !s.freeRun {
+		// the jump back to the clock isn't a frame error, and a held timecode's day no longer applies
+		s.frame.SetClockTime(s.frame.Time)
+		s.prevFrameIndex = noFrame
+		s.recent.Reset()
+	}
+}
+
+// Hold sends tc on every frame from the next one, a static test pattern, until Resume is called
+func (s *frameScheduler) Hold(tc glitc.TimeCode) error {
+	if err := checkTimeCode(tc, s.frame); err != nil {
+		return err
+	}
+	s.frame.SetTimeCode(tc, s.frame.Time)
+	s.Pause()
+	return nil
+}
+
+// checkTimeCode returns an error if tc can't be sent in frame
+func checkTimeCode(tc glitc.TimeCode, frame glitc.LTCFrame) error {
+	if tc.DropFrame != frame.DropFrame {
+		return fmt.Errorf("timecode %s doesn't match dropframe setting %v", tc, frame.DropFrame)
+	}
+	if !tc.IsValid(frame.FramesPerSecond, frame.DropFrame) {
+		return fmt.Errorf("timecode %s isn't sent at %g fps", tc, frame.EffectiveFPS())
+	}
+	return nil
+}
+
+// Jam makes tc the timecode of the next frame.  In free run mode counting continues from tc,
+// otherwise the offset from the clock changes by whole frames so timecode follows the clock from tc.
+func (s *frameScheduler) Jam(tc glitc.TimeCode) error {
+	if err := checkTimeCode(tc, s.frame); err != nil {
+		return err
+	}
+
+	next := s.frame
+	next.Time = next.Time.Add(time.Duration(s.direction()) * next.FrameDuration())
+	jammed := next
+	jammed.SetTimeCode(tc, next.Time)
+	if s.freeRun {
+		s.freeRunBase = jammed.Time
+		s.freeRunCount = 0
+	} else {
+		s.offset += jammed.FrameBeginTime().Sub(next.FrameBeginTime())
+		// pulled down timecode carries on through the day of tc rather than the clock's day
+		jammed.Time = jammed.Time.Add(-time.Duration(s.direction()) * jammed.FrameDuration())
+		s.frame = jammed
+	}
+	// the jump isn't a frame error
+	s.prevFrameIndex = noFrame
+	s.recent.Reset()
+	return nil
+}
+
+// checkLeapSecond resyncs to the clock without counting frame errors if it was stepped by a leap
+// second since the previous tick.  Inserting a leap second repeats a second of timecode, as the
+// clock repeats 23:59:59, which would otherwise be counted as a duplicate on every frame of it.
+func (s *frameScheduler) checkLeapSecond() {
+	now, mono := s.clock.Now(), s.clock.Monotonic()
+	if !s.lastWall.IsZero() {
+		step := now.Sub(s.lastWall) - (mono - s.lastMono)
+		if leapSecondStep(now, step) {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "step_ns": step},
+				"Clock stepped %s for a leap second after %s, timecode follows it", step, s.frame.Frame())
+			s.prevFrameIndex = noFrame
+			s.recent.Reset()
+		}
+	}
+	s.lastWall, s.lastMono = now, mono
+}
+
+// countFrameErrors counts the frames skipped or repeated by sending index after the previous frame,
+// returning false if it would repeat the previous frame and shouldn't be sent.  Timecode that
+// jumps back, e.g. following a clock step, is sent, and frames sent again are counted as duplicates.
+// Frames older than the recent window are assumed to have been sent.  Each error is only logged if
+// warn is set.
+func (s *frameScheduler) countFrameErrors(index int, warn bool) bool {
+	if s.prevFrameIndex == noFrame {
+		return true
+	}
+	distance := s.frameDistance(s.prevFrameIndex, index)
+	switch {
+	case distance == 0:
+		if warn {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "error": "duplicate"},
+				"Would have output duplicate frame number at %s, skipping", s.frame.Frame())
+		}
+		s.status.Duplicate()
+		return false
+	case s.recent.Contains(index) || distance < -recentFrameWindow:
+		if warn {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "error": "repeated"},
+				"Repeating frame %s sent earlier", s.frame.Frame())
+		}
+		s.status.Duplicate()
+	case distance < 0:
+		if warn {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "error": "late"},
+				"Sending skipped frame %s late", s.frame.Frame())
+		}
+	case distance > 1:
+		// frames sent before timecode last jumped back aren't skipped
+		skipped := distance - 1 - s.recent.Between(s.prevFrameIndex, distance, s.frameDistance)
+		if skipped > 0 && warn {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "error": "skipped", "count": skipped},
+				"Skipped %d frames at %s", skipped, s.frame.Frame())
+		}
+		if skipped > 0 {
+			s.status.Dropped(skipped)
+		}
+	}
+	return true
+}
+
+// skew returns how far the timecode of the frame being sent leads the wall clock when the frame starts
+// playing, negative if it lags.  The frame plays once the output delay compensated for has passed,
+// and the offset asked for is taken out.  In clock mode a frame is sent just after it begins, so skew
+// is a little below 0 when compensation is right, and up to a frame below when frame timer ticks are
+// late.  Unlike the intra frame offset it measures against the wall clock itself, so it also shows
+// free run and monotonic timecode drifting away from it.
+func (s *frameScheduler) skew() time.Duration {
+	playing := s.clock.Now().Add(s.outputDelay)
+	return wrapDay(s.frame.FrameBeginTime().Add(-s.offset).Sub(playing))
+}
+
+// Paused returns true if timecode is being held
+func (s *frameScheduler) Paused() bool {
+	return s.paused
+}
+
+// Frame returns the most recently scheduled frame
+func (s *frameScheduler) Frame() glitc.LTCFrame {
+	return s.frame
+}
+
+// Prefill returns the n frames to send ahead of the next one when a look-ahead buffer is first filled.
+// The output delay should include the look-ahead so the next frame is the one that follows them.
+// Prefilled frames aren't counted in the status.
+func (s *frameScheduler) Prefill(n int) []glitc.LTCFrame {
+	frames := make([]glitc.LTCFrame, 0, n)
+	if s.paused {
+		for i := 0; i < n; i++ {
+			frames = append(frames, s.frame)
+		}
+		return frames
+	}
+	if s.freeRun {
+		// free run timecode starts from the first frame sent, so the prefill comes first
+		for i := 0; i < n; i++ {
+			s.frame.Time = s.freeRunBase.Add(time.Duration(s.freeRunCount) * s.frame.FrameDuration())
+			s.freeRunCount += s.direction()
+			s.prevFrameIndex = s.frame.FrameIndex()
+			frames = append(frames, s.frame)
+		}
+		return frames
+	}
+
+	for i := n; i > 0; i-- {
+		frame := s.frame
+		frame.Time = frame.Time.Add(-time.Duration(i) * frame.FrameDuration())
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// Next returns the frame to send for a frame timer tick at t.  ok is false if the frame would repeat
+// the previous one and shouldn't be sent.
+func (s *frameScheduler) Next(t time.Time) (frame glitc.LTCFrame, ok bool) {
+	if s.paused {
+		s.status.Sent(s.clock.Now().Sub(t))
+		return s.frame, true
+	}
+
+	var intraFrameOffset time.Duration
+	if s.freeRun {
+		s.frame.Time = s.freeRunBase.Add(time.Duration(s.freeRunCount) * s.frame.FrameDuration())
+		s.freeRunCount += s.direction()
+		intraFrameOffset = s.clock.Now().Sub(t)
+	} else {
+		if !s.monotonic {
+			s.checkLeapSecond()
+		}
+		s.frame.Time = s.timecodeTime(t)
+		intraFrameOffset = s.timecodeTime(s.clock.Now()).Sub(s.frame.FrameBeginTime())
+	}
+	if window := s.bufferWindow(); outsideWindow(intraFrameOffset, window) {
+		s.status.OutsideWindow()
+		if ok, suppressed := s.windowWarnings.Allow(s.clock.Now()); ok {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "offset_ns": intraFrameOffset, "window_ns": window, "suppressed": suppressed},
+				"Intra frame offset %s outside output buffer window 0-%s, %d more since the last warning",
+				intraFrameOffset, window, suppressed)
+		}
+	}
+
+	thisFrameIndex := s.frame.FrameIndex()
+	warn := false
+	if distance := s.frameDistance(s.prevFrameIndex, thisFrameIndex); s.prevFrameIndex != noFrame && distance != 1 {
+		var suppressed int
+		if warn, suppressed = s.frameErrorWarnings.Allow(s.clock.Now()); warn {
+			logWarningf(logFields{"timecode": s.frame.Frame().String(), "offset_ns": intraFrameOffset, "suppressed": suppressed},
+				"Frame error detected: current intra frame offset: %s, %d more since the last warning", intraFrameOffset, suppressed)
+		}
+	} else if suppressed := s.frameErrorWarnings.Pending(s.clock.Now()); suppressed > 0 {
+		logWarningf(logFields{"timecode": s.frame.Frame().String(), "suppressed": suppressed},
+			"%d more frame errors since the last warning", suppressed)
+	}
+	if !s.countFrameErrors(thisFrameIndex, warn) {
+		return s.frame, false
+	}
+
+	s.status.Sent(intraFrameOffset)
+	s.status.Skew(s.skew())
+	s.prevFrameIndex = thisFrameIndex
+	s.recent.Add(thisFrameIndex)
+	return s.frame, true
+}
@@ -0,0 +1,783 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/go-test/deep"
+)
+
+func TestFrameScheduler(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 30}
+	frameDuration := frame.FrameDuration()
+	// start in the middle of a frame so tick times are well clear of frame boundaries
+	start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration / 2)
+	frame.Time = start
+	startIndex := frame.FrameIndex()
+
+	clock := newFakeClock(start)
+	status := NewStatus(100)
+	s := newFrameScheduler(clock, frame, 0, 0, status, false, nil, false, false)
+
+	// tick times in frames after start, the third fires early and the fifth late
+	ticks := []float64{1, 2, 2.25, 3, 5, 6}
+	var sent []int
+	for _, tick := range ticks {
+		clock.Advance(start.Add(time.Duration(tick * float64(frameDuration))).Sub(clock.Now()))
+		if f, ok := s.Next(clock.Now()); ok {
+			sent = append(sent, f.FrameIndex()-startIndex)
+		}
+	}
+
+	if diff := deep.Equal(sent, []int{1, 2, 3, 5, 6}); len(diff) > 0 {
+		t.Error("Sent frames don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	snapshot := status.Snapshot()
+	if snapshot.Sent != 5 || snapshot.Duplicate != 1 || snapshot.Dropped != 1 {
+		t.Errorf("Expected 5 sent, 1 duplicate and 1 dropped, got %d sent, %d duplicate and %d dropped",
+			snapshot.Sent, snapshot.Duplicate, snapshot.Dropped)
+	}
+}
+
+func TestFrameSchedulerFreeRun(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	frameDuration := frame.FrameDuration()
+	start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+	clock := newFakeClock(start)
+	status := NewStatus(100)
+	freeRunStart := glitc.TimeCode{Hour: 10, Minute: 0, Second: 59, Frame: 28, DropFrame: true}
+	s := newFrameScheduler(clock, frame, 0, 0, status, true, &freeRunStart, false, false)
+
+	// ticks jitter by most of a frame, but free run timecode only depends on the number of ticks
+	ticks := []float64{1, 2.9, 3.1, 5.5}
+	var sent []glitc.TimeCode
+	for _, tick := range ticks {
+		clock.Advance(start.Add(time.Duration(tick * float64(frameDuration))).Sub(clock.Now()))
+		if f, ok := s.Next(clock.Now()); ok {
+			sent = append(sent, f.Frame())
+		}
+	}
+
+	expected := []glitc.TimeCode{
+		{Hour: 10, Minute: 0, Second: 59, Frame: 28, DropFrame: true},
+		{Hour: 10, Minute: 0, Second: 59, Frame: 29, DropFrame: true},
+		{Hour: 10, Minute: 1, Second: 0, Frame: 2, DropFrame: true},
+		{Hour: 10, Minute: 1, Second: 0, Frame: 3, DropFrame: true},
+	}
+	if diff := deep.Equal(sent, expected); len(diff) > 0 {
+		t.Error("Sent frames don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	snapshot := status.Snapshot()
+	if snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+		t.Errorf("Expected no frame errors, got %d duplicate and %d dropped", snapshot.Duplicate, snapshot.Dropped)
+	}
+}
+
+func TestFrameSchedulerReverse(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	frameDuration := frame.FrameDuration()
+	start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+	clock := newFakeClock(start)
+	status := NewStatus(100)
+	freeRunStart := glitc.TimeCode{Hour: 10, Minute: 1, Second: 0, Frame: 3, DropFrame: true}
+	s := newFrameScheduler(clock, frame, 0, 0, status, true, &freeRunStart, true, false)
+
+	var sent []glitc.TimeCode
+	for i := 1; i <= 4; i++ {
+		clock.Advance(frameDuration)
+		if f, ok := s.Next(clock.Now()); ok {
+			sent = append(sent, f.Frame())
+		}
+	}
+
+	expected := []glitc.TimeCode{
+		{Hour: 10, Minute: 1, Second: 0, Frame: 3, DropFrame: true},
+		{Hour: 10, Minute: 1, Second: 0, Frame: 2, DropFrame: true},
+		{Hour: 10, Minute: 0, Second: 59, Frame: 29, DropFrame: true},
+		{Hour: 10, Minute: 0, Second: 59, Frame: 28, DropFrame: true},
+	}
+	if diff := deep.Equal(sent, expected); len(diff) > 0 {
+		t.Error("Sent frames don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+
+	snapshot := status.Snapshot()
+	if snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+		t.Errorf("Expected no frame errors, got %d duplicate and %d dropped", snapshot.Duplicate, snapshot.Dropped)
+	}
+}
+
+func TestFrameSchedulerOffset(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Frame  glitc.LTCFrame
+		Offset time.Duration
+	}{
+		{"30/1h", glitc.LTCFrame{FramesPerSecond: 30}, time.Hour},
+		{"25/-10s", glitc.LTCFrame{FramesPerSecond: 25}, -10 * time.Second},
+		{"29.97df/1h", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, time.Hour},
+		{"29.97df/-10s", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, -10 * time.Second},
+		{"29.97df/partial frame", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, 90*time.Second + 10*time.Millisecond},
+		{"29.97df/mid window", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, time.Hour + 7*time.Minute + 33*time.Second},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frameDuration := c.Frame.FrameDuration()
+			// the frame timer ticks in the middle of offset frames, as main aligns it
+			c.Frame.Time = time.Date(2018, 12, 1, 10, 14, 21, 0, time.Local).Add(c.Offset)
+			start := c.Frame.FrameBeginTime().Add(frameDuration / 2).Add(-c.Offset)
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, c.Frame, 0, c.Offset, status, false, nil, false, false)
+
+			var sent, expected []glitc.TimeCode
+			for i := 1; i <= 5; i++ {
+				clock.Advance(frameDuration)
+				if f, ok := s.Next(clock.Now()); ok {
+					sent = append(sent, f.Frame())
+				}
+				wallClock := c.Frame
+				wallClock.Time = clock.Now().Add(c.Offset)
+				expected = append(expected, wallClock.Frame())
+			}
+
+			if diff := deep.Equal(sent, expected); len(diff) > 0 {
+				st.Error("Sent frames don't match wall clock plus offset:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+
+			snapshot := status.Snapshot()
+			if snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+				st.Errorf("Expected no frame errors, got %d duplicate and %d dropped", snapshot.Duplicate, snapshot.Dropped)
+			}
+			if snapshot.OffsetMax < frameDuration/2-time.Microsecond || snapshot.OffsetMax > frameDuration/2+time.Microsecond {
+				st.Errorf("Expected frames to be sent half a frame in, got offset %s", snapshot.OffsetMax)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerPause(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		FreeRun  bool
+		Expected []int
+	}{
+		// frames 3 and 4 are sent while paused, free run continues from the held frame and
+		// otherwise timecode jumps back to the clock
+		{"Clock", false, []int{1, 2, 2, 2, 5, 6}},
+		{"FreeRun", true, []int{1, 2, 2, 2, 3, 4}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 30}
+			frameDuration := frame.FrameDuration()
+			start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration / 2)
+			frame.Time = start
+			startIndex := frame.FrameIndex()
+
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 0, 0, status, c.FreeRun, nil, false, false)
+
+			var sent []int
+			for tick := 1; tick <= 6; tick++ {
+				switch tick {
+				case 3:
+					s.Pause()
+					if !status.Snapshot().Paused {
+						st.Error("Status should be paused")
+					}
+				case 5:
+					s.Resume()
+				}
+				clock.Advance(frameDuration)
+				if f, ok := s.Next(clock.Now()); ok {
+					sent = append(sent, f.FrameIndex()-startIndex)
+				}
+			}
+
+			if diff := deep.Equal(sent, c.Expected); len(diff) > 0 {
+				st.Error("Sent frames don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+
+			snapshot := status.Snapshot()
+			if snapshot.Paused || snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+				st.Errorf("Expected resumed status without frame errors, got paused %v, %d duplicate and %d dropped",
+					snapshot.Paused, snapshot.Duplicate, snapshot.Dropped)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerFrameErrors(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		Indexes           []int
+		ExpectedSent      []int
+		ExpectedDuplicate int64
+		ExpectedDropped   int64
+	}{
+		{"InOrder", []int{1, 2, 3, 4}, []int{1, 2, 3, 4}, 0, 0},
+		{"Adjacent", []int{1, 2, 2, 4}, []int{1, 2, 4}, 1, 1},
+		// jumping back repeats frame 1, and frame 2 isn't skipped on the way to 3
+		{"BackThenForward", []int{1, 2, 1, 3}, []int{1, 2, 1, 3}, 1, 0},
+		{"BackTwice", []int{1, 2, 3, 1, 2, 3, 4}, []int{1, 2, 3, 1, 2, 3, 4}, 3, 0},
+		// frame 2 was skipped, so sending it late isn't a duplicate
+		{"Late", []int{1, 3, 2, 4}, []int{1, 3, 2, 4}, 0, 1},
+		{"DuplicateThenGap", []int{1, 2, 2, 2, 6, 7}, []int{1, 2, 6, 7}, 2, 3},
+		{"GapAroundRepeats", []int{1, 2, 3, 2, 8}, []int{1, 2, 3, 2, 8}, 1, 4},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 25}
+			frameDuration := frame.FrameDuration()
+			frame.Time = time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+			base := frame.FrameBeginTime().Add(frameDuration / 2)
+			frame.Time = base
+			baseIndex := frame.FrameIndex()
+
+			clock := newFakeClock(base)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 0, 0, status, false, nil, false, false)
+
+			var sent []int
+			for _, index := range c.Indexes {
+				target := base.Add(time.Duration(index) * frameDuration)
+				clock.Step(target.Sub(clock.Now()))
+				if f, ok := s.Next(clock.Now()); ok {
+					sent = append(sent, f.FrameIndex()-baseIndex)
+				}
+			}
+
+			if diff := deep.Equal(sent, c.ExpectedSent); len(diff) > 0 {
+				st.Error("Sent frames don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+			snapshot := status.Snapshot()
+			if snapshot.Duplicate != c.ExpectedDuplicate || snapshot.Dropped != c.ExpectedDropped {
+				st.Errorf("Expected %d duplicate and %d dropped, got %d and %d",
+					c.ExpectedDuplicate, c.ExpectedDropped, snapshot.Duplicate, snapshot.Dropped)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerHold(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Frame    glitc.LTCFrame
+		TimeCode string
+	}{
+		{"NonDropFrame", glitc.LTCFrame{FramesPerSecond: 25}, "10:00:00:00"},
+		{"DropFrame", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true, PullDown: true}, "01:09:59;29"},
+		{"PullDown", glitc.LTCFrame{FramesPerSecond: 24, PullDown: true}, "23:59:59:20"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			tc, err := glitc.ParseTimeCode(c.TimeCode)
+			if err != nil {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+			frame := c.Frame
+			frameDuration := frame.FrameDuration()
+			frame.Time = time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration / 2)
+
+			clock := newFakeClock(frame.Time)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 0, 0, status, false, nil, false, false)
+			if err := s.Hold(tc); err != nil {
+				st.Fatalf("Unexpected error result: %v", err)
+			}
+
+			template := glitc.LTCFrame{Time: frame.Time, FramesPerSecond: frame.FramesPerSecond, PullDown: frame.PullDown}
+			for tick := 0; tick < 100; tick++ {
+				clock.Advance(frameDuration)
+				f, ok := s.Next(clock.Now())
+				if !ok {
+					st.Fatalf("Frame %d wasn't sent", tick)
+				}
+				decoded, err := template.DecodeFrame(f.EncodeFrame())
+				if err != nil {
+					st.Fatalf("Unable to decode frame %d: %v", tick, err)
+				}
+				if decoded.Frame() != tc {
+					st.Fatalf("Frame %d decoded as %s, expected %s", tick, decoded.Frame(), tc)
+				}
+			}
+			if snapshot := status.Snapshot(); snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+				st.Errorf("Expected no frame errors, got %d duplicate and %d dropped", snapshot.Duplicate, snapshot.Dropped)
+			}
+
+			if err := s.Hold(glitc.TimeCode{Hour: 10, Frame: 30}); err == nil {
+				st.Error("Expected an error holding a frame number that isn't sent")
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerFrameErrorWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l *jsonLogger) { jsonLog = l }(jsonLog)
+	jsonLog = &jsonLogger{w: &buf, now: time.Now}
+
+	frame := glitc.LTCFrame{FramesPerSecond: 25}
+	frameDuration := frame.FrameDuration()
+	frame.Time = time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+	base := frame.FrameBeginTime().Add(frameDuration / 2)
+	frame.Time = base
+
+	clock := newFakeClock(base)
+	status := NewStatus(100)
+	s := newFrameScheduler(clock, frame, 0, 0, status, false, nil, false, false)
+	s.SetFrameErrorInterval(time.Second)
+
+	// a burst of duplicates, then a second of good frames
+	indexes := []int{1, 2, 2, 2, 2}
+	for i := 3; i <= 30; i++ {
+		indexes = append(indexes, i)
+	}
+	for _, index := range indexes {
+		target := base.Add(time.Duration(index) * frameDuration)
+		clock.Step(target.Sub(clock.Now()))
+		s.Next(clock.Now())
+	}
+
+	var messages []string
+	for dec := json.NewDecoder(&buf); dec.More(); {
+		var entry struct{ Msg string }
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("Unable to decode log: %v", err)
+		}
+		messages = append(messages, entry.Msg)
+	}
+	expected := []string{
+		"Frame error detected: current intra frame offset: 20ms, 0 more since the last warning",
+		"Would have output duplicate frame number at 23:14:21:02, skipping",
+		"2 more frame errors since the last warning",
+	}
+	if diff := deep.Equal(messages, expected); len(diff) > 0 {
+		t.Error("Logged warnings don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+	if snapshot := status.Snapshot(); snapshot.Duplicate != 3 {
+		t.Errorf("Expected every duplicate counted, got %d", snapshot.Duplicate)
+	}
+}
+
+func TestFrameSchedulerJam(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Frame    glitc.LTCFrame
+		FreeRun  bool
+		Jam      string
+		Expected []string
+	}{
+		{"Clock", glitc.LTCFrame{FramesPerSecond: 30}, false, "10:00:00:00",
+			[]string{"23:14:21:01", "23:14:21:02", "10:00:00:00", "10:00:00:01", "10:00:00:02"}},
+		{"FreeRun", glitc.LTCFrame{FramesPerSecond: 30}, true, "10:00:00:00",
+			[]string{"23:14:21:01", "23:14:21:02", "10:00:00:00", "10:00:00:01", "10:00:00:02"}},
+		{"DropFrame", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, false, "10:00:59;29",
+			[]string{"23:14:21;01", "23:14:21;02", "10:00:59;29", "10:01:00;02", "10:01:00;03"}},
+		// pulled down timecode runs on through the end of its day, past the clock's midnight
+		{"PullDown", glitc.LTCFrame{FramesPerSecond: 30, PullDown: true}, false, "23:59:59:28",
+			[]string{"23:12:57:13", "23:12:57:14", "23:59:59:28", "23:59:59:29", "00:00:00:00"}},
+		{"PullDownFreeRun", glitc.LTCFrame{FramesPerSecond: 30, PullDown: true}, true, "23:59:59:28",
+			[]string{"23:12:57:13", "23:12:57:14", "23:59:59:28", "23:59:59:29", "00:00:00:00"}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := c.Frame
+			frameDuration := frame.FrameDuration()
+			frame.Time = time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+			start := frame.FrameBeginTime().Add(frameDuration / 2)
+
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 0, 0, status, c.FreeRun, nil, false, false)
+			tc, err := glitc.ParseTimeCode(c.Jam)
+			if err != nil {
+				st.Fatalf("Unable to parse %s: %v", c.Jam, err)
+			}
+
+			var sent []string
+			for tick := 1; tick <= 5; tick++ {
+				if tick == 3 {
+					if err := s.Jam(tc); err != nil {
+						st.Fatalf("Unable to jam to %s: %v", tc, err)
+					}
+				}
+				clock.Advance(frameDuration)
+				if f, ok := s.Next(clock.Now()); ok {
+					sent = append(sent, f.Frame().String())
+				}
+			}
+
+			if diff := deep.Equal(sent, c.Expected); len(diff) > 0 {
+				st.Error("Sent frames don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+			snapshot := status.Snapshot()
+			if snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+				st.Errorf("Expected no frame errors, got %d duplicate and %d dropped", snapshot.Duplicate, snapshot.Dropped)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerJamInvalid(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 25}
+	clock := newFakeClock(time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local))
+	s := newFrameScheduler(clock, frame, 0, 0, NewStatus(100), false, nil, false, false)
+	for _, tc := range []glitc.TimeCode{
+		{Hour: 10, Frame: 25},
+		{Hour: 10, DropFrame: true},
+	} {
+		if err := s.Jam(tc); err == nil {
+			t.Errorf("Expected an error jamming to %s", tc)
+		}
+	}
+}
+
+func TestFrameSchedulerClockStep(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Monotonic bool
+		Repeated  int
+	}{
+		// following the wall clock back repeats the frames sent in the last 15 frame periods
+		{"Wall", false, 15},
+		{"Monotonic", true, 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 30}
+			frameDuration := frame.FrameDuration()
+			start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration / 2)
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 0, 0, status, false, nil, false, c.Monotonic)
+
+			sent := make(map[glitc.TimeCode]bool)
+			repeated := 0
+			for tick := 1; tick <= 40; tick++ {
+				if tick == 20 {
+					// NTP steps the clock back by 15 frames
+					clock.Step(-15 * frameDuration)
+				}
+				clock.Advance(frameDuration)
+				if f, ok := s.Next(clock.Now()); ok {
+					if sent[f.Frame()] {
+						repeated++
+					}
+					sent[f.Frame()] = true
+				}
+			}
+
+			if repeated != c.Repeated {
+				st.Errorf("Expected %d repeated frames, got %d", c.Repeated, repeated)
+			}
+			snapshot := status.Snapshot()
+			if c.Monotonic && (snapshot.Duplicate != 0 || snapshot.Dropped != 0) {
+				st.Errorf("Expected no frame errors, got %d duplicate and %d dropped", snapshot.Duplicate, snapshot.Dropped)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerSkew(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Monotonic bool
+		Step      time.Duration
+		MinSkew   time.Duration
+		MaxSkew   time.Duration
+	}{
+		// frames are sent a quarter of a frame after they begin
+		{"Wall", false, 0, -8333333, -8333333},
+		// the wall clock stepping doesn't show up in the intra frame offset in monotonic mode
+		{"MonotonicStep", true, 100 * time.Millisecond, -108333333, -8333333},
+	}
+	// frame begin times are counted from midnight in whole nanosecond frame durations, so drift a
+	// little from the clock's frame boundaries late in the day
+	const tolerance = 100 * time.Microsecond
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 30}
+			frameDuration := frame.FrameDuration()
+			outputDelay := 20 * time.Millisecond
+			start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration/4 - outputDelay)
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, outputDelay, time.Hour, status, false, nil, false, c.Monotonic)
+
+			for tick := 1; tick <= 20; tick++ {
+				if tick == 10 {
+					clock.Step(c.Step)
+				}
+				clock.Advance(frameDuration)
+				s.Next(clock.Now())
+			}
+
+			snapshot := status.Snapshot()
+			if d := snapshot.SkewMin - c.MinSkew; d < -tolerance || d > tolerance {
+				st.Errorf("Expected minimum skew %s, got %s", c.MinSkew, snapshot.SkewMin)
+			}
+			if d := snapshot.SkewMax - c.MaxSkew; d < -tolerance || d > tolerance {
+				st.Errorf("Expected maximum skew %s, got %s", c.MaxSkew, snapshot.SkewMax)
+			}
+			if snapshot.OffsetMax > frameDuration/4+tolerance {
+				st.Errorf("Expected intra frame offsets of a quarter frame, got up to %s", snapshot.OffsetMax)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerLeapSecond(t *testing.T) {
+	midnight := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Name       string
+		Step       time.Duration
+		At         time.Time
+		Duplicates int64
+		Dropped    int64
+	}{
+		// the clock repeats 23:59:59 and so does timecode, once, without counting it as 30 duplicates
+		{"Inserted", -time.Second, midnight, 0, 0},
+		// the clock jumps from 23:59:59 to midnight and so does timecode
+		{"Removed", time.Second, midnight.Add(-time.Second), 0, 0},
+		// the same step at any other time is a clock error, every frame of the repeated second is counted
+		{"NotLeap", -time.Second, midnight.Add(-6 * time.Hour), 30, 0},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 30}
+			frameDuration := frame.FrameDuration()
+			clock := newFakeClock(c.At.Add(-2 * time.Second).Add(frameDuration / 2))
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 0, 0, status, false, nil, false, false)
+
+			stepped := false
+			for tick := 1; tick <= 150; tick++ {
+				clock.Advance(frameDuration)
+				if !stepped && !clock.Now().Before(c.At) {
+					clock.Step(c.Step)
+					stepped = true
+				}
+				s.Next(clock.Now())
+			}
+
+			snapshot := status.Snapshot()
+			if snapshot.Duplicate != c.Duplicates || snapshot.Dropped != c.Dropped {
+				st.Errorf("Expected %d duplicate and %d dropped frames, got %d and %d",
+					c.Duplicates, c.Dropped, snapshot.Duplicate, snapshot.Dropped)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerPrefill(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		FreeRun bool
+	}{
+		{"Clock", false},
+		{"FreeRun", true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 25}
+			frameDuration := frame.FrameDuration()
+			start := time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local).Add(frameDuration / 2)
+			frame.Time = start
+			startIndex := frame.FrameIndex()
+
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, 3*frameDuration, 0, status, c.FreeRun, nil, false, false)
+
+			// the first tick fills the buffer ahead of the frame due then, later ticks send one frame
+			var sent []int
+			for tick := 1; tick <= 4; tick++ {
+				clock.Advance(frameDuration)
+				if tick == 1 {
+					for _, f := range s.Prefill(3) {
+						sent = append(sent, f.FrameIndex()-startIndex)
+					}
+				}
+				if f, ok := s.Next(clock.Now()); ok {
+					sent = append(sent, f.FrameIndex()-startIndex)
+				}
+			}
+
+			// frames follow on from the prefill, and in clock mode the first is the one playing at the first tick
+			first := 1
+			if c.FreeRun {
+				first = 4
+			}
+			var expected []int
+			for i := 0; i < 7; i++ {
+				expected = append(expected, first+i)
+			}
+			if diff := deep.Equal(sent, expected); len(diff) > 0 {
+				st.Error("Sent frames don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+
+			snapshot := status.Snapshot()
+			if snapshot.Sent != 4 || snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+				st.Errorf("Expected 4 sent and no frame errors, got %d sent, %d duplicate and %d dropped",
+					snapshot.Sent, snapshot.Duplicate, snapshot.Dropped)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerMidnight(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		Frame   glitc.LTCFrame
+		FreeRun bool
+		Reverse bool
+	}{
+		{"25fps", glitc.LTCFrame{FramesPerSecond: 25}, false, false},
+		{"29.97df", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, false, false},
+		{"29.97df/free run", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, true, false},
+		{"29.97df/reverse", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}, true, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frameDuration := c.Frame.FrameDuration()
+			// start a few frames before midnight, or after it when counting down
+			tc := glitc.TimeCode{Hour: 23, Minute: 59, Second: 59, Frame: int(c.Frame.FramesPerSecond) - 3, DropFrame: c.Frame.DropFrame}
+			if c.Reverse {
+				tc = glitc.TimeCode{Hour: 0, Minute: 0, Second: 0, Frame: 2, DropFrame: c.Frame.DropFrame}
+			}
+			frame := c.Frame
+			frame.SetTimeCode(tc, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+			start := frame.Time.Add(-frameDuration)
+
+			clock := newFakeClock(start)
+			status := NewStatus(100)
+			var freeRunStart *glitc.TimeCode
+			if c.FreeRun {
+				freeRunStart = &tc
+			}
+			s := newFrameScheduler(clock, c.Frame, 0, 0, status, c.FreeRun, freeRunStart, c.Reverse, false)
+
+			var sent []glitc.TimeCode
+			for tick := 1; tick <= 6; tick++ {
+				clock.Advance(frameDuration)
+				if f, ok := s.Next(clock.Now()); ok {
+					sent = append(sent, f.Frame())
+				}
+			}
+
+			if len(sent) != 6 || sent[0] != tc {
+				st.Fatalf("Expected 6 frames from %s, got %v", tc, sent)
+			}
+			snapshot := status.Snapshot()
+			if snapshot.Duplicate != 0 || snapshot.Dropped != 0 {
+				st.Errorf("Expected no frame errors across midnight, got %d duplicate and %d dropped: %v",
+					snapshot.Duplicate, snapshot.Dropped, sent)
+			}
+		})
+	}
+}
+
+func TestOutsideWindow(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Offset   time.Duration
+		Window   time.Duration
+		Expected bool
+	}{
+		{"Inside", time.Millisecond, 10 * time.Millisecond, false},
+		{"Start", 0, 10 * time.Millisecond, false},
+		{"End", 10 * time.Millisecond, 10 * time.Millisecond, false},
+		{"Late", 10*time.Millisecond + 1, 10 * time.Millisecond, true},
+		{"Early", -1, 10 * time.Millisecond, true},
+		{"NoWindow", time.Second, 0, false},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if outside := outsideWindow(c.Offset, c.Window); outside != c.Expected {
+				st.Errorf("Expected outsideWindow(%s, %s) to be %v", c.Offset, c.Window, c.Expected)
+			}
+		})
+	}
+}
+
+func TestFrameSchedulerOffsetWindow(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Window   time.Duration
+		Expected int64
+	}{
+		// half the 20ms output delay
+		{"Default", 0, 1},
+		{"Configured", 5 * time.Millisecond, 2},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			frame := glitc.LTCFrame{FramesPerSecond: 25}
+			frameDuration := frame.FrameDuration()
+			outputDelay := 20 * time.Millisecond
+			frame.Time = time.Date(2018, 12, 1, 23, 14, 21, 0, time.Local)
+			begin := frame.FrameBeginTime()
+
+			clock := newFakeClock(begin.Add(-outputDelay))
+			status := NewStatus(100)
+			s := newFrameScheduler(clock, frame, outputDelay, 0, status, false, nil, false, false)
+			s.SetOffsetWindow(c.Window)
+
+			// how far into each frame the samples are due when the tick fires
+			for i, offset := range []time.Duration{time.Millisecond, 10 * time.Millisecond, 15 * time.Millisecond, 2 * time.Millisecond} {
+				clock.Advance(begin.Add(time.Duration(i+1) * frameDuration).Add(-outputDelay).Add(offset).Sub(clock.Now()))
+				s.Next(clock.Now())
+			}
+
+			if outside := status.Snapshot().OutsideWindow; outside != c.Expected {
+				st.Errorf("Expected %d frames outside the window, got %d", c.Expected, outside)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/azenk/audio/stream/encoding"
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/spf13/viper"
+)
+
+// frameVerifier checks decoded frames, counting those that fail to decode or don't follow on from
+// the previous frame
+type frameVerifier struct {
+	template        glitc.LTCFrame
+	prev            *glitc.TimeCode
+	repeated        bool
+	verified        int
+	failed          int
+	discontinuities int
+}
+
+// Check verifies a single decoded frame
+func (v *frameVerifier) Check(binaryFrame []byte) {
+	frame, err := v.template.DecodeFrame(binaryFrame)
+	if err != nil {
+		logWarningf(logFields{"frame": fmt.Sprintf("%x", binaryFrame), "error": err.Error()}, "Unable to decode frame %x: %v", binaryFrame, err)
+		v.failed++
+		return
+	}
+
+	tc := frame.Frame()
+	if v.prev != nil {
+		expected := v.prev.Add(1, v.template.FramesPerSecond)
+		// above 30fps both frames of a pair carry the timecode of the first, so each timecode is
+		// expected twice before moving on to the next pair
+		if v.template.FramesPerSecond > 30 {
+			if tc == *v.prev && !v.repeated {
+				v.repeated = true
+				v.verified++
+				return
+			}
+			expected = v.prev.Add(2, v.template.FramesPerSecond)
+		}
+		v.repeated = false
+		if tc != expected {
+			logWarningf(logFields{"timecode": tc.String(), "expected": expected.String(), "previous": v.prev.String()},
+				"Discontinuity, expected %s after %s, got %s", expected, v.prev, tc)
+			v.discontinuities++
+		}
+	}
+	v.prev = &tc
+	v.verified++
+}
+
+// Err returns an error describing any failures
+func (v *frameVerifier) Err() error {
+	if v.failed == 0 && v.discontinuities == 0 {
+		return nil
+	}
+	return fmt.Errorf("self test failed, %d frames failed to decode and %d discontinuities", v.failed, v.discontinuities)
+}
+
+// selfTest encodes duration worth of frames through the same encoder and shaping used for output,
+// decodes the samples again and reports any frames that don't round trip
+func selfTest(ctx context.Context, cfgFile *viper.Viper, frame glitc.LTCFrame, amplitude float64) error {
+	var err error
+	if frame.Time, err = renderStartTime(); err != nil {
+		return err
+	}
+	rate, err := renderSampleRate(cfgFile)
+	if err != nil {
+		return err
+	}
+	sampleRate := float64(rate)
+	if err := checkSampleRate(frame, sampleRate); err != nil {
+		return err
+	}
+
+	rawFrameChan := make(chan byte, 160)
+	samplesPerFrame := int(math.Ceil(frame.SamplesPerFrame(sampleRate)))
+	encodedData := encoding.DifferentialManchester(ctx,
+		3*samplesPerFrame,
+		frame.EffectiveFPS()*80,
+		amplitude,
+		sampleRate,
+		rawFrameChan)
+
+	frames := int(duration.Seconds() * frame.EffectiveFPS())
+	logInfof(logFields{"frames": frames, "timecode": frame.Frame().String()}, "Self test of %d frames starting at %s", frames, frame.Frame())
+	// the encoding goroutine advances its own copy of frame, the verifier keeps the original as its template
+	go func(frame glitc.LTCFrame) {
+		for i := 0; i < frames; i++ {
+			for _, b := range frame.EncodeFrame() {
+				rawFrameChan <- b
+			}
+			frame.Time = frame.Time.Add(frame.FrameDuration())
+		}
+		close(rawFrameChan)
+	}(frame)
+
+	shaper := newSlewLimiter(riseTime(), sampleRate, amplitude)
+	decoder := glitc.NewLTCDecoder(sampleRate, frame.EffectiveFPS()*80)
+	verifier := &frameVerifier{template: frame}
+	samples := make([]int32, 0, samplesPerFrame)
+	for sample := range encodedData {
+		samples = append(samples, int32(shaper.Apply(sample)))
+		if len(samples) == cap(samples) {
+			for _, binaryFrame := range decoder.Decode(samples) {
+				verifier.Check(binaryFrame)
+			}
+			samples = samples[:0]
+		}
+	}
+	for _, binaryFrame := range decoder.Decode(samples) {
+		verifier.Check(binaryFrame)
+	}
+
+	// the final frame can't be decoded without the edge that would start the next one
+	fmt.Printf("Verified %d of %d frames, %d failed to decode, %d discontinuities\n",
+		verifier.verified, frames, verifier.failed, verifier.discontinuities)
+	if verifier.verified < frames-1 {
+		return fmt.Errorf("self test failed, only %d of %d frames were decoded", verifier.verified, frames)
+	}
+	return verifier.Err()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+	"github.com/spf13/viper"
+)
+
+func TestFrameVerifier(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}
+	frame.SetTimeCode(glitc.TimeCode{Hour: 10, Minute: 0, Second: 59, Frame: 28, DropFrame: true}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	v := &frameVerifier{template: frame}
+
+	// 00:59;28, 00:59;29 and 01:00;02 follow on across the dropped frames
+	for i := 0; i < 3; i++ {
+		v.Check(frame.EncodeFrame())
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+	if err := v.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// skip a frame, then send a corrupted frame
+	frame.Time = frame.Time.Add(frame.FrameDuration())
+	v.Check(frame.EncodeFrame())
+	corrupt := frame.EncodeFrame()
+	corrupt[0] ^= 0x80
+	v.Check(corrupt)
+
+	if v.verified != 4 || v.discontinuities != 1 || v.failed != 1 {
+		t.Errorf("Expected 4 verified, 1 discontinuity and 1 failure, got %d, %d and %d", v.verified, v.discontinuities, v.failed)
+	}
+	if v.Err() == nil {
+		t.Errorf("Expected error after failures")
+	}
+}
+
+func TestFrameVerifierFramePairs(t *testing.T) {
+	frame := glitc.LTCFrame{FramesPerSecond: 60}
+	frame.SetTimeCode(glitc.TimeCode{Hour: 10, Second: 59, Frame: 56}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	v := &frameVerifier{template: frame}
+
+	// both frames of each pair carry the same timecode
+	for i := 0; i < 8; i++ {
+		v.Check(frame.EncodeFrame())
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+	if err := v.Err(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// skipping a pair is a discontinuity
+	frame.Time = frame.Time.Add(2 * frame.FrameDuration())
+	v.Check(frame.EncodeFrame())
+	if v.discontinuities != 1 {
+		t.Errorf("Expected 1 discontinuity after skipping a pair, got %d", v.discontinuities)
+	}
+
+	// as is a third copy of the same pair
+	v.Check(frame.EncodeFrame())
+	v.Check(frame.EncodeFrame())
+	if v.discontinuities != 2 {
+		t.Errorf("Expected 2 discontinuities after a pair sent three times, got %d", v.discontinuities)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	defer func(d time.Duration) { *duration = d }(*duration)
+	*duration = time.Second
+
+	testCases := []struct {
+		Name  string
+		Frame glitc.LTCFrame
+	}{
+		{"25fps", glitc.LTCFrame{FramesPerSecond: 25}},
+		{"29.97df", glitc.LTCFrame{FramesPerSecond: 30, DropFrame: true}},
+		{"23.976", glitc.LTCFrame{FramesPerSecond: 24, PullDown: true}},
+		{"50fps", glitc.LTCFrame{FramesPerSecond: 50}},
+		{"60fps", glitc.LTCFrame{FramesPerSecond: 60}},
+		{"59.94df", glitc.LTCFrame{FramesPerSecond: 60, DropFrame: true}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if err := selfTest(context.Background(), viper.New(), c.Frame, 0.5); err != nil {
+				st.Errorf("Unexpected error result: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package serial writes the current timecode as a line of ASCII to a serial port, for decks and
+// displays that read timecode over RS-232 or RS-422.  Lines are written as each frame starts, so they
+// are only as accurate as the port's buffering allows, unlike the sample accurate LTC.
+package serial
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+// writeTimeout bounds how long a single line may take to write before it is dropped
+const writeTimeout = 100 * time.Millisecond
+
+// Line returns the line sent for tc, hh:mm:ss:ff followed by a newline, or hh:mm:ss;ff for drop frame
+func Line(tc glitc.TimeCode) []byte {
+	return []byte(tc.String() + "\n")
+}
+
+// deadlineWriter is a writer that can bound how long a write blocks, such as a serial port opened
+// with Open
+type deadlineWriter interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// Writer writes a line for each frame passed to WriteFrame from its own goroutine.  Lines are dropped
+// if the port falls behind, so a stuck port never holds up the caller.
+type Writer struct {
+	// dropped is updated atomically so it comes first to keep it 64-bit aligned on 32-bit platforms
+	dropped int64
+	out     io.Writer
+	lines   chan []byte
+	doneCh  chan error
+}
+
+// NewWriter starts writing lines to out, typically a serial port returned by Open.  Writing stops once
+// ctx is cancelled.
+func NewWriter(ctx context.Context, out io.Writer) *Writer {
+	w := &Writer{
+		out:    out,
+		lines:  make(chan []byte, 2),
+		doneCh: make(chan error, 1),
+	}
+	go w.write(ctx)
+	return w
+}
+
+// WriteFrame queues the line for frame, it should be called as each frame starts
+func (w *Writer) WriteFrame(frame glitc.LTCFrame) {
+	select {
+	case w.lines <- Line(frame.Frame()):
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+	}
+}
+
+// Dropped returns the number of lines that couldn't be written
+func (w *Writer) Dropped() int64 {
+	return atomic.LoadInt64(&w.dropped)
+}
+
+// Done returns a channel that receives any write error and is closed once writing stops
+func (w *Writer) Done() chan error {
+	return w.doneCh
+}
+
+func (w *Writer) write(ctx context.Context) {
+	defer close(w.doneCh)
+
+	deadline, _ := w.out.(deadlineWriter)
+	for {
+		select {
+		case line := <-w.lines:
+			if deadline != nil {
+				deadline.SetWriteDeadline(time.Now().Add(writeTimeout))
+			}
+			if _, err := w.out.Write(line); err != nil {
+				if os.IsTimeout(err) {
+					atomic.AddInt64(&w.dropped, 1)
+					continue
+				}
+				w.doneCh <- err
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package serial
+
+import (
+	"errors"
+	"os"
+)
+
+// Open is only supported on linux
+func Open(device string, baud int) (*os.File, error) {
+	return nil, errors.New("serial ports are only supported on linux")
+}
@@ -0,0 +1,117 @@
+package serial
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/azenk/ltcgen/glitc"
+)
+
+func TestLine(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		TimeCode glitc.TimeCode
+		Expected string
+	}{
+		{"NonDropFrame", glitc.TimeCode{Hour: 23, Minute: 14, Second: 21, Frame: 29}, "23:14:21:29\n"},
+		{"DropFrame", glitc.TimeCode{Hour: 1, Minute: 2, Second: 3, Frame: 4, DropFrame: true}, "01:02:03;04\n"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if line := string(Line(c.TimeCode)); line != c.Expected {
+				st.Errorf("Expected line %q, got %q", c.Expected, line)
+			}
+		})
+	}
+}
+
+// syncBuffer is a buffer safe to write from the writer's goroutine while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out syncBuffer
+	w := NewWriter(ctx, &out)
+	frame := glitc.LTCFrame{FramesPerSecond: 25}
+	frame.SetTimeCode(glitc.TimeCode{Hour: 10, Minute: 1, Second: 2, Frame: 3}, time.Date(2018, 12, 1, 0, 0, 0, 0, time.Local))
+	expected := ""
+	for i := 0; i < 3; i++ {
+		w.WriteFrame(frame)
+		expected += string(Line(frame.Frame()))
+		// give the writer time to take each line so none are dropped
+		for deadline := time.Now().Add(5 * time.Second); out.String() != expected && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		frame.Time = frame.Time.Add(frame.FrameDuration())
+	}
+
+	if out.String() != "10:01:02:03\n10:01:02:04\n10:01:02:05\n" {
+		t.Errorf("Unexpected lines written: %q", out.String())
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("Expected no dropped lines, got %d", w.Dropped())
+	}
+	cancel()
+	for err := range w.Done() {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
+
+// stuckWriter never completes a write, like a port held up by flow control
+type stuckWriter struct {
+	release chan struct{}
+}
+
+func (w stuckWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return len(p), nil
+}
+
+func TestWriterStuck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	out := stuckWriter{release: make(chan struct{})}
+	defer close(out.release)
+	w := NewWriter(ctx, out)
+	frame := glitc.LTCFrame{FramesPerSecond: 30}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			w.WriteFrame(frame)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("WriteFrame blocked on a stuck port")
+	}
+	if w.Dropped() == 0 {
+		t.Error("Expected lines to be dropped while the port is stuck")
+	}
+}
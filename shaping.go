@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/azenk/audio/stream"
+)
+
+// slewLimiter limits how far the signal can move from one sample to the next, turning the square
+// wave from the encoder into a trapezoid with the configured rise time
+type slewLimiter struct {
+	maxStep int64
+	prev    int64
+	started bool
+}
+
+// newSlewLimiter returns a limiter taking riseTime to swing between -amplitude and +amplitude of full
+// scale at sampleRate.  Rise times shorter than a sample period leave the signal unchanged.
+func newSlewLimiter(riseTime time.Duration, sampleRate float64, amplitude float64) *slewLimiter {
+	samples := riseTime.Seconds() * sampleRate
+	if samples <= 1 {
+		return &slewLimiter{maxStep: math.MaxInt64}
+	}
+	return &slewLimiter{maxStep: int64(math.Ceil(2 * amplitude * math.MaxInt32 / samples))}
+}
+
+// checkRiseTime returns an error if riseTime is negative or so long that the signal wouldn't reach
+// its full level within half a bit period
+func checkRiseTime(riseTime time.Duration, bitPeriod time.Duration) error {
+	if riseTime < 0 || riseTime > bitPeriod/4 {
+		return fmt.Errorf("rise time must be between 0 and %s, a quarter of the bit period, got %s", bitPeriod/4, riseTime)
+	}
+	return nil
+}
+
+// Apply returns the next output sample for an input sample
+func (l *slewLimiter) Apply(sample stream.Sample) stream.Sample {
+	if !l.started {
+		l.started = true
+		l.prev = int64(sample)
+		return sample
+	}
+
+	step := int64(sample) - l.prev
+	if step > l.maxStep {
+		step = l.maxStep
+	} else if step < -l.maxStep {
+		step = -l.maxStep
+	}
+	l.prev += step
+	return stream.Sample(l.prev)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/azenk/audio/stream"
+	"github.com/go-test/deep"
+)
+
+func TestSlewLimiter(t *testing.T) {
+	// 100µs at 48kHz is 4.8 samples to swing from full scale negative to positive
+	l := newSlewLimiter(100*time.Microsecond, 48000, 1)
+	input := []stream.Sample{-math.MaxInt32, -math.MaxInt32, math.MaxInt32, math.MaxInt32, math.MaxInt32,
+		math.MaxInt32, math.MaxInt32, math.MaxInt32, -math.MaxInt32}
+
+	var output []stream.Sample
+	for _, sample := range input {
+		output = append(output, l.Apply(sample))
+	}
+
+	step := stream.Sample(math.Ceil(2 * math.MaxInt32 / 4.8))
+	expected := []stream.Sample{
+		-math.MaxInt32,
+		-math.MaxInt32,
+		-math.MaxInt32 + step,
+		-math.MaxInt32 + 2*step,
+		-math.MaxInt32 + 3*step,
+		-math.MaxInt32 + 4*step,
+		math.MaxInt32,
+		math.MaxInt32,
+		math.MaxInt32 - step,
+	}
+	if diff := deep.Equal(output, expected); len(diff) > 0 {
+		t.Error("Shaped samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestSlewLimiterShortRiseTime(t *testing.T) {
+	// 20µs is less than a sample period at 48kHz, so nothing changes
+	l := newSlewLimiter(20*time.Microsecond, 48000, 1)
+	for _, sample := range []stream.Sample{-math.MaxInt32, math.MaxInt32, math.MinInt32, 0} {
+		if shaped := l.Apply(sample); shaped != sample {
+			t.Errorf("Expected %d to be unchanged, got %d", sample, shaped)
+		}
+	}
+}
+
+func TestCheckRiseTime(t *testing.T) {
+	bitPeriod := 416 * time.Microsecond
+	testCases := []struct {
+		Name        string
+		RiseTime    time.Duration
+		ExpectError bool
+	}{
+		{"Off", 0, false},
+		{"SMPTE", 25 * time.Microsecond, false},
+		{"QuarterBit", 104 * time.Microsecond, false},
+		{"TooLong", 105 * time.Microsecond, true},
+		{"Negative", -time.Microsecond, true},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if err := checkRiseTime(c.RiseTime, bitPeriod); (err != nil) != c.ExpectError {
+				st.Errorf("Unexpected result for %s: %v", c.RiseTime, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package main
+
+import "os"
+
+// notifyPause does nothing, SIGUSR1 is only sent on unix
+func notifyPause(c chan<- os.Signal) {}
+
+// notifyMute does nothing, SIGUSR2 is only sent on unix
+func notifyMute(c chan<- os.Signal) {}
@@ -0,0 +1,20 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyPause relays SIGUSR1, which pauses and resumes timecode, to c
+func notifyPause(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR1)
+}
+
+// notifyMute relays SIGUSR2, which mutes and unmutes the LTC output, to c
+func notifyMute(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR2)
+}
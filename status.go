@@ -2,29 +2,46 @@ package main
 
 import (
 	"container/ring"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
+	"sync"
 	"time"
+
+	"github.com/azenk/ltcgen/control"
 )
 
+// DurationStatistics tracks the mean and variance of a series of durations using Welford's method.
+// The mean and sum of squared differences from it are kept in float64 nanoseconds, so they don't
+// overflow or accumulate rounding errors over millions of frames.
 type DurationStatistics struct {
-	n        int
-	average  time.Duration
-	variance int64
-	minMax   MinMaxDuration
+	n       int
+	average time.Duration
+	mean    float64
+	m2      float64
+	minMax  MinMaxDuration
 }
 
 func (s *DurationStatistics) Update(d time.Duration) {
 	s.n++
-	oldAvg := s.average
-	s.average = oldAvg + (d-oldAvg)/time.Duration(s.n)
-	s.variance += (d - s.average).Nanoseconds() * (d - oldAvg).Nanoseconds()
+	x := float64(d.Nanoseconds())
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+	s.average = time.Duration(math.Round(s.mean))
 	s.minMax.Update(d)
 }
 
+// Variance returns the sample variance in square nanoseconds
 func (s DurationStatistics) Variance() time.Duration {
+	return time.Duration(s.variance())
+}
+
+// variance returns the sample variance in square nanoseconds
+func (s DurationStatistics) variance() float64 {
 	if s.n > 1 {
-		return time.Duration(s.variance / int64(s.n-1))
+		return s.m2 / float64(s.n-1)
 	}
 	return 0
 }
@@ -34,7 +51,7 @@ func (s DurationStatistics) Slow(d time.Duration) bool {
 }
 
 func (s DurationStatistics) StdDev() time.Duration {
-	return time.Duration(math.Sqrt(float64(s.Variance().Nanoseconds())))
+	return time.Duration(math.Sqrt(s.variance()))
 }
 
 func (s DurationStatistics) String() string {
@@ -45,16 +62,19 @@ type MinMaxDuration struct {
 	min     time.Duration
 	max     time.Duration
 	current time.Duration
+	updated bool
 }
 
 func (m *MinMaxDuration) Update(d time.Duration) {
-	if d < m.min || m.min == 0 {
+	if d < m.min || !m.updated {
 		m.min = d
-	} else if d > m.max {
+	}
+	if d > m.max || !m.updated {
 		m.max = d
 	}
 
 	m.current = d
+	m.updated = true
 }
 
 func (m MinMaxDuration) Min() time.Duration {
@@ -87,7 +107,8 @@ func (r *TimeRing) Mark() {
 }
 
 func (r TimeRing) Latest() time.Time {
-	return r.Value.(time.Time)
+	val, _ := r.Value.(time.Time)
+	return val
 }
 
 func (r TimeRing) First() time.Time {
@@ -96,19 +117,41 @@ func (r TimeRing) First() time.Time {
 }
 
 func (r *TimeRing) AvgRate() float64 {
+	if r.marked == 0 {
+		return 0
+	}
 	elapsed := r.Latest().Sub(r.First())
 	return float64(r.marked) / elapsed.Seconds()
 }
 
 type Status struct {
+	mu          sync.Mutex
 	sent        int64
 	dropped     int64
 	duplicate   int64
 	largeOffset int64
+	outside     int64
 	start       time.Time
 	lastSent    time.Time
 	times       *TimeRing
 	offset      DurationStatistics
+	// skew is how far the start of each frame's timecode led the wall clock as it played
+	skew        DurationStatistics
+	outputDelay time.Duration
+	// delayCorrection is how far measurements have moved the output delay from the estimate
+	delayCorrection time.Duration
+	xruns           int64
+	reconnects      int64
+	deviceOpen      bool
+	paused          bool
+	muted           bool
+	buffered        int
+	// userBytes sent in every frame in hex, empty if none are set
+	userBytes string
+	// chasing is set while timecode follows a chase input, chaseError is the phase error to it
+	chasing     bool
+	chaseLocked bool
+	chaseError  time.Duration
 }
 
 func NewStatus(rateLen int) *Status {
@@ -118,7 +161,10 @@ func NewStatus(rateLen int) *Status {
 }
 
 func (s *Status) Sent(offset time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.times.Mark()
+	s.lastSent = time.Now()
 	s.sent++
 	s.offset.Update(offset)
 	if offset > time.Millisecond {
@@ -126,19 +172,258 @@ func (s *Status) Sent(offset time.Duration) {
 	}
 }
 
+// Skew records how far the timecode of a frame sent led the wall clock when it started playing,
+// negative if it lagged, see frameScheduler.skew
+func (s *Status) Skew(skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skew.Update(skew)
+}
+
 func (s *Status) Dropped(number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.dropped += int64(number)
 }
 
 func (s *Status) Duplicate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.duplicate++
 }
 
-func (s Status) FPS() float64 {
+// OutsideWindow counts a frame whose intra frame offset fell outside the output buffer window, so
+// its samples may have reached the device too late
+func (s *Status) OutsideWindow() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outside++
+}
+
+// SetOutputDelay records the output delay being compensated for
+func (s *Status) SetOutputDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outputDelay = delay
+}
+
+// SetDelayCorrection records how far the output delay compensated for has been moved from the
+// estimate at startup by measuring it
+func (s *Status) SetDelayCorrection(correction time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delayCorrection = correction
+}
+
+// SetXruns records the number of underruns reported by the audio device since it was opened
+func (s *Status) SetXruns(xruns int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.xruns = xruns
+}
+
+// SetReconnects records the number of times the audio device has been reopened after failing
+func (s *Status) SetReconnects(reconnects int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reconnects = reconnects
+}
+
+// SetDeviceOpen records whether the audio device is open and playing samples
+func (s *Status) SetDeviceOpen(open bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deviceOpen = open
+}
+
+// SetPaused records whether timecode is being held on a single frame
+func (s *Status) SetPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = paused
+}
+
+// SetMuted records whether the output is silenced while timecode keeps counting
+func (s *Status) SetMuted(muted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = muted
+}
+
+// SetUserBytes records the user bits sent in every frame
+func (s *Status) SetUserBytes(b [4]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userBytes = hex.EncodeToString(b[:])
+}
+
+// SetBuffered records the number of frames waiting in the look-ahead buffer
+func (s *Status) SetBuffered(frames int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buffered = frames
+}
+
+// SetChase records whether timecode is locked to the chase input and the phase error at the last
+// frame read from it
+func (s *Status) SetChase(locked bool, phaseError time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chasing = true
+	s.chaseLocked = locked
+	s.chaseError = phaseError
+}
+
+func (s *Status) FPS() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.times.AvgRate()
 }
 
-func (s Status) String() string {
+// StatusSnapshot is a point in time copy of the Status counters.  Offsets are encoded in JSON as
+// integer nanoseconds.
+type StatusSnapshot struct {
+	Sent            int64         `json:"sent"`
+	Dropped         int64         `json:"dropped"`
+	Duplicate       int64         `json:"duplicate"`
+	LargeOffset     int64         `json:"large_offset"`
+	OutsideWindow   int64         `json:"outside_window"`
+	FPS             float64       `json:"fps"`
+	OffsetMin       time.Duration `json:"offset_min_ns"`
+	OffsetMean      time.Duration `json:"offset_mean_ns"`
+	OffsetStdDev    time.Duration `json:"offset_stddev_ns"`
+	OffsetMax       time.Duration `json:"offset_max_ns"`
+	SkewMin         time.Duration `json:"skew_min_ns"`
+	SkewMean        time.Duration `json:"skew_mean_ns"`
+	SkewStdDev      time.Duration `json:"skew_stddev_ns"`
+	SkewMax         time.Duration `json:"skew_max_ns"`
+	OutputDelay     time.Duration `json:"output_delay_ns"`
+	DelayCorrection time.Duration `json:"delay_correction_ns"`
+	Xruns           int64         `json:"xruns"`
+	Reconnects      int64         `json:"reconnects"`
+	DeviceOpen      bool          `json:"device_open"`
+	LastSent        time.Time     `json:"-"`
+	Paused          bool          `json:"paused"`
+	Muted           bool          `json:"muted"`
+	Buffered        int           `json:"buffered_frames"`
+	UserBytes       string        `json:"user_bytes,omitempty"`
+	ChaseLocked     *bool         `json:"chase_locked,omitempty"`
+	ChaseError      time.Duration `json:"chase_error_ns,omitempty"`
+}
+
+// MarshalJSON encodes the snapshot, reporting an FPS of 0 until it can be measured since JSON can't
+// represent infinity
+func (s StatusSnapshot) MarshalJSON() ([]byte, error) {
+	type snapshot StatusSnapshot
+	if math.IsInf(s.FPS, 0) || math.IsNaN(s.FPS) {
+		s.FPS = 0
+	}
+	return json.Marshal(snapshot(s))
+}
+
+// Proto returns the snapshot as sent by the control service, with an FPS of 0 until it can be
+// measured like the JSON status
+func (s StatusSnapshot) Proto() *control.StatusSnapshot {
+	fps := s.FPS
+	if math.IsInf(fps, 0) || math.IsNaN(fps) {
+		fps = 0
+	}
+	return &control.StatusSnapshot{
+		Sent:              s.Sent,
+		Dropped:           s.Dropped,
+		Duplicate:         s.Duplicate,
+		LargeOffset:       s.LargeOffset,
+		OutsideWindow:     s.OutsideWindow,
+		Fps:               fps,
+		OffsetMinNs:       int64(s.OffsetMin),
+		OffsetMeanNs:      int64(s.OffsetMean),
+		OffsetStddevNs:    int64(s.OffsetStdDev),
+		OffsetMaxNs:       int64(s.OffsetMax),
+		SkewMinNs:         int64(s.SkewMin),
+		SkewMeanNs:        int64(s.SkewMean),
+		SkewStddevNs:      int64(s.SkewStdDev),
+		SkewMaxNs:         int64(s.SkewMax),
+		OutputDelayNs:     int64(s.OutputDelay),
+		DelayCorrectionNs: int64(s.DelayCorrection),
+		Xruns:             s.Xruns,
+		Reconnects:        s.Reconnects,
+		DeviceOpen:        s.DeviceOpen,
+		Paused:            s.Paused,
+		Muted:             s.Muted,
+		BufferedFrames:    int64(s.Buffered),
+		UserBytes:         s.UserBytes,
+		ChaseLocked:       s.ChaseLocked,
+		ChaseErrorNs:      int64(s.ChaseError),
+	}
+}
+
+// Snapshot returns a copy of the current counters that is safe to use from other goroutines
+func (s *Status) Snapshot() StatusSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var chaseLocked *bool
+	if s.chasing {
+		locked := s.chaseLocked
+		chaseLocked = &locked
+	}
+	return StatusSnapshot{
+		Sent:            s.sent,
+		Dropped:         s.dropped,
+		Duplicate:       s.duplicate,
+		LargeOffset:     s.largeOffset,
+		OutsideWindow:   s.outside,
+		FPS:             s.times.AvgRate(),
+		OffsetMin:       s.offset.minMax.Min(),
+		OffsetMean:      s.offset.average,
+		OffsetStdDev:    s.offset.StdDev(),
+		OffsetMax:       s.offset.minMax.Max(),
+		SkewMin:         s.skew.minMax.Min(),
+		SkewMean:        s.skew.average,
+		SkewStdDev:      s.skew.StdDev(),
+		SkewMax:         s.skew.minMax.Max(),
+		OutputDelay:     s.outputDelay,
+		DelayCorrection: s.delayCorrection,
+		Xruns:           s.xruns,
+		Reconnects:      s.reconnects,
+		DeviceOpen:      s.deviceOpen,
+		LastSent:        s.lastSent,
+		Paused:          s.paused,
+		Muted:           s.muted,
+		Buffered:        s.buffered,
+		UserBytes:       s.userBytes,
+		ChaseLocked:     chaseLocked,
+		ChaseError:      s.chaseError,
+	}
+}
+
+func (s *Status) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	pct := 100 * (1 - float64(s.largeOffset+s.dropped+s.duplicate)/float64(s.sent))
-	return fmt.Sprintf("%d frames sent - %0.2f%% perfect %d/%d/%d drop/dup/slow - frame start offset %s", s.sent, pct, s.dropped, s.duplicate, s.largeOffset, s.offset)
+	reconnects := ""
+	if s.reconnects != 0 {
+		reconnects = fmt.Sprintf(" - %d reconnects", s.reconnects)
+	}
+	delay := s.outputDelay.String()
+	if s.delayCorrection != 0 {
+		delay += fmt.Sprintf(" (%+.3fms from estimate)", float64(s.delayCorrection)/float64(time.Millisecond))
+	}
+	paused := ""
+	if s.paused {
+		paused = " - paused"
+	}
+	if s.muted {
+		paused += " - muted"
+	}
+	if s.userBytes != "" {
+		paused += " - user bytes " + s.userBytes
+	}
+	if s.chasing {
+		if s.chaseLocked {
+			paused += fmt.Sprintf(" - chase locked, phase error %s", s.chaseError)
+		} else {
+			paused += " - chase unlocked"
+		}
+	}
+	return fmt.Sprintf("%d frames sent - %0.2f%% perfect %d/%d/%d drop/dup/slow - %d outside buffer window - frame start offset %s - clock skew %s - output delay %s - %d frames buffered - %d xruns%s%s", s.sent, pct, s.dropped, s.duplicate, s.largeOffset, s.outside, s.offset, s.skew, delay, s.buffered, s.xruns, reconnects, paused)
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"math"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/go-test/deep"
 )
 
 func TestDurationStatistics(t *testing.T) {
@@ -22,6 +29,56 @@ func TestDurationStatistics(t *testing.T) {
 	}
 }
 
+func TestDurationStatisticsLargeSample(t *testing.T) {
+	// offsets of a few hundred microseconds around a large constant, which the old integer
+	// accumulator couldn't square without overflowing
+	const n = 2000000
+	values := make([]time.Duration, n)
+	for i := range values {
+		values[i] = 10*time.Second + time.Duration(i%1000)*time.Microsecond + time.Duration(i%7)*time.Nanosecond
+	}
+
+	s := DurationStatistics{}
+	var sum float64
+	for _, v := range values {
+		s.Update(v)
+		sum += float64(v)
+	}
+
+	// naive two pass computation
+	mean := sum / n
+	var squares float64
+	for _, v := range values {
+		squares += (float64(v) - mean) * (float64(v) - mean)
+	}
+	stddev := math.Sqrt(squares / (n - 1))
+
+	if diff := math.Abs(float64(s.average) - mean); diff > 1 {
+		t.Errorf("Mean %s differs from naive mean %f by %fns", s.average, mean, diff)
+	}
+	if diff := math.Abs(float64(s.StdDev()) - stddev); diff > 1 {
+		t.Errorf("Stddev %s differs from naive stddev %f by %fns", s.StdDev(), stddev, diff)
+	}
+}
+
+func TestMinMaxDuration(t *testing.T) {
+	m := MinMaxDuration{}
+	m.Update(5 * time.Millisecond)
+	if m.Min() != 5*time.Millisecond || m.Max() != 5*time.Millisecond {
+		t.Errorf("Single value should set min and max, got %s", m)
+	}
+
+	m.Update(-1 * time.Millisecond)
+	m.Update(7 * time.Millisecond)
+	m.Update(2 * time.Millisecond)
+	if m.Min() != -1*time.Millisecond {
+		t.Errorf("Incorrect min, expected -1ms got %s", m.Min())
+	}
+	if m.Max() != 7*time.Millisecond {
+		t.Errorf("Incorrect max, expected 7ms got %s", m.Max())
+	}
+}
+
 func TestSlow(t *testing.T) {
 	s := DurationStatistics{average: time.Millisecond}.Slow(1501 * time.Microsecond)
 	if !s {
@@ -43,3 +100,147 @@ func TestTimeRing(t *testing.T) {
 		t.Errorf("Got wrong rate: %f", rate)
 	}
 }
+
+func TestStatusJSON(t *testing.T) {
+	s := NewStatus(10)
+	s.Sent(2 * time.Millisecond)
+	s.Skew(-time.Millisecond)
+	s.Dropped(3)
+	s.Duplicate()
+	s.SetOutputDelay(20 * time.Millisecond)
+	s.SetXruns(4)
+	s.SetReconnects(1)
+	s.SetDeviceOpen(true)
+
+	b, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		t.Fatalf("Unable to marshal status: %v", err)
+	}
+
+	expected := `{"sent":1,"dropped":3,"duplicate":1,"large_offset":1,"outside_window":0,"fps":0,` +
+		`"offset_min_ns":2000000,"offset_mean_ns":2000000,"offset_stddev_ns":0,"offset_max_ns":2000000,` +
+		`"skew_min_ns":-1000000,"skew_mean_ns":-1000000,"skew_stddev_ns":0,"skew_max_ns":-1000000,"output_delay_ns":20000000,"delay_correction_ns":0,"xruns":4,"reconnects":1,"device_open":true,"paused":false,"muted":false,"buffered_frames":0}`
+	if string(b) != expected {
+		t.Errorf("Incorrect JSON status:\ngot      %s\nexpected %s", b, expected)
+	}
+}
+
+func TestStatusUserBytes(t *testing.T) {
+	s := NewStatus(10)
+	s.SetUserBytes([4]byte{0xA5, 0xC3, 0x91, 0x72})
+
+	b, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		t.Fatalf("Unable to marshal status: %v", err)
+	}
+	if !strings.Contains(string(b), `"user_bytes":"a5c39172"`) {
+		t.Errorf("Expected user bytes in JSON status, got %s", b)
+	}
+	if !strings.HasSuffix(s.String(), " - user bytes a5c39172") {
+		t.Errorf("Expected user bytes in status, got %s", s.String())
+	}
+}
+
+func TestStatusChase(t *testing.T) {
+	s := NewStatus(10)
+	b, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		t.Fatalf("Unable to marshal status: %v", err)
+	}
+	if strings.Contains(string(b), "chase") {
+		t.Errorf("Expected no chase fields without a chase input, got %s", b)
+	}
+
+	s.SetChase(true, 2*time.Millisecond)
+	if b, err = json.Marshal(s.Snapshot()); err != nil {
+		t.Fatalf("Unable to marshal status: %v", err)
+	}
+	if !strings.Contains(string(b), `"chase_locked":true,"chase_error_ns":2000000`) {
+		t.Errorf("Expected chase lock in JSON status, got %s", b)
+	}
+	if !strings.HasSuffix(s.String(), " - chase locked, phase error 2ms") {
+		t.Errorf("Expected chase lock in status, got %s", s.String())
+	}
+
+	s.SetChase(false, 0)
+	if !strings.HasSuffix(s.String(), " - chase unlocked") {
+		t.Errorf("Expected chase unlocked in status, got %s", s.String())
+	}
+}
+
+func TestStatusDelayCorrection(t *testing.T) {
+	s := NewStatus(10)
+	s.SetOutputDelay(20 * time.Millisecond)
+	if strings.Contains(s.String(), "from estimate") {
+		t.Errorf("Expected no delay correction in status before measuring, got %s", s.String())
+	}
+	s.SetDelayCorrection(-1500 * time.Microsecond)
+	if !strings.Contains(s.String(), "output delay 20ms (-1.500ms from estimate)") {
+		t.Errorf("Expected delay correction in status, got %s", s.String())
+	}
+	if c := s.Snapshot().DelayCorrection; c != -1500*time.Microsecond {
+		t.Errorf("Expected delay correction of -1.5ms in snapshot, got %s", c)
+	}
+}
+
+// TestStatusSnapshotProto checks the StatusSnapshot message in control/control.proto, which isn't
+// compiled, has the fields of the JSON status streamed by the control server
+func TestStatusSnapshotProto(t *testing.T) {
+	b, err := ioutil.ReadFile("control/control.proto")
+	if err != nil {
+		t.Fatalf("Unable to read control.proto: %v", err)
+	}
+	message := regexp.MustCompile(`(?s)message StatusSnapshot \{(.*?)\}`).FindSubmatch(b)
+	if message == nil {
+		t.Fatal("No StatusSnapshot message in control.proto")
+	}
+	var fields []string
+	for _, f := range regexp.MustCompile(`(?m)^\s*(?:optional )?\w+ (\w+) = \d+;`).FindAllSubmatch(message[1], -1) {
+		fields = append(fields, string(f[1]))
+	}
+
+	var expected []string
+	snapshot := reflect.TypeOf(StatusSnapshot{})
+	for i := 0; i < snapshot.NumField(); i++ {
+		if name := strings.Split(snapshot.Field(i).Tag.Get("json"), ",")[0]; name != "-" {
+			expected = append(expected, name)
+		}
+	}
+	if diff := deep.Equal(fields, expected); len(diff) > 0 {
+		t.Error("StatusSnapshot in control.proto doesn't match the JSON status:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestStatusSnapshotToProto(t *testing.T) {
+	locked := true
+	snapshot := StatusSnapshot{
+		Sent: 1, Dropped: 2, Duplicate: 3, LargeOffset: 4, OutsideWindow: 5, FPS: 29.97,
+		OffsetMin: 6, OffsetMean: 7, OffsetStdDev: 8, OffsetMax: 9,
+		SkewMin: 10, SkewMean: 11, SkewStdDev: 12, SkewMax: 13,
+		OutputDelay: 14, DelayCorrection: 15, Xruns: 16, Reconnects: 17, DeviceOpen: true,
+		Paused: true, Muted: true, Buffered: 18, UserBytes: "a5c39172", ChaseLocked: &locked, ChaseError: 19,
+	}
+
+	// every field is set in the snapshot, so every field of the message should be too
+	m := snapshot.Proto().ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if !m.Has(fields.Get(i)) {
+			t.Errorf("%s isn't set from the status", fields.Get(i).Name())
+		}
+	}
+
+	p := snapshot.Proto()
+	if p.OffsetMeanNs != 7 || p.Fps != 29.97 || p.BufferedFrames != 18 || !p.GetChaseLocked() {
+		t.Errorf("Snapshot converted incorrectly: %v", p)
+	}
+
+	snapshot.FPS = math.Inf(1)
+	snapshot.ChaseLocked = nil
+	if p := snapshot.Proto(); p.Fps != 0 || p.ChaseLocked != nil {
+		t.Errorf("Expected no FPS or chase lock before they're known, got %v", p)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "time"
+
+// logThrottle limits a repeating warning to one message per interval, counting the messages held back
+type logThrottle struct {
+	interval   time.Duration
+	last       time.Time
+	suppressed int
+}
+
+// newLogThrottle returns a throttle allowing one message per interval
+func newLogThrottle(interval time.Duration) *logThrottle {
+	return &logThrottle{interval: interval}
+}
+
+// Allow returns true if a message should be logged at now, along with the number of messages held back
+// since the last one logged
+func (l *logThrottle) Allow(now time.Time) (bool, int) {
+	if !l.last.IsZero() && now.Sub(l.last) < l.interval {
+		l.suppressed++
+		return false, 0
+	}
+	suppressed := l.suppressed
+	l.last = now
+	l.suppressed = 0
+	return true, suppressed
+}
+
+// Pending returns the number of messages held back once the interval since the last one logged has
+// passed, so they can be summed up when no more follow, or 0 before then
+func (l *logThrottle) Pending(now time.Time) int {
+	if l.suppressed == 0 || now.Sub(l.last) < l.interval {
+		return 0
+	}
+	suppressed := l.suppressed
+	l.suppressed = 0
+	return suppressed
+}
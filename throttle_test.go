@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogThrottle(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		Offset     time.Duration
+		Allowed    bool
+		Suppressed int
+	}{
+		{0, true, 0},
+		{100 * time.Millisecond, false, 0},
+		{999 * time.Millisecond, false, 0},
+		{time.Second, true, 2},
+		{1500 * time.Millisecond, false, 0},
+		{5 * time.Second, true, 1},
+		{6 * time.Second, true, 0},
+	}
+
+	throttle := newLogThrottle(time.Second)
+	for _, c := range testCases {
+		allowed, suppressed := throttle.Allow(start.Add(c.Offset))
+		if allowed != c.Allowed || suppressed != c.Suppressed {
+			t.Errorf("At %s expected allowed %v with %d suppressed, got %v with %d", c.Offset, c.Allowed, c.Suppressed, allowed, suppressed)
+		}
+	}
+}
+
+func TestLogThrottlePending(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	throttle := newLogThrottle(time.Second)
+	if pending := throttle.Pending(start); pending != 0 {
+		t.Errorf("Expected nothing pending before any message, got %d", pending)
+	}
+	throttle.Allow(start)
+	throttle.Allow(start.Add(100 * time.Millisecond))
+	throttle.Allow(start.Add(200 * time.Millisecond))
+
+	testCases := []struct {
+		Offset  time.Duration
+		Pending int
+	}{
+		{500 * time.Millisecond, 0},
+		{time.Second, 2},
+		{2 * time.Second, 0},
+	}
+	for _, c := range testCases {
+		if pending := throttle.Pending(start.Add(c.Offset)); pending != c.Pending {
+			t.Errorf("At %s expected %d pending, got %d", c.Offset, c.Pending, pending)
+		}
+	}
+	if allowed, suppressed := throttle.Allow(start.Add(2 * time.Second)); !allowed || suppressed != 0 {
+		t.Errorf("Expected the next message allowed with none suppressed, got %v with %d", allowed, suppressed)
+	}
+}
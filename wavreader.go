@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/azenk/audio/stream"
+)
+
+// wavFormatExtensible is the audio format of WAVE_FORMAT_EXTENSIBLE files, the real format is the
+// first two bytes of the sub format GUID
+const wavFormatExtensible = 0xFFFE
+
+// WAVReader reads the samples of a PCM or IEEE float WAV file, one sample frame at a time
+type WAVReader struct {
+	config    WAVConfig
+	file      *os.File
+	in        io.Reader
+	remaining uint32
+	buf       []byte
+}
+
+// OpenWAVFile opens the WAV file at path and reads its header.  Formats that can be written by
+// CreateWAVFile can be read.
+func OpenWAVFile(path string) (*WAVReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &WAVReader{file: file}
+	if err := r.readHeader(bufio.NewReader(file)); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return r, nil
+}
+
+// readHeader reads chunks up to the start of the data chunk, skipping any that aren't needed
+func (r *WAVReader) readHeader(in *bufio.Reader) error {
+	var riff struct {
+		ChunkID   [4]byte
+		ChunkSize uint32
+		Format    [4]byte
+	}
+	if err := binary.Read(in, binary.LittleEndian, &riff); err != nil {
+		return fmt.Errorf("unable to read RIFF header: %v", err)
+	}
+	if string(riff.ChunkID[:]) != "RIFF" || string(riff.Format[:]) != "WAVE" {
+		return errors.New("not a WAV file")
+	}
+
+	haveFormat := false
+	for {
+		var chunk struct {
+			ID   [4]byte
+			Size uint32
+		}
+		if err := binary.Read(in, binary.LittleEndian, &chunk); err != nil {
+			return fmt.Errorf("no data chunk found: %v", err)
+		}
+
+		switch string(chunk.ID[:]) {
+		case "fmt ":
+			body := make([]byte, chunk.Size)
+			if _, err := io.ReadFull(in, body); err != nil {
+				return fmt.Errorf("unable to read format chunk: %v", err)
+			}
+			if err := r.parseFormat(body); err != nil {
+				return err
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return errors.New("data chunk found before format chunk")
+			}
+			r.in = in
+			r.remaining = chunk.Size
+			r.buf = make([]byte, r.config.Channels*r.config.SampleSizeBytes())
+			return nil
+		default:
+			if _, err := in.Discard(int(chunk.Size)); err != nil {
+				return fmt.Errorf("unable to skip %q chunk: %v", chunk.ID, err)
+			}
+		}
+		// chunks are padded to an even length
+		if chunk.Size%2 == 1 {
+			if _, err := in.Discard(1); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseFormat sets the config from the body of a format chunk
+func (r *WAVReader) parseFormat(body []byte) error {
+	if len(body) < 16 {
+		return fmt.Errorf("short format chunk, %d bytes", len(body))
+	}
+	audioFormat := binary.LittleEndian.Uint16(body[0:2])
+	if audioFormat == wavFormatExtensible && len(body) >= 26 {
+		audioFormat = binary.LittleEndian.Uint16(body[24:26])
+	}
+	r.config = WAVConfig{
+		Channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+		SampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+		Float:         audioFormat == 3,
+		Interleaved:   true,
+	}
+
+	switch {
+	case audioFormat != 1 && audioFormat != 3:
+		return fmt.Errorf("unsupported audio format %d, only PCM and IEEE float are supported", audioFormat)
+	case r.config.Float && r.config.BitsPerSample != 32:
+		return fmt.Errorf("unsupported bits per float sample: %d", r.config.BitsPerSample)
+	case r.config.BitsPerSample != 16 && r.config.BitsPerSample != 24 && r.config.BitsPerSample != 32:
+		return fmt.Errorf("unsupported bits per sample: %d", r.config.BitsPerSample)
+	case r.config.Channels < 1:
+		return fmt.Errorf("unsupported channel count: %d", r.config.Channels)
+	}
+	return nil
+}
+
+// Config returns the format of the file, with Interleaved set as each sample frame read holds one
+// sample per channel
+func (r *WAVReader) Config() WAVConfig {
+	return r.config
+}
+
+// Read returns the next sample frame, one sample per channel scaled to the full range of
+// stream.Sample, or io.EOF after the last one
+func (r *WAVReader) Read() ([]stream.Sample, error) {
+	if r.remaining < uint32(len(r.buf)) {
+		return nil, io.EOF
+	}
+	if _, err := io.ReadFull(r.in, r.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	r.remaining -= uint32(len(r.buf))
+
+	size := r.config.SampleSizeBytes()
+	frame := make([]stream.Sample, r.config.Channels)
+	for c := range frame {
+		frame[c] = r.decodeSample(r.buf[c*size : (c+1)*size])
+	}
+	return frame, nil
+}
+
+// decodeSample is the inverse of WAVWriter.encodeSample
+func (r *WAVReader) decodeSample(buf []byte) stream.Sample {
+	if r.config.Float {
+		v := float64(math.Float32frombits(binary.LittleEndian.Uint32(buf))) * (math.MaxInt32 + 1)
+		return stream.Sample(math.Max(math.MinInt32, math.Min(math.MaxInt32, v)))
+	}
+
+	switch r.config.BitsPerSample {
+	case 16:
+		return stream.Sample(int32(binary.LittleEndian.Uint16(buf)) << 16)
+	case 24:
+		return stream.Sample(int32(uint32(buf[0])<<8 | uint32(buf[1])<<16 | uint32(buf[2])<<24))
+	}
+	return stream.Sample(int32(binary.LittleEndian.Uint32(buf)))
+}
+
+// Close closes the file
+func (r *WAVReader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azenk/audio/stream"
+	"github.com/go-test/deep"
+)
+
+// writeTestWAV writes sample frames, each holding one sample per channel, to a WAV file at path
+func writeTestWAV(t *testing.T, path string, config WAVConfig, frames [][]stream.Sample) {
+	t.Helper()
+	config.Interleaved = true
+	w, err := CreateWAVFile(context.Background(), path, config)
+	if err != nil {
+		t.Fatalf("Unable to create wav file: %v", err)
+	}
+	for _, frame := range frames {
+		w.Stream() <- frame
+	}
+	close(w.Stream())
+	for err := range w.Done() {
+		if err != nil {
+			t.Fatalf("Error writing wav file: %v", err)
+		}
+	}
+}
+
+// readTestWAV returns the format and every sample frame of the WAV file at path
+func readTestWAV(t *testing.T, path string) (WAVConfig, [][]stream.Sample) {
+	t.Helper()
+	r, err := OpenWAVFile(path)
+	if err != nil {
+		t.Fatalf("Unable to open wav file: %v", err)
+	}
+	defer r.Close()
+
+	var frames [][]stream.Sample
+	for {
+		frame, err := r.Read()
+		if err == io.EOF {
+			return r.Config(), frames
+		}
+		if err != nil {
+			t.Fatalf("Error reading wav file: %v", err)
+		}
+		frames = append(frames, frame)
+	}
+}
+
+func TestWAVReader(t *testing.T) {
+	frames := [][]stream.Sample{
+		{0x7FFF0000, -0x80000000},
+		{0x12340000, 0},
+		{-0x40000000, 0x00010000},
+	}
+
+	for _, format := range []string{"S16_LE", "S24_3LE", "S32_LE", "FLOAT_LE"} {
+		t.Run(format, func(st *testing.T) {
+			dir, err := ioutil.TempDir("", "ltcgen")
+			if err != nil {
+				st.Fatalf("Unable to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			bits, float, err := ParseSampleFormat(format)
+			if err != nil {
+				st.Fatalf("Unable to parse format: %v", err)
+			}
+			config := WAVConfig{SampleRate: 44100, BitsPerSample: bits, Float: float, Channels: 2, Interleaved: true}
+			path := filepath.Join(dir, "test.wav")
+			writeTestWAV(st, path, config, frames)
+
+			readConfig, readFrames := readTestWAV(st, path)
+			if diff := deep.Equal(readConfig, config); len(diff) > 0 {
+				st.Errorf("Config doesn't match: %v", diff)
+			}
+			if diff := deep.Equal(readFrames, frames); len(diff) > 0 {
+				st.Errorf("Samples don't match: %v", diff)
+			}
+		})
+	}
+}
+
+func TestWAVReaderSkipsChunks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := []byte{
+		'R', 'I', 'F', 'F', 0x2F, 0x00, 0x00, 0x00, 'W', 'A', 'V', 'E',
+		'L', 'I', 'S', 'T', 0x03, 0x00, 0x00, 0x00, 'a', 'b', 'c', 0x00,
+		'f', 'm', 't', ' ', 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00,
+		0x80, 0xBB, 0x00, 0x00, 0x00, 0x77, 0x01, 0x00, 0x02, 0x00, 0x10, 0x00,
+		'd', 'a', 't', 'a', 0x04, 0x00, 0x00, 0x00,
+		0x34, 0x12, 0x00, 0xC0,
+	}
+	path := filepath.Join(dir, "test.wav")
+	if err := ioutil.WriteFile(path, contents, 0644); err != nil {
+		t.Fatalf("Unable to write wav file: %v", err)
+	}
+
+	config, frames := readTestWAV(t, path)
+	if config.SampleRate != 48000 || config.Channels != 1 || config.BitsPerSample != 16 {
+		t.Errorf("Unexpected config %s", config)
+	}
+	if diff := deep.Equal(frames, [][]stream.Sample{{0x12340000}, {-0x40000000}}); len(diff) > 0 {
+		t.Errorf("Samples don't match: %v", diff)
+	}
+}
+
+func TestWAVReaderErrors(t *testing.T) {
+	header := func(audioFormat, bits byte) []byte {
+		return []byte{
+			'R', 'I', 'F', 'F', 0x24, 0x00, 0x00, 0x00, 'W', 'A', 'V', 'E',
+			'f', 'm', 't', ' ', 0x10, 0x00, 0x00, 0x00, audioFormat, 0x00, 0x01, 0x00,
+			0x80, 0xBB, 0x00, 0x00, 0x00, 0x77, 0x01, 0x00, 0x02, 0x00, bits, 0x00,
+			'd', 'a', 't', 'a', 0x00, 0x00, 0x00, 0x00,
+		}
+	}
+	testCases := []struct {
+		Name     string
+		Contents []byte
+	}{
+		{"Empty", nil},
+		{"NotWAV", []byte("RIFF\x04\x00\x00\x00AVI ")},
+		{"NoData", header(1, 16)[:36]},
+		{"Compressed", header(2, 16)},
+		{"Bits", header(1, 8)},
+		{"FloatBits", header(3, 16)},
+	}
+
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			path := filepath.Join(dir, c.Name+".wav")
+			if err := ioutil.WriteFile(path, c.Contents, 0644); err != nil {
+				st.Fatalf("Unable to write wav file: %v", err)
+			}
+			if r, err := OpenWAVFile(path); err == nil {
+				r.Close()
+				st.Errorf("Expected error opening %s", c.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/azenk/audio/stream"
+)
+
+// wavHeader is the canonical 44 byte RIFF/WAVE header for PCM data
+type wavHeader struct {
+	ChunkID       [4]byte
+	ChunkSize     uint32
+	Format        [4]byte
+	Subchunk1ID   [4]byte
+	Subchunk1Size uint32
+	AudioFormat   uint16
+	NumChannels   uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+	Subchunk2ID   [4]byte
+	Subchunk2Size uint32
+}
+
+// WAVConfig describes the format of a WAV file.  Samples are signed integers of BitsPerSample, 24 bit
+// samples are packed into 3 bytes, or 32 bit IEEE floats if Float is set.  ChannelModes optionally
+// selects what is written to each channel, by default every channel carries the signal.  If
+// Interleaved is set each sample frame sent to a writer already holds one sample per channel and
+// ChannelModes is ignored.
+type WAVConfig struct {
+	SampleRate    int
+	BitsPerSample int
+	Float         bool
+	Channels      int
+	ChannelModes  []ChannelMode
+	Interleaved   bool
+}
+
+// sampleFormats maps ALSA style format names to BitsPerSample and Float
+var sampleFormats = map[string]struct {
+	bitsPerSample int
+	float         bool
+}{
+	"S16_LE":   {16, false},
+	"S24_3LE":  {24, false},
+	"S32_LE":   {32, false},
+	"FLOAT_LE": {32, true},
+}
+
+// ParseSampleFormat returns the bits per sample and whether samples are floating point for a format
+// name, one of S16_LE, S24_3LE, S32_LE or FLOAT_LE
+func ParseSampleFormat(name string) (int, bool, error) {
+	format, ok := sampleFormats[name]
+	if !ok {
+		return 0, false, fmt.Errorf("unknown sample format %q, expected one of S16_LE, S24_3LE, S32_LE or FLOAT_LE", name)
+	}
+	return format.bitsPerSample, format.float, nil
+}
+
+// ChannelMode returns the mode used for a channel
+func (c WAVConfig) ChannelMode(channel int) ChannelMode {
+	if channel < len(c.ChannelModes) {
+		return c.ChannelModes[channel]
+	}
+	return ChannelSignal
+}
+
+// SampleSizeBytes returns the number of bytes used by a single sample on one channel
+func (c WAVConfig) SampleSizeBytes() int {
+	return c.BitsPerSample / 8
+}
+
+// Format returns the ALSA style name of the sample format
+func (c WAVConfig) Format() string {
+	switch {
+	case c.Float:
+		return "FLOAT_LE"
+	case c.BitsPerSample == 24:
+		return "S24_3LE"
+	}
+	return fmt.Sprintf("S%d_LE", c.BitsPerSample)
+}
+
+func (c WAVConfig) String() string {
+	return fmt.Sprintf("Rate: %d Format: %s Channels: %d", c.SampleRate, c.Format(), c.Channels)
+}
+
+func (c WAVConfig) header(dataSize uint32) wavHeader {
+	blockAlign := c.Channels * c.SampleSizeBytes()
+	audioFormat := uint16(1)
+	if c.Float {
+		audioFormat = 3
+	}
+	return wavHeader{
+		ChunkID:       [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     36 + dataSize,
+		Format:        [4]byte{'W', 'A', 'V', 'E'},
+		Subchunk1ID:   [4]byte{'f', 'm', 't', ' '},
+		Subchunk1Size: 16,
+		AudioFormat:   audioFormat,
+		NumChannels:   uint16(c.Channels),
+		SampleRate:    uint32(c.SampleRate),
+		ByteRate:      uint32(c.SampleRate * blockAlign),
+		BlockAlign:    uint16(blockAlign),
+		BitsPerSample: uint16(c.BitsPerSample),
+		Subchunk2ID:   [4]byte{'d', 'a', 't', 'a'},
+		Subchunk2Size: dataSize,
+	}
+}
+
+// validate returns an error if samples can't be written in this format
+func (c WAVConfig) validate() error {
+	if c.BitsPerSample != 16 && c.BitsPerSample != 24 && c.BitsPerSample != 32 {
+		return fmt.Errorf("unsupported bits per sample: %d", c.BitsPerSample)
+	}
+	if c.Float && c.BitsPerSample != 32 {
+		return fmt.Errorf("unsupported bits per float sample: %d", c.BitsPerSample)
+	}
+	if c.Channels < 1 {
+		return fmt.Errorf("unsupported channel count: %d", c.Channels)
+	}
+	if len(c.ChannelModes) != 0 && len(c.ChannelModes) != c.Channels {
+		return fmt.Errorf("got %d channel modes for %d channels", len(c.ChannelModes), c.Channels)
+	}
+	if c.SampleRate < 1 {
+		return fmt.Errorf("unsupported sample rate: %d", c.SampleRate)
+	}
+	return nil
+}
+
+// WAVWriter writes samples to a WAV file, or as raw PCM to any writer, filling the same role as a
+// stream.StreamDevice
+type WAVWriter struct {
+	config   WAVConfig
+	out      io.Writer
+	file     *os.File
+	streamCh chan []stream.Sample
+	doneCh   chan error
+}
+
+// CreateWAVFile creates a WAV file at path and starts writing samples sent on Stream() to it.  The
+// file is finalized once the stream channel is closed or ctx is cancelled.
+func CreateWAVFile(ctx context.Context, path string, config WAVConfig) (*WAVWriter, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAVWriter{
+		config:   config,
+		out:      file,
+		file:     file,
+		streamCh: make(chan []stream.Sample, 1024),
+		doneCh:   make(chan error, 1),
+	}
+	go w.write(ctx)
+	return w, nil
+}
+
+// NewPCMWriter starts writing samples sent on Stream() to out as raw little endian PCM with no
+// header, e.g. to pipe them into another program.  out is flushed but not closed once the stream
+// channel is closed or ctx is cancelled.
+func NewPCMWriter(ctx context.Context, out io.Writer, config WAVConfig) (*WAVWriter, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	w := &WAVWriter{
+		config:   config,
+		out:      out,
+		streamCh: make(chan []stream.Sample, 1024),
+		doneCh:   make(chan error, 1),
+	}
+	go w.write(ctx)
+	return w, nil
+}
+
+// Config returns the format of the samples being written
+func (w *WAVWriter) Config() WAVConfig {
+	return w.config
+}
+
+// Stream returns the channel samples should be sent on, close it to finish the file
+func (w *WAVWriter) Stream() chan []stream.Sample {
+	return w.streamCh
+}
+
+// Done returns a channel that receives any write error and is closed once the file is complete
+func (w *WAVWriter) Done() chan error {
+	return w.doneCh
+}
+
+func (w *WAVWriter) encodeSample(buf []byte, sample stream.Sample) {
+	if w.config.Float {
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(float64(sample)/(math.MaxInt32+1))))
+		return
+	}
+
+	switch w.config.BitsPerSample {
+	case 16:
+		binary.LittleEndian.PutUint16(buf, uint16(int32(sample)>>16))
+	case 24:
+		v := uint32(int32(sample) >> 8)
+		buf[0], buf[1], buf[2] = byte(v), byte(v>>8), byte(v>>16)
+	case 32:
+		binary.LittleEndian.PutUint32(buf, uint32(int32(sample)))
+	}
+}
+
+func (w *WAVWriter) write(ctx context.Context) {
+	defer close(w.doneCh)
+
+	if err := w.writeData(ctx); err != nil {
+		if w.file != nil {
+			w.file.Close()
+		}
+		w.doneCh <- err
+		return
+	}
+
+	if w.file == nil {
+		return
+	}
+	if err := w.file.Close(); err != nil {
+		w.doneCh <- err
+	}
+}
+
+func (w *WAVWriter) writeData(ctx context.Context) error {
+	if w.file != nil {
+		if err := binary.Write(w.file, binary.LittleEndian, w.config.header(0)); err != nil {
+			return err
+		}
+	}
+
+	out := bufio.NewWriter(w.out)
+	buf := make([]byte, w.config.SampleSizeBytes())
+	// with a second generator each pair of samples is one sample frame
+	generators := generatorCount(w.config.ChannelModes)
+	if w.config.Interleaved {
+		generators = w.config.Channels
+	}
+	var dataSize uint32
+
+	for done := false; !done; {
+		select {
+		case samples, more := <-w.streamCh:
+			if !more {
+				done = true
+				break
+			}
+			for i := 0; i+generators <= len(samples); i += generators {
+				frame := samples[i : i+generators]
+				for c := 0; c < w.config.Channels; c++ {
+					if w.config.Interleaved {
+						w.encodeSample(buf, frame[c])
+					} else {
+						w.encodeSample(buf, w.config.ChannelMode(c).FrameSample(frame))
+					}
+					if _, err := out.Write(buf); err != nil {
+						return err
+					}
+					dataSize += uint32(len(buf))
+				}
+			}
+			if w.file == nil && len(w.streamCh) == 0 {
+				// a reader on the other end of a pipe gets samples as soon as they're encoded
+				if err := out.Flush(); err != nil {
+					return err
+				}
+			}
+		case <-ctx.Done():
+			done = true
+		}
+	}
+
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if w.file == nil {
+		return nil
+	}
+
+	if _, err := w.file.Seek(0, 0); err != nil {
+		return err
+	}
+	return binary.Write(w.file, binary.LittleEndian, w.config.header(dataSize))
+}
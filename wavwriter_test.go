@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/azenk/audio/stream"
+	"github.com/go-test/deep"
+)
+
+func TestWAVWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.wav")
+	w, err := CreateWAVFile(context.Background(), path, WAVConfig{SampleRate: 48000, BitsPerSample: 16, Channels: 2})
+	if err != nil {
+		t.Fatalf("Unable to create wav file: %v", err)
+	}
+
+	w.Stream() <- []stream.Sample{0x7FFFFFFF, -0x80000000}
+	w.Stream() <- []stream.Sample{0x12345678}
+	close(w.Stream())
+	for err := range w.Done() {
+		if err != nil {
+			t.Fatalf("Error writing wav file: %v", err)
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read wav file: %v", err)
+	}
+
+	expected := []byte{
+		'R', 'I', 'F', 'F', 0x30, 0x00, 0x00, 0x00, 'W', 'A', 'V', 'E',
+		'f', 'm', 't', ' ', 0x10, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00,
+		0x80, 0xBB, 0x00, 0x00, 0x00, 0xEE, 0x02, 0x00, 0x04, 0x00, 0x10, 0x00,
+		'd', 'a', 't', 'a', 0x0C, 0x00, 0x00, 0x00,
+		0xFF, 0x7F, 0xFF, 0x7F,
+		0x00, 0x80, 0x00, 0x80,
+		0x34, 0x12, 0x34, 0x12,
+	}
+	if diff := deep.Equal(contents, expected); len(diff) > 0 {
+		t.Error("WAV file doesn't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestWAVWriterChannelModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.wav")
+	config := WAVConfig{
+		SampleRate:    48000,
+		BitsPerSample: 16,
+		Channels:      3,
+		ChannelModes:  []ChannelMode{ChannelSignal, ChannelInverted, ChannelSilent},
+	}
+	w, err := CreateWAVFile(context.Background(), path, config)
+	if err != nil {
+		t.Fatalf("Unable to create wav file: %v", err)
+	}
+
+	w.Stream() <- []stream.Sample{0x12345678}
+	close(w.Stream())
+	for err := range w.Done() {
+		if err != nil {
+			t.Fatalf("Error writing wav file: %v", err)
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read wav file: %v", err)
+	}
+
+	expected := []byte{0x34, 0x12, 0xCB, 0xED, 0x00, 0x00}
+	if diff := deep.Equal(contents[44:], expected); len(diff) > 0 {
+		t.Error("WAV samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestWAVWriterLTCChannel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	modes, err := ltcChannelModes(4, 2)
+	if err != nil {
+		t.Fatalf("Unable to select channel modes: %v", err)
+	}
+	path := filepath.Join(dir, "test.wav")
+	config := WAVConfig{
+		SampleRate:    48000,
+		BitsPerSample: 16,
+		Channels:      len(modes),
+		ChannelModes:  modes,
+	}
+	w, err := CreateWAVFile(context.Background(), path, config)
+	if err != nil {
+		t.Fatalf("Unable to create wav file: %v", err)
+	}
+
+	w.Stream() <- []stream.Sample{0x12345678, -0x12345678}
+	close(w.Stream())
+	for err := range w.Done() {
+		if err != nil {
+			t.Fatalf("Error writing wav file: %v", err)
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read wav file: %v", err)
+	}
+
+	// each sample frame has the signal on the third channel and silence on the others
+	expected := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x34, 0x12, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xCB, 0xED, 0x00, 0x00,
+	}
+	if diff := deep.Equal(contents[44:], expected); len(diff) > 0 {
+		t.Error("WAV samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestWAVWriterSecondGenerator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ltcgen")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.wav")
+	config := WAVConfig{
+		SampleRate:    48000,
+		BitsPerSample: 16,
+		Channels:      2,
+		ChannelModes:  []ChannelMode{ChannelSignal, ChannelInverted2},
+	}
+	w, err := CreateWAVFile(context.Background(), path, config)
+	if err != nil {
+		t.Fatalf("Unable to create wav file: %v", err)
+	}
+
+	// each pair of samples is one sample frame, the first from each generator
+	w.Stream() <- []stream.Sample{0x12345678, 0x11110000, 0x22220000, 0x33330000}
+	close(w.Stream())
+	for err := range w.Done() {
+		if err != nil {
+			t.Fatalf("Error writing wav file: %v", err)
+		}
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read wav file: %v", err)
+	}
+
+	expected := []byte{0x34, 0x12, 0xEF, 0xEE, 0x22, 0x22, 0xCD, 0xCC}
+	if diff := deep.Equal(contents[44:], expected); len(diff) > 0 {
+		t.Error("WAV samples don't match expected value:")
+		for _, l := range diff {
+			t.Log(l)
+		}
+	}
+}
+
+func TestWAVWriterConfig(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Config WAVConfig
+	}{
+		{"BitsPerSample", WAVConfig{SampleRate: 48000, BitsPerSample: 20, Channels: 1}},
+		{"Float", WAVConfig{SampleRate: 48000, BitsPerSample: 16, Float: true, Channels: 1}},
+		{"Channels", WAVConfig{SampleRate: 48000, BitsPerSample: 16, Channels: 0}},
+		{"SampleRate", WAVConfig{SampleRate: 0, BitsPerSample: 16, Channels: 1}},
+		{"ChannelModes", WAVConfig{SampleRate: 48000, BitsPerSample: 16, Channels: 2, ChannelModes: []ChannelMode{ChannelSignal}}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Name, func(st *testing.T) {
+			if _, err := CreateWAVFile(context.Background(), os.DevNull, c.Config); err == nil {
+				st.Errorf("Expected error for config %s", c.Config)
+			}
+		})
+	}
+}
+
+func TestWAVWriterFormats(t *testing.T) {
+	testCases := []struct {
+		Format   string
+		Expected []byte
+	}{
+		{"S16_LE", []byte{0x34, 0x12, 0x00, 0xC0}},
+		{"S24_3LE", []byte{0x56, 0x34, 0x12, 0x00, 0x00, 0xC0}},
+		{"S32_LE", []byte{0x78, 0x56, 0x34, 0x12, 0x00, 0x00, 0x00, 0xC0}},
+		// 0x12345678 is 0.142222 of full scale
+		{"FLOAT_LE", []byte{0xB4, 0xA2, 0x11, 0x3E, 0x00, 0x00, 0x00, 0xBF}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Format, func(st *testing.T) {
+			dir, err := ioutil.TempDir("", "ltcgen")
+			if err != nil {
+				st.Fatalf("Unable to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			bits, float, err := ParseSampleFormat(c.Format)
+			if err != nil {
+				st.Fatalf("Unable to parse format: %v", err)
+			}
+			config := WAVConfig{SampleRate: 48000, BitsPerSample: bits, Float: float, Channels: 1}
+			if config.Format() != c.Format {
+				st.Errorf("Expected format %s, got %s", c.Format, config.Format())
+			}
+
+			path := filepath.Join(dir, "test.wav")
+			w, err := CreateWAVFile(context.Background(), path, config)
+			if err != nil {
+				st.Fatalf("Unable to create wav file: %v", err)
+			}
+			w.Stream() <- []stream.Sample{0x12345678, -0x40000000}
+			close(w.Stream())
+			for err := range w.Done() {
+				if err != nil {
+					st.Fatalf("Error writing wav file: %v", err)
+				}
+			}
+
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				st.Fatalf("Unable to read wav file: %v", err)
+			}
+			if diff := deep.Equal(contents[44:], c.Expected); len(diff) > 0 {
+				st.Error("WAV samples don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+
+			// fmt chunk audio format is 1 for PCM and 3 for IEEE float
+			expectedFormat := byte(1)
+			if float {
+				expectedFormat = 3
+			}
+			if contents[20] != expectedFormat {
+				st.Errorf("Expected audio format %d, got %d", expectedFormat, contents[20])
+			}
+		})
+	}
+
+	if _, _, err := ParseSampleFormat("S8"); err == nil {
+		t.Errorf("Expected error for unknown format")
+	}
+}
+
+func TestPCMWriter(t *testing.T) {
+	testCases := []struct {
+		Format   string
+		Channels []ChannelMode
+		Expected []byte
+	}{
+		{"S16_LE", []ChannelMode{ChannelSignal}, []byte{0x34, 0x12, 0x00, 0xC0}},
+		{"S24_3LE", []ChannelMode{ChannelSignal, ChannelSilent}, []byte{0x56, 0x34, 0x12, 0, 0, 0, 0x00, 0x00, 0xC0, 0, 0, 0}},
+		{"FLOAT_LE", []ChannelMode{ChannelSignal}, []byte{0xB4, 0xA2, 0x11, 0x3E, 0x00, 0x00, 0x00, 0xBF}},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.Format, func(st *testing.T) {
+			bits, float, err := ParseSampleFormat(c.Format)
+			if err != nil {
+				st.Fatalf("Unable to parse format: %v", err)
+			}
+			var out bytes.Buffer
+			config := WAVConfig{SampleRate: 48000, BitsPerSample: bits, Float: float, Channels: len(c.Channels), ChannelModes: c.Channels}
+			w, err := NewPCMWriter(context.Background(), &out, config)
+			if err != nil {
+				st.Fatalf("Unable to create PCM writer: %v", err)
+			}
+			w.Stream() <- []stream.Sample{0x12345678}
+			w.Stream() <- []stream.Sample{-0x40000000}
+			close(w.Stream())
+			for err := range w.Done() {
+				if err != nil {
+					st.Fatalf("Error writing PCM: %v", err)
+				}
+			}
+
+			if diff := deep.Equal(out.Bytes(), c.Expected); len(diff) > 0 {
+				st.Error("PCM samples don't match expected value:")
+				for _, l := range diff {
+					st.Log(l)
+				}
+			}
+		})
+	}
+
+	if _, err := NewPCMWriter(context.Background(), &bytes.Buffer{}, WAVConfig{SampleRate: 48000, BitsPerSample: 8, Channels: 1}); err == nil {
+		t.Errorf("Expected error for 8 bit samples")
+	}
+}